@@ -3,7 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -14,12 +14,22 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	"gorm.io/gorm"
 
+	"github.com/akhilbidhuri/taskkr/internal/auth"
 	"github.com/akhilbidhuri/taskkr/internal/config"
 	"github.com/akhilbidhuri/taskkr/internal/handler"
+	"github.com/akhilbidhuri/taskkr/internal/health"
+	"github.com/akhilbidhuri/taskkr/internal/logger"
+	"github.com/akhilbidhuri/taskkr/internal/metrics"
 	appMiddleware "github.com/akhilbidhuri/taskkr/internal/middleware"
+	"github.com/akhilbidhuri/taskkr/internal/migrate"
 	"github.com/akhilbidhuri/taskkr/internal/repository"
+	"github.com/akhilbidhuri/taskkr/internal/repository/gormrepo"
+	"github.com/akhilbidhuri/taskkr/internal/repository/memory"
 	"github.com/akhilbidhuri/taskkr/internal/repository/postgres"
+	"github.com/akhilbidhuri/taskkr/internal/repository/sqlite"
 	"github.com/akhilbidhuri/taskkr/internal/service"
+	"github.com/akhilbidhuri/taskkr/internal/tracing"
+	"github.com/akhilbidhuri/taskkr/migrations"
 
 	_ "github.com/akhilbidhuri/taskkr/docs" // generated docs
 
@@ -27,27 +37,149 @@ import (
 )
 
 var cfg *config.Config
+var appLogger *slog.Logger
 var db *gorm.DB
 var taskRepo repository.TaskRepository
 var taskService *service.TaskService
 var taskHandler *handler.TaskHandler
+var labelRepo repository.LabelRepository
+var labelService *service.LabelService
+var labelHandler *handler.LabelHandler
+var workflowRepo repository.WorkflowRepository
+var workflowService *service.WorkflowService
+var workflowHandler *handler.WorkflowHandler
+var projectRepo repository.ProjectRepository
+var projectService *service.ProjectService
+var projectHandler *handler.ProjectHandler
+var apiKeyRepo repository.APIKeyRepository
+var apiKeyService *service.APIKeyService
+var apiKeyHandler *handler.APIKeyHandler
+var tokenVerifier *auth.TokenVerifier
+var appMetrics *metrics.Metrics
+var shutdownTracing func(context.Context) error
+var healthChecker *health.Checker
+var migrator *migrate.Migrator
 
-func initialize() {
-	log.Println("init method run")
+// initDatabase loads the configuration and connects to the database, it's all the migrate command needs
+func initDatabase() {
 	// Load configuration
 	cfg = config.Load()
 
+	// Initialize logger
+	appLogger = logger.New(os.Stdout, cfg.LogLevel, cfg.LogFormat)
+	slog.SetDefault(appLogger)
+
 	// Initialize database
-	db = postgres.NewPostgresDB(cfg)
+	switch cfg.DBDriver {
+	case config.DriverMemory:
+		// nothing to connect to
+		return
+	case config.DriverPostgres:
+		db = postgres.NewPostgresDB(cfg)
+	case config.DriverSQLite:
+		db = sqlite.NewSQLiteDB(cfg)
+	default:
+		fatal("invalid configuration", fmt.Errorf("unknown DB_DRIVER %q", cfg.DBDriver))
+	}
+
+	// Initialize migrations
+	sqlDB, err := db.DB()
+	if err != nil {
+		fatal("failed to get database handle", err)
+	}
+	files, err := migrations.For(cfg.DBDriver)
+	if err != nil {
+		fatal("failed to load migrations", err)
+	}
+	migrator, err = migrate.New(sqlDB, cfg.DBDriver, files)
+	if err != nil {
+		fatal("failed to load migrations", err)
+	}
+}
+
+func initialize() {
+	initDatabase()
+
+	// Initialize tracing
+	var err error
+	shutdownTracing, err = tracing.Setup(context.Background(), cfg)
+	if err != nil {
+		fatal("failed to setup tracing", err)
+	}
+
+	// Initialize token verifier
+	tokenVerifier, err = auth.NewTokenVerifier(cfg.JWTSecret, cfg.JWTPublicKeyPath, cfg.JWTIssuer, cfg.JWTAudience, cfg.JWTAllowHS256WithRS256)
+	if err != nil {
+		fatal("failed to setup auth", err)
+	}
+
+	// Initialize health checks and metrics
+	healthChecker = health.New(cfg.HealthCheckTimeout)
+	appMetrics = metrics.New()
 
 	// Initialize repository
-	taskRepo = postgres.NewTaskRepository(db)
+	if cfg.DBDriver == config.DriverMemory {
+		slog.Warn("using in-memory storage, data is lost when the server stops")
+		store := memory.NewDB()
+		taskRepo = memory.NewTaskRepository(store)
+		labelRepo = memory.NewLabelRepository(store)
+		workflowRepo = memory.NewWorkflowRepository(store)
+		projectRepo = memory.NewProjectRepository(store)
+		apiKeyRepo = memory.NewAPIKeyRepository(store)
+	} else {
+		if cfg.MigrateOnStart {
+			if _, err := migrator.Up(context.Background()); err != nil {
+				fatal("failed to migrate database", err)
+			}
+		}
+
+		healthChecker.AddReadinessCheck("database", gormrepo.PingCheck(db))
+		healthChecker.AddReadinessCheck("migrations", migrator.Check)
+
+		dbName, dbSystem := cfg.DBName, tracing.DBSystemPostgres
+		if cfg.DBDriver == config.DriverSQLite {
+			dbName, dbSystem = cfg.SQLitePath, tracing.DBSystemSQLite
+		}
+		if err := appMetrics.InstrumentGorm(db, dbName); err != nil {
+			fatal("failed to instrument database", err)
+		}
+		if err := tracing.InstrumentGorm(db, dbSystem); err != nil {
+			fatal("failed to instrument database", err)
+		}
+
+		taskRepo = gormrepo.NewTaskRepository(db)
+		labelRepo = gormrepo.NewLabelRepository(db)
+		workflowRepo = gormrepo.NewWorkflowRepository(db)
+		projectRepo = gormrepo.NewProjectRepository(db)
+		apiKeyRepo = gormrepo.NewAPIKeyRepository(db)
+	}
+
+	if err := appMetrics.Register(metrics.NewTaskCollector(taskRepo)); err != nil {
+		fatal("failed to register task metrics", err)
+	}
 
 	// Initialize service
-	taskService = service.NewTaskService(taskRepo)
+	var transitions []service.TaskTransition
+	if cfg.TaskTransitions != "" {
+		if transitions, err = service.ParseTransitions(cfg.TaskTransitions); err != nil {
+			fatal("invalid TASK_TRANSITIONS", err)
+		}
+	}
+	taskService = service.NewTaskService(taskRepo, workflowRepo, projectRepo, service.TaskOptions{
+		RequireChildrenCompleted: cfg.RequireChildrenCompleted,
+		Transitions:              transitions,
+	})
+	labelService = service.NewLabelService(labelRepo)
+	workflowService = service.NewWorkflowService(workflowRepo)
+	projectService = service.NewProjectService(projectRepo)
+	apiKeyService = service.NewAPIKeyService(apiKeyRepo)
 
 	// Initialize handler
 	taskHandler = handler.NewTaskHandler(taskService)
+	labelHandler = handler.NewLabelHandler(labelService)
+	workflowHandler = handler.NewWorkflowHandler(workflowService)
+	projectHandler = handler.NewProjectHandler(projectService, taskHandler)
+	apiKeyHandler = handler.NewAPIKeyHandler(apiKeyService)
 
 }
 
@@ -56,14 +188,28 @@ func initialize() {
 // @description Service for managing tasks
 // @host localhost:8080
 // @BasePath /api/v1
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Bearer token or API key, e.g. "Bearer {jwt}" or "Bearer tk_..."
+// @securityDefinitions.apikey APIKeyAuth
+// @in header
+// @name X-API-Key
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+
 	initialize()
 	// Setup router
 	r := chi.NewRouter()
 
-	r.Use(middleware.Logger)
+	r.Use(appMiddleware.RequestID)
+	r.Use(appMiddleware.RequestLogger(appLogger))
+	r.Use(appMiddleware.Metrics(appMetrics))
+	r.Use(appMiddleware.Tracing)
 	r.Use(middleware.Recoverer)
-	// r.Use(appMiddleware.RequestID)
 	// r.Use(middleware.RealIP)
 	r.Use(middleware.Timeout(60 * time.Second))
 	r.Use(appMiddleware.CORS)
@@ -73,15 +219,22 @@ func main() {
 		httpSwagger.URL("http://localhost:"+cfg.ServerPort+"/swagger/doc.json"), // The url pointing to API definition
 	))
 
-	// Health check
-	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
-	})
+	// Prometheus metrics
+	r.Handle("/metrics", appMetrics.Handler())
+
+	// Health checks, add ?verbose for the status of each check
+	r.Get("/livez", healthChecker.LiveHandler)
+	r.Get("/readyz", healthChecker.ReadyHandler)
+	r.Get("/health", healthChecker.ReadyHandler)
 
 	// API routes
 	r.Route("/api/v1", func(r chi.Router) {
+		r.Use(appMiddleware.Auth(tokenVerifier, apiKeyService))
 		r.Mount("/tasks", taskHandler.Routes())
+		r.Mount("/labels", labelHandler.Routes())
+		r.Mount("/workflows", workflowHandler.Routes())
+		r.Mount("/projects", projectHandler.Routes())
+		r.Mount("/admin/api-keys", apiKeyHandler.Routes())
 	})
 
 	srv := &http.Server{
@@ -94,9 +247,9 @@ func main() {
 
 	// Graceful shutdown
 	go func() {
-		log.Printf("Server is running on port %s", cfg.ServerPort)
+		slog.Info("server is running", "port", cfg.ServerPort)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("listen: %s", err)
+			fatal("listen failed", err)
 		}
 	}()
 
@@ -104,14 +257,27 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Println("Shutting down server...")
+	slog.Info("shutting down server", "delay", cfg.ShutdownDelay)
+
+	// Fail readiness first so the load balancer stops sending new requests,
+	// in flight and late requests are still served until Shutdown drains them
+	healthChecker.SetShuttingDown()
+	time.Sleep(cfg.ShutdownDelay)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		fatal("server forced to shutdown", err)
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("failed to flush traces", "error", err)
 	}
 
-	log.Println("Server exited gracefully")
+	slog.Info("server exited gracefully")
+}
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
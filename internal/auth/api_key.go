@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// APIKeyPrefix marks a bearer credential as an API key instead of a JWT
+const APIKeyPrefix = "tk_"
+
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// KeyAuthenticator resolves a plain API key to the identity it was issued for
+type KeyAuthenticator interface {
+	Authenticate(ctx context.Context, key string) (*Identity, error)
+}
+
+// GenerateAPIKey returns a new random key
+func GenerateAPIKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return APIKeyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashAPIKey returns the hex encoded SHA-256 of the key, keys are random
+// so a fast hash is enough and allows looking them up by hash
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsAPIKey reports whether the credential looks like an API key
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, APIKeyPrefix)
+}
@@ -0,0 +1,28 @@
+package auth
+
+import "context"
+
+// Identity describes the authenticated caller of a request
+type Identity struct {
+	UserID  uint   `json:"user_id"`
+	Subject string `json:"subject"`
+	Role    Role   `json:"role"`
+}
+
+// Can reports whether the identity's role grants the permission
+func (i *Identity) Can(perm Permission) bool {
+	return i != nil && i.Role.Has(perm)
+}
+
+type identityKey struct{}
+
+// NewContext returns a copy of ctx carrying the given identity
+func NewContext(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// FromContext returns the identity stored in ctx, if any
+func FromContext(ctx context.Context) (*Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(*Identity)
+	return identity, ok && identity != nil
+}
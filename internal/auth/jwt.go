@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrMissingToken = errors.New("missing bearer token")
+	ErrInvalidToken = errors.New("invalid token")
+)
+
+// placeholderSecret is the example JWT_SECRET of .env.example, anyone can sign tokens with it
+const placeholderSecret = "your-secret-key"
+
+// Claims are the JWT claims understood by the service, callers without a role claim are members
+type Claims struct {
+	jwt.RegisteredClaims
+	Role Role `json:"role,omitempty"`
+}
+
+// TokenVerifier validates signed JWTs and turns their claims into an Identity.
+// HS256 tokens are checked against the shared secret and RS256 tokens against
+// the configured public key, only the algorithms with a configured key are accepted.
+// Both are only accepted together when explicitly allowed.
+type TokenVerifier struct {
+	secret    []byte
+	publicKey *rsa.PublicKey
+	parser    *jwt.Parser
+}
+
+// NewTokenVerifier builds a verifier from a shared HMAC secret or a path to a PEM
+// encoded RSA public key, issuer and audience are checked when set. Configuring both
+// fails unless allowHS256WithRS256 is set, a verifier trusting an RSA key shouldn't
+// also accept tokens anyone knowing the secret can sign.
+func NewTokenVerifier(secret, publicKeyPath, issuer, audience string, allowHS256WithRS256 bool) (*TokenVerifier, error) {
+	if secret == placeholderSecret {
+		return nil, errors.New("jwt secret is the example placeholder, set JWT_SECRET to a random value")
+	}
+	if secret != "" && publicKeyPath != "" && !allowHS256WithRS256 {
+		return nil, errors.New("both a jwt secret and a public key are configured, unset JWT_SECRET or set JWT_ALLOW_HS256_WITH_RS256=true")
+	}
+
+	v := &TokenVerifier{}
+	var methods []string
+
+	if secret != "" {
+		v.secret = []byte(secret)
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if publicKeyPath != "" {
+		pemBytes, err := os.ReadFile(publicKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("read jwt public key: %w", err)
+		}
+		key, err := jwt.ParseRSAPublicKeyFromPEM(pemBytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse jwt public key: %w", err)
+		}
+		v.publicKey = key
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+	if len(methods) == 0 {
+		return nil, errors.New("no jwt secret or public key configured")
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+	}
+	if issuer != "" {
+		opts = append(opts, jwt.WithIssuer(issuer))
+	}
+	if audience != "" {
+		opts = append(opts, jwt.WithAudience(audience))
+	}
+	v.parser = jwt.NewParser(opts...)
+
+	return v, nil
+}
+
+// Verify parses the raw token, checks signature and expiry and returns the caller identity.
+// The subject claim must hold the numeric id of the user.
+func (v *TokenVerifier) Verify(raw string) (*Identity, error) {
+	if raw == "" {
+		return nil, ErrMissingToken
+	}
+
+	claims := &Claims{}
+	_, err := v.parser.ParseWithClaims(raw, claims, v.keyFunc)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, fmt.Errorf("%w: token expired", ErrInvalidToken)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	userID, err := strconv.ParseUint(claims.Subject, 10, 64)
+	if err != nil || userID == 0 {
+		return nil, fmt.Errorf("%w: subject is not a valid user id", ErrInvalidToken)
+	}
+
+	role := claims.Role
+	if role == "" {
+		role = RoleMember
+	}
+	if !role.Valid() {
+		return nil, fmt.Errorf("%w: unknown role %q", ErrInvalidToken, role)
+	}
+
+	return &Identity{
+		UserID:  uint(userID),
+		Subject: claims.Subject,
+		Role:    role,
+	}, nil
+}
+
+func (v *TokenVerifier) keyFunc(token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if v.secret != nil {
+			return v.secret, nil
+		}
+	case *jwt.SigningMethodRSA:
+		if v.publicKey != nil {
+			return v.publicKey, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+}
@@ -0,0 +1,197 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSecret = "test-secret"
+
+// rsaKey generates a key pair and writes its public key where NewTokenVerifier can read it
+func rsaKey(t *testing.T) (*rsa.PrivateKey, string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "jwt.pub")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+	return key, path
+}
+
+func claims(sub string) *Claims {
+	return &Claims{RegisteredClaims: jwt.RegisteredClaims{
+		Subject:   sub,
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, key interface{}, c jwt.Claims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, c).SignedString(key)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return token
+}
+
+func TestNewTokenVerifier(t *testing.T) {
+	_, keyPath := rsaKey(t)
+	tests := []struct {
+		name      string
+		secret    string
+		keyPath   string
+		allowBoth bool
+		wantErr   bool
+	}{
+		{name: "secret", secret: testSecret},
+		{name: "public key", keyPath: keyPath},
+		{name: "both allowed", secret: testSecret, keyPath: keyPath, allowBoth: true},
+		{name: "both", secret: testSecret, keyPath: keyPath, wantErr: true},
+		{name: "placeholder secret", secret: "your-secret-key", wantErr: true},
+		{name: "nothing", wantErr: true},
+		{name: "missing key file", keyPath: filepath.Join(t.TempDir(), "missing.pub"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTokenVerifier(tt.secret, tt.keyPath, "", "", tt.allowBoth)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewTokenVerifier() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTokenVerifierHS256(t *testing.T) {
+	v, err := NewTokenVerifier(testSecret, "", "", "", false)
+	if err != nil {
+		t.Fatalf("NewTokenVerifier: %v", err)
+	}
+	admin := claims("7")
+	admin.Role = RoleAdmin
+	expired := claims("7")
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+	noExpiry := claims("7")
+	noExpiry.ExpiresAt = nil
+	unknownRole := claims("7")
+	unknownRole.Role = "root"
+	valid := sign(t, jwt.SigningMethodHS256, []byte(testSecret), claims("7"))
+	parts := strings.Split(valid, ".")
+
+	tests := []struct {
+		name  string
+		token string
+		want  *Identity
+		err   error
+	}{
+		{name: "valid", token: valid, want: &Identity{UserID: 7, Subject: "7", Role: RoleMember}},
+		{name: "role", token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), admin), want: &Identity{UserID: 7, Subject: "7", Role: RoleAdmin}},
+		{name: "missing", token: "", err: ErrMissingToken},
+		{name: "expired", token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), expired), err: ErrInvalidToken},
+		{name: "without expiry", token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), noExpiry), err: ErrInvalidToken},
+		{name: "other secret", token: sign(t, jwt.SigningMethodHS256, []byte("other"), claims("7")), err: ErrInvalidToken},
+		{name: "tampered claims", token: parts[0] + "." + jwtSegment(`{"sub":"1","exp":4102444800,"role":"admin"}`) + "." + parts[2], err: ErrInvalidToken},
+		{name: "alg none", token: sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, claims("7")), err: ErrInvalidToken},
+		{name: "HS512", token: sign(t, jwt.SigningMethodHS512, []byte(testSecret), claims("7")), err: ErrInvalidToken},
+		{name: "missing sub", token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), claims("")), err: ErrInvalidToken},
+		{name: "non-numeric sub", token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), claims("alice")), err: ErrInvalidToken},
+		{name: "zero sub", token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), claims("0")), err: ErrInvalidToken},
+		{name: "unknown role", token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), unknownRole), err: ErrInvalidToken},
+		{name: "garbage", token: "not.a.jwt", err: ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := v.Verify(tt.token)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.err)
+			}
+			if tt.want != nil && *got != *tt.want {
+				t.Errorf("Verify() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenVerifierRS256(t *testing.T) {
+	key, keyPath := rsaKey(t)
+	other, _ := rsaKey(t)
+	pub, err := os.ReadFile(keyPath)
+	if err != nil {
+		t.Fatalf("read key: %v", err)
+	}
+	v, err := NewTokenVerifier("", keyPath, "taskkr-auth", "taskkr", false)
+	if err != nil {
+		t.Fatalf("NewTokenVerifier: %v", err)
+	}
+	scoped := func(sub, issuer, audience string) *Claims {
+		c := claims(sub)
+		c.Issuer = issuer
+		c.Audience = jwt.ClaimStrings{audience}
+		return c
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{name: "valid", token: sign(t, jwt.SigningMethodRS256, key, scoped("7", "taskkr-auth", "taskkr"))},
+		{name: "other key", token: sign(t, jwt.SigningMethodRS256, other, scoped("7", "taskkr-auth", "taskkr")), err: ErrInvalidToken},
+		{name: "wrong issuer", token: sign(t, jwt.SigningMethodRS256, key, scoped("7", "someone", "taskkr")), err: ErrInvalidToken},
+		{name: "wrong audience", token: sign(t, jwt.SigningMethodRS256, key, scoped("7", "taskkr-auth", "other")), err: ErrInvalidToken},
+		{name: "HS256", token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), scoped("7", "taskkr-auth", "taskkr")), err: ErrInvalidToken},
+		// the public key is no secret, HMAC tokens signed with it must not pass
+		{name: "HS256 with the public key", token: sign(t, jwt.SigningMethodHS256, pub, scoped("7", "taskkr-auth", "taskkr")), err: ErrInvalidToken},
+		{name: "alg none", token: sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, scoped("7", "taskkr-auth", "taskkr")), err: ErrInvalidToken},
+		{name: "non-numeric sub", token: sign(t, jwt.SigningMethodRS256, key, scoped("svc-batch", "taskkr-auth", "taskkr")), err: ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := v.Verify(tt.token)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.err)
+			}
+			if tt.err == nil && got.UserID != 7 {
+				t.Errorf("Verify() = %+v, want user 7", got)
+			}
+		})
+	}
+}
+
+func TestTokenVerifierHS256WithRS256(t *testing.T) {
+	key, keyPath := rsaKey(t)
+	v, err := NewTokenVerifier(testSecret, keyPath, "", "", true)
+	if err != nil {
+		t.Fatalf("NewTokenVerifier: %v", err)
+	}
+	for _, token := range []string{
+		sign(t, jwt.SigningMethodHS256, []byte(testSecret), claims("7")),
+		sign(t, jwt.SigningMethodRS256, key, claims("7")),
+	} {
+		if _, err := v.Verify(token); err != nil {
+			t.Errorf("Verify() = %v, want both algorithms accepted", err)
+		}
+	}
+}
+
+// jwtSegment encodes a JSON object as a JWT segment
+func jwtSegment(json string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(json))
+}
@@ -0,0 +1,48 @@
+package auth
+
+// Role groups the permissions granted to a caller
+type Role string
+
+const (
+	RoleAdmin    Role = "admin"
+	RoleMember   Role = "member"
+	RoleReadOnly Role = "read_only"
+)
+
+// Permission is a single action a route or service method can require
+type Permission string
+
+const (
+	// PermTaskRead allows reading the caller's own tasks
+	PermTaskRead Permission = "tasks:read"
+	// PermTaskWrite allows creating, updating and deleting the caller's own tasks
+	PermTaskWrite Permission = "tasks:write"
+	// PermTaskReadAll extends reads to the tasks of every user
+	PermTaskReadAll Permission = "tasks:read_all"
+	// PermTaskWriteAll extends writes to the tasks of every user
+	PermTaskWriteAll Permission = "tasks:write_all"
+	// PermAPIKeyManage allows issuing, listing and revoking API keys
+	PermAPIKeyManage Permission = "api_keys:manage"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleAdmin:    {PermTaskRead, PermTaskWrite, PermTaskReadAll, PermTaskWriteAll, PermAPIKeyManage},
+	RoleMember:   {PermTaskRead, PermTaskWrite},
+	RoleReadOnly: {PermTaskRead, PermTaskReadAll},
+}
+
+// Valid reports whether r is one of the known roles
+func (r Role) Valid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// Has reports whether the role grants the permission
+func (r Role) Has(perm Permission) bool {
+	for _, p := range rolePermissions[r] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
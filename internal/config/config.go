@@ -1,38 +1,107 @@
 package config
 
 import (
-	"log"
+	"log/slog"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// Storage drivers selectable with DB_DRIVER
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+	DriverMemory   = "memory"
+)
+
 type Config struct {
+	// DBDriver selects the storage backend, the in-memory one needs no database
+	// and loses all data on restart, it's meant for tests and local development
+	DBDriver   string
 	DBHost     string
 	DBPort     string
 	DBUser     string
 	DBPassword string
 	DBName     string
+	// SQLitePath is the database file used by the sqlite driver
+	SQLitePath string
 	ServerPort string
 	JWTSecret  string
+
+	// MigrateOnStart applies pending migrations when the server starts
+	MigrateOnStart bool
+
+	// RequireChildrenCompleted refuses to complete a task while one of its subtasks isn't
+	RequireChildrenCompleted bool
+	// TaskTransitions overrides the allowed task status changes, e.g.
+	// "pending>in_process,in_process>completed+resolution", empty keeps the defaults
+	TaskTransitions string
+
+	// ShutdownDelay is how long the server keeps serving after reporting not ready,
+	// giving load balancers time to stop routing to it
+	ShutdownDelay time.Duration
+	// HealthCheckTimeout bounds each readiness check
+	HealthCheckTimeout time.Duration
+
+	// JWTPublicKeyPath points to a PEM encoded RSA public key used to verify RS256 tokens
+	JWTPublicKeyPath string
+	JWTIssuer        string
+	JWTAudience      string
+	// JWTAllowHS256WithRS256 accepts HS256 tokens signed with JWTSecret next to RS256 ones
+	JWTAllowHS256WithRS256 bool
+
+	LogLevel  string // debug, info, warn or error
+	LogFormat string // json or text
+	// LogSlowQuery is the duration after which SQL queries are logged as slow
+	LogSlowQuery time.Duration
+
+	// TracingExporter is one of none, stdout, file or otlp
+	TracingExporter    string
+	TracingFile        string
+	TracingSampleRatio float64
 }
 
 func Load() *Config {
 	err := godotenv.Load()
 	if err != nil {
-		log.Println("Warning: .env file not found, reading environment variables")
+		slog.Warn(".env file not found, reading environment variables")
 	}
 
-	log.Println("port:", os.Getenv("SERVER_PORT"))
-
 	return &Config{
+		DBDriver:   getEnv("DB_DRIVER", DriverPostgres),
 		DBHost:     getEnv("DB_HOST", "localhost"),
 		DBPort:     getEnv("DB_PORT", "5432"),
 		DBUser:     getEnv("DB_USER", "postgres"),
 		DBPassword: getEnv("DB_PASSWORD", "password"),
 		DBName:     getEnv("DB_NAME", "taskdb"),
+		SQLitePath: getEnv("SQLITE_PATH", "taskkr.db"),
+
+		MigrateOnStart: getBoolEnv("MIGRATE_ON_START", false),
+
+		RequireChildrenCompleted: getBoolEnv("REQUIRE_CHILDREN_COMPLETED", false),
+		TaskTransitions:          getEnv("TASK_TRANSITIONS", ""),
+
 		ServerPort: getEnv("SERVER_PORT", "8080"),
-		JWTSecret:  getEnv("JWT_SECRET", "your-secret-key"),
+		JWTSecret:  getEnv("JWT_SECRET", ""),
+
+		ShutdownDelay:      getDurationEnv("SHUTDOWN_DELAY", 5*time.Second),
+		HealthCheckTimeout: getDurationEnv("HEALTH_CHECK_TIMEOUT", 2*time.Second),
+
+		JWTPublicKeyPath: getEnv("JWT_PUBLIC_KEY_PATH", ""),
+		JWTIssuer:        getEnv("JWT_ISSUER", ""),
+		JWTAudience:      getEnv("JWT_AUDIENCE", ""),
+
+		JWTAllowHS256WithRS256: getBoolEnv("JWT_ALLOW_HS256_WITH_RS256", false),
+
+		LogLevel:     getEnv("LOG_LEVEL", "info"),
+		LogFormat:    getEnv("LOG_FORMAT", "json"),
+		LogSlowQuery: getDurationEnv("LOG_SLOW_QUERY", 200*time.Millisecond),
+
+		TracingExporter:    getEnv("TRACING_EXPORTER", "none"),
+		TracingFile:        getEnv("TRACING_FILE", "traces.jsonl"),
+		TracingSampleRatio: getFloatEnv("TRACING_SAMPLE_RATIO", 1),
 	}
 }
 
@@ -42,3 +111,42 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func getDurationEnv(key string, fallback time.Duration) time.Duration {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		slog.Warn("invalid duration, using default", "key", key, "value", value, "default", fallback)
+		return fallback
+	}
+	return d
+}
+
+func getFloatEnv(key string, fallback float64) float64 {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		slog.Warn("invalid number, using default", "key", key, "value", value, "default", fallback)
+		return fallback
+	}
+	return f
+}
+
+func getBoolEnv(key string, fallback bool) bool {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		slog.Warn("invalid boolean, using default", "key", key, "value", value, "default", fallback)
+		return fallback
+	}
+	return b
+}
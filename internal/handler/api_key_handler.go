@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/akhilbidhuri/taskkr/internal/auth"
+	"github.com/akhilbidhuri/taskkr/internal/middleware"
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/service"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type APIKeyHandler struct {
+	service *service.APIKeyService
+}
+
+func NewAPIKeyHandler(service *service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{service: service}
+}
+
+func (h *APIKeyHandler) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Use(middleware.RequirePermission(auth.PermAPIKeyManage))
+	r.Post("/", h.CreateAPIKey)
+	r.Get("/", h.ListAPIKeys)
+	r.Delete("/{id}", h.RevokeAPIKey)
+	return r
+}
+
+// CreateAPIKey godoc
+// @Summary Issue a new API key
+// @Description Issue an API key bound to a user and role, the plain key is only returned in this response
+// @Tags api-keys
+// @Accept  json
+// @Produce  json
+// @Param key body model.CreateAPIKey true "API key info"
+// @Success 201 {object} model.IssuedAPIKey
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /admin/api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req model.CreateAPIKey
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	key, err := h.service.Issue(r.Context(), &req)
+	if err != nil {
+		serviceError(w, r, err)
+		return
+	}
+	utils.Success(w, http.StatusCreated, "", key)
+}
+
+// ListAPIKeys godoc
+// @Summary List API keys
+// @Description List all issued API keys including revoked ones
+// @Tags api-keys
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} model.APIKey
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /admin/api-keys [get]
+func (h *APIKeyHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.service.List(r.Context())
+	if err != nil {
+		serviceError(w, r, err)
+		return
+	}
+	utils.Success(w, http.StatusOK, "", keys)
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke an API key
+// @Description Revoke the API key with given ID, it can't be used afterwards
+// @Tags api-keys
+// @Accept  json
+// @Produce  json
+// @Param id path int true "API key ID"
+// @Success 204
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /admin/api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if err := h.service.Revoke(r.Context(), id); err != nil {
+		serviceError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
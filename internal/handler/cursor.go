@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/akhilbidhuri/taskkr/internal/model"
+)
+
+var errInvalidCursor = errors.New("Invalid cursor, it must come from a list with the same sort")
+
+// cursor is the sort key of a task, only the sorted fields are set.
+// The sort is kept too so a cursor can't be used with a different order.
+type cursor struct {
+	Sort      string             `json:"s,omitempty"`
+	ID        uint               `json:"id"`
+	Title     string             `json:"t,omitempty"`
+	Status    model.TaskStatus   `json:"st,omitempty"`
+	Priority  model.TaskPriority `json:"p,omitempty"`
+	StartAt   *time.Time         `json:"sa,omitempty"`
+	DueAt     *time.Time         `json:"da,omitempty"`
+	CreatedAt *time.Time         `json:"ca,omitempty"`
+	UpdatedAt *time.Time         `json:"ua,omitempty"`
+}
+
+// encodeCursor returns the opaque token pointing at task in a list ordered by sort
+func encodeCursor(task *model.Task, sort []model.TaskSort) string {
+	c := cursor{Sort: formatSort(sort), ID: task.ID}
+	for _, s := range sort {
+		switch s.Field {
+		case model.SortTitle:
+			c.Title = task.Title
+		case model.SortStatus:
+			c.Status = task.Status
+		case model.SortPriority:
+			c.Priority = task.Priority
+		case model.SortStartAt:
+			c.StartAt = task.StartAt
+		case model.SortDueAt:
+			c.DueAt = task.DueAt
+		case model.SortCreatedAt:
+			c.CreatedAt = &task.CreatedAt
+		case model.SortUpdatedAt:
+			c.UpdatedAt = &task.UpdatedAt
+		}
+	}
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor returns the sort key held by token, which must come from a list ordered by sort
+func decodeCursor(token string, sort []model.TaskSort) (*model.Task, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Sort != formatSort(sort) {
+		return nil, errInvalidCursor
+	}
+	key := &model.Task{
+		ID:       c.ID,
+		Title:    c.Title,
+		Status:   c.Status,
+		Priority: c.Priority,
+		StartAt:  c.StartAt,
+		DueAt:    c.DueAt,
+	}
+	if c.CreatedAt != nil {
+		key.CreatedAt = *c.CreatedAt
+	}
+	if c.UpdatedAt != nil {
+		key.UpdatedAt = *c.UpdatedAt
+	}
+	return key, nil
+}
+
+// formatSort is the inverse of getSort
+func formatSort(sort []model.TaskSort) string {
+	fields := make([]string, len(sort))
+	for i, s := range sort {
+		fields[i] = string(s.Field)
+		if s.Desc {
+			fields[i] = "-" + fields[i]
+		}
+	}
+	return strings.Join(fields, ",")
+}
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/akhilbidhuri/taskkr/internal/auth"
+	"github.com/akhilbidhuri/taskkr/internal/middleware"
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/service"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type LabelHandler struct {
+	service *service.LabelService
+}
+
+func NewLabelHandler(service *service.LabelService) *LabelHandler {
+	return &LabelHandler{service: service}
+}
+
+func (h *LabelHandler) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.With(middleware.RequirePermission(auth.PermTaskRead)).Get("/", h.ListLabels)
+	r.With(middleware.RequirePermission(auth.PermTaskWrite)).Post("/", h.CreateLabel)
+	r.With(middleware.RequirePermission(auth.PermTaskRead)).Get("/{id}", h.GetLabel)
+	r.With(middleware.RequirePermission(auth.PermTaskWrite)).Put("/{id}", h.UpdateLabel)
+	r.With(middleware.RequirePermission(auth.PermTaskWrite)).Delete("/{id}", h.DeleteLabel)
+	return r
+}
+
+// CreateLabel godoc
+// @Summary Create a label
+// @Description Create a label owned by the authenticated user, names are unique per user
+// @Tags labels
+// @Accept  json
+// @Produce  json
+// @Param label body model.Label true "Label info"
+// @Success 201 {object} model.Label
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /labels [post]
+func (h *LabelHandler) CreateLabel(w http.ResponseWriter, r *http.Request) {
+	var label model.Label
+	if err := json.NewDecoder(r.Body).Decode(&label); err != nil {
+		utils.Error(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if err := h.service.Create(r.Context(), &label); err != nil {
+		serviceError(w, r, err)
+		return
+	}
+	utils.Success(w, http.StatusCreated, "", label)
+}
+
+// ListLabels godoc
+// @Summary List labels
+// @Description List the labels of the authenticated user sorted by name
+// @Tags labels
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} model.Label
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /labels [get]
+func (h *LabelHandler) ListLabels(w http.ResponseWriter, r *http.Request) {
+	labels, err := h.service.List(r.Context())
+	if err != nil {
+		serviceError(w, r, err)
+		return
+	}
+	utils.Success(w, http.StatusOK, "", labels)
+}
+
+// GetLabel godoc
+// @Summary Get a label
+// @Description Get the label with given ID
+// @Tags labels
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Label ID"
+// @Success 200 {object} model.Label
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /labels/{id} [get]
+func (h *LabelHandler) GetLabel(w http.ResponseWriter, r *http.Request) {
+	label, err := h.service.GetByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		serviceError(w, r, err)
+		return
+	}
+	if label == nil {
+		serviceError(w, r, utils.NoEntryError)
+		return
+	}
+	utils.Success(w, http.StatusOK, "", label)
+}
+
+// UpdateLabel godoc
+// @Summary Update a label
+// @Description Rename or recolor the label with given ID, tasks keep it under the new name
+// @Tags labels
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Label ID"
+// @Param label body model.UpdateLabel true "Label update info"
+// @Success 202 {object} model.Label
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /labels/{id} [put]
+func (h *LabelHandler) UpdateLabel(w http.ResponseWriter, r *http.Request) {
+	var update model.UpdateLabel
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		utils.Error(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	label, err := h.service.Update(r.Context(), chi.URLParam(r, "id"), &update)
+	if err != nil {
+		serviceError(w, r, err)
+		return
+	}
+	utils.Success(w, http.StatusAccepted, "", label)
+}
+
+// DeleteLabel godoc
+// @Summary Delete a label
+// @Description Delete the label with given ID, it is removed from every task
+// @Tags labels
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Label ID"
+// @Success 204
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /labels/{id} [delete]
+func (h *LabelHandler) DeleteLabel(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.Delete(r.Context(), chi.URLParam(r, "id")); err != nil {
+		serviceError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
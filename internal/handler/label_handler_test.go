@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/akhilbidhuri/taskkr/internal/auth"
+	"github.com/akhilbidhuri/taskkr/internal/repository/memory"
+	"github.com/akhilbidhuri/taskkr/internal/service"
+)
+
+func TestLabelHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		identity *auth.Identity
+		method   string
+		path     string
+		body     string
+	}{
+		{name: "create", identity: member, method: http.MethodPost, path: "/", body: `{"name":" frontend ","color":"#0075ca","user_id":2}`},
+		{name: "create_duplicate", identity: member, method: http.MethodPost, path: "/", body: `{"name":"bug"}`},
+		{name: "create_invalid_name", identity: member, method: http.MethodPost, path: "/", body: `{"name":"a,b"}`},
+		{name: "create_invalid_color", identity: member, method: http.MethodPost, path: "/", body: `{"name":"ui","color":"blue"}`},
+		{name: "create_read_only", identity: readOnly, method: http.MethodPost, path: "/", body: `{"name":"ui"}`},
+
+		{name: "list", identity: member, method: http.MethodGet, path: "/"},
+		{name: "get", identity: member, method: http.MethodGet, path: "/1"},
+		{name: "get_other_users_label", identity: member, method: http.MethodGet, path: "/3"},
+
+		{name: "update", identity: member, method: http.MethodPut, path: "/1", body: `{"name":"defect"}`},
+		{name: "update_duplicate", identity: member, method: http.MethodPut, path: "/1", body: `{"name":"backend"}`},
+		{name: "update_other_users_label", identity: member, method: http.MethodPut, path: "/3", body: `{"name":"mine"}`},
+
+		{name: "delete", identity: member, method: http.MethodDelete, path: "/1"},
+		{name: "delete_missing_id", identity: member, method: http.MethodDelete, path: "/99"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewLabelHandler(service.NewLabelService(memory.NewLabelRepository(seededDB(t))))
+			got := serve(t, h.Routes(), tt.identity, tt.method, tt.path, tt.body)
+			assertGolden(t, filepath.Join("testdata", "labels", tt.name+".golden"), got)
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/akhilbidhuri/taskkr/internal/auth"
+	"github.com/akhilbidhuri/taskkr/internal/middleware"
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/service"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type ProjectHandler struct {
+	service *service.ProjectService
+	tasks   *TaskHandler
+}
+
+// NewProjectHandler serves the projects, tasks serves the tasks nested under them
+func NewProjectHandler(service *service.ProjectService, tasks *TaskHandler) *ProjectHandler {
+	return &ProjectHandler{service: service, tasks: tasks}
+}
+
+func (h *ProjectHandler) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.With(middleware.RequirePermission(auth.PermTaskRead)).Get("/", h.ListProjects)
+	r.With(middleware.RequirePermission(auth.PermTaskWrite)).Post("/", h.CreateProject)
+	r.With(middleware.RequirePermission(auth.PermTaskRead)).Get("/{id}", h.GetProject)
+	r.With(middleware.RequirePermission(auth.PermTaskWrite)).Put("/{id}", h.UpdateProject)
+	r.With(middleware.RequirePermission(auth.PermTaskWrite)).Delete("/{id}", h.DeleteProject)
+	r.With(middleware.RequirePermission(auth.PermTaskRead)).Get("/{id}/members", h.ListMembers)
+	r.With(middleware.RequirePermission(auth.PermTaskWrite)).Post("/{id}/members", h.AddMember)
+	r.With(middleware.RequirePermission(auth.PermTaskWrite)).Delete("/{id}/members/{userID}", h.RemoveMember)
+	r.Mount("/{id}/tasks", h.tasks.ProjectRoutes())
+	return r
+}
+
+// CreateProject godoc
+// @Summary Create a project
+// @Description Create a project owned by the authenticated user, names are unique per owner. The owner is its first member.
+// @Tags projects
+// @Accept  json
+// @Produce  json
+// @Param project body model.Project true "Project info"
+// @Success 201 {object} model.Project
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /projects [post]
+func (h *ProjectHandler) CreateProject(w http.ResponseWriter, r *http.Request) {
+	var project model.Project
+	if err := json.NewDecoder(r.Body).Decode(&project); err != nil {
+		utils.Error(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if err := h.service.Create(r.Context(), &project); err != nil {
+		serviceError(w, r, err)
+		return
+	}
+	utils.Success(w, http.StatusCreated, "", project)
+}
+
+// ListProjects godoc
+// @Summary List projects
+// @Description List the projects the authenticated user is a member of sorted by name
+// @Tags projects
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} model.Project
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /projects [get]
+func (h *ProjectHandler) ListProjects(w http.ResponseWriter, r *http.Request) {
+	projects, err := h.service.List(r.Context())
+	if err != nil {
+		serviceError(w, r, err)
+		return
+	}
+	utils.Success(w, http.StatusOK, "", projects)
+}
+
+// GetProject godoc
+// @Summary Get a project
+// @Description Get the project with given ID, the authenticated user must be a member of it
+// @Tags projects
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Project ID"
+// @Success 200 {object} model.Project
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /projects/{id} [get]
+func (h *ProjectHandler) GetProject(w http.ResponseWriter, r *http.Request) {
+	project, err := h.service.GetByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		serviceError(w, r, err)
+		return
+	}
+	if project == nil {
+		serviceError(w, r, utils.NoEntryError)
+		return
+	}
+	utils.Success(w, http.StatusOK, "", project)
+}
+
+// UpdateProject godoc
+// @Summary Update a project
+// @Description Rename or describe the project with given ID, only its owner can
+// @Tags projects
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Project ID"
+// @Param project body model.UpdateProject true "Project update info"
+// @Success 202 {object} model.Project
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /projects/{id} [put]
+func (h *ProjectHandler) UpdateProject(w http.ResponseWriter, r *http.Request) {
+	var update model.UpdateProject
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		utils.Error(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	project, err := h.service.Update(r.Context(), chi.URLParam(r, "id"), &update)
+	if err != nil {
+		serviceError(w, r, err)
+		return
+	}
+	utils.Success(w, http.StatusAccepted, "", project)
+}
+
+// DeleteProject godoc
+// @Summary Delete a project
+// @Description Delete the project with given ID, only its owner can and it must have no tasks left
+// @Tags projects
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Project ID"
+// @Success 204
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /projects/{id} [delete]
+func (h *ProjectHandler) DeleteProject(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.Delete(r.Context(), chi.URLParam(r, "id")); err != nil {
+		serviceError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListMembers godoc
+// @Summary List the members of a project
+// @Description List the members of the project with given ID by user ID, the owner included
+// @Tags projects
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Project ID"
+// @Success 200 {array} model.ProjectMember
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /projects/{id}/members [get]
+func (h *ProjectHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	members, err := h.service.Members(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		serviceError(w, r, err)
+		return
+	}
+	utils.Success(w, http.StatusOK, "", members)
+}
+
+// AddMember godoc
+// @Summary Add a member to a project
+// @Description Let user_id see the project with given ID and all its tasks, only the owner can add members
+// @Tags projects
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Project ID"
+// @Param member body model.AddProjectMember true "New member"
+// @Success 201 {object} model.ProjectMember
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /projects/{id}/members [post]
+func (h *ProjectHandler) AddMember(w http.ResponseWriter, r *http.Request) {
+	var add model.AddProjectMember
+	if err := json.NewDecoder(r.Body).Decode(&add); err != nil {
+		utils.Error(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	member, err := h.service.AddMember(r.Context(), chi.URLParam(r, "id"), add.UserID)
+	if err != nil {
+		serviceError(w, r, err)
+		return
+	}
+	utils.Success(w, http.StatusCreated, "", member)
+}
+
+// RemoveMember godoc
+// @Summary Remove a member from a project
+// @Description Remove userID from the project with given ID, only the owner can and they can't remove themselves.
+// @Description The tasks the member owns in the project stay theirs.
+// @Tags projects
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Project ID"
+// @Param userID path int true "Member user ID"
+// @Success 204
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /projects/{id}/members/{userID} [delete]
+func (h *ProjectHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseUint(chi.URLParam(r, "userID"), 10, 64)
+	if err != nil {
+		serviceError(w, r, utils.NoEntryError)
+		return
+	}
+	if err := h.service.RemoveMember(r.Context(), chi.URLParam(r, "id"), uint(userID)); err != nil {
+		serviceError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
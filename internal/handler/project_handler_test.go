@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/akhilbidhuri/taskkr/internal/auth"
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/repository/memory"
+	"github.com/akhilbidhuri/taskkr/internal/service"
+)
+
+// teammate is a member of the "Website" project (1) of member
+var teammate = &auth.Identity{UserID: 2, Subject: "2", Role: auth.RoleMember}
+
+// seedProjects are stored after seed for the project tests, "Mobile" (2) is
+// owned by teammate and member isn't part of it
+var seedProjects = []*model.Project{
+	{UserID: 1, Name: "Website", Description: "the public site"},
+	{UserID: 2, Name: "Mobile"},
+}
+
+// seedProjectTasks are stored after seedProjects, with ids 4 and 5
+var seedProjectTasks = []*model.Task{
+	{UserID: 1, Title: "Landing page", ProjectID: ptr[uint](1), Status: model.StatusPending},
+	{UserID: 2, Title: "Contact form", ProjectID: ptr[uint](1), Status: model.StatusInProcess},
+}
+
+func seededProjectDB(t *testing.T) *memory.DB {
+	t.Helper()
+	db := seededDB(t)
+	projects := memory.NewProjectRepository(db)
+	for _, project := range seedProjects {
+		project := *project
+		if err := projects.Create(context.Background(), &project); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	if err := projects.AddMember(context.Background(), 1, "1", &model.ProjectMember{UserID: 2, Role: model.RoleMember}); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	tasks := memory.NewTaskRepository(db)
+	for _, task := range seedProjectTasks {
+		task := *task
+		if err := tasks.Create(context.Background(), &task); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	return db
+}
+
+func TestProjectHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		identity *auth.Identity
+		method   string
+		path     string
+		body     string
+	}{
+		{name: "create", identity: member, method: http.MethodPost, path: "/", body: `{"name":" Backend ","description":"APIs","user_id":2}`},
+		{name: "create_duplicate", identity: member, method: http.MethodPost, path: "/", body: `{"name":"Website"}`},
+		{name: "create_missing_name", identity: member, method: http.MethodPost, path: "/", body: `{"description":"nameless"}`},
+		{name: "create_read_only", identity: readOnly, method: http.MethodPost, path: "/", body: `{"name":"Backend"}`},
+
+		{name: "list", identity: member, method: http.MethodGet, path: "/"},
+		{name: "list_as_teammate", identity: teammate, method: http.MethodGet, path: "/"},
+		{name: "get", identity: member, method: http.MethodGet, path: "/1"},
+		{name: "get_as_teammate", identity: teammate, method: http.MethodGet, path: "/1"},
+		{name: "get_not_a_member", identity: member, method: http.MethodGet, path: "/2"},
+
+		{name: "update", identity: member, method: http.MethodPut, path: "/1", body: `{"name":"Site"}`},
+		{name: "update_as_teammate", identity: teammate, method: http.MethodPut, path: "/1", body: `{"name":"Ours"}`},
+		{name: "update_not_a_member", identity: member, method: http.MethodPut, path: "/2", body: `{"name":"Mine"}`},
+
+		{name: "delete_with_tasks", identity: member, method: http.MethodDelete, path: "/1"},
+		{name: "delete_as_teammate", identity: teammate, method: http.MethodDelete, path: "/1"},
+		{name: "delete", identity: teammate, method: http.MethodDelete, path: "/2"},
+
+		{name: "members", identity: teammate, method: http.MethodGet, path: "/1/members"},
+		{name: "members_not_a_member", identity: member, method: http.MethodGet, path: "/2/members"},
+		{name: "add_member", identity: member, method: http.MethodPost, path: "/1/members", body: `{"user_id":3}`},
+		{name: "add_member_twice", identity: member, method: http.MethodPost, path: "/1/members", body: `{"user_id":2}`},
+		{name: "add_member_missing_user", identity: member, method: http.MethodPost, path: "/1/members", body: `{}`},
+		{name: "add_member_as_teammate", identity: teammate, method: http.MethodPost, path: "/1/members", body: `{"user_id":3}`},
+		{name: "remove_member", identity: member, method: http.MethodDelete, path: "/1/members/2"},
+		{name: "remove_owner", identity: member, method: http.MethodDelete, path: "/1/members/1"},
+		{name: "remove_missing_member", identity: member, method: http.MethodDelete, path: "/1/members/3"},
+		{name: "remove_invalid_member", identity: member, method: http.MethodDelete, path: "/1/members/abc"},
+
+		{name: "list_tasks", identity: teammate, method: http.MethodGet, path: "/1/tasks/"},
+		{name: "list_tasks_filtered", identity: member, method: http.MethodGet, path: "/1/tasks/?status=in_process"},
+		{name: "list_tasks_not_a_member", identity: member, method: http.MethodGet, path: "/2/tasks/"},
+		{name: "create_task", identity: teammate, method: http.MethodPost, path: "/1/tasks/", body: `{"title":"Footer","project_id":2}`},
+		{name: "create_task_not_a_member", identity: member, method: http.MethodPost, path: "/2/tasks/", body: `{"title":"Splash"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := seededProjectDB(t)
+			tasks := NewTaskHandler(service.NewTaskService(memory.NewTaskRepository(db), memory.NewWorkflowRepository(db), memory.NewProjectRepository(db), service.TaskOptions{}))
+			h := NewProjectHandler(service.NewProjectService(memory.NewProjectRepository(db)), tasks)
+			got := serve(t, h.Routes(), tt.identity, tt.method, tt.path, tt.body)
+			assertGolden(t, filepath.Join("testdata", "projects", tt.name+".golden"), got)
+		})
+	}
+}
+
+func TestTaskHandlerProjects(t *testing.T) {
+	tests := []struct {
+		name     string
+		identity *auth.Identity
+		method   string
+		path     string
+		body     string
+	}{
+		{name: "create_in_project", identity: member, method: http.MethodPost, path: "/", body: `{"title":"Blog","project_id":1}`},
+		{name: "create_in_other_project", identity: member, method: http.MethodPost, path: "/", body: `{"title":"Splash","project_id":2}`},
+		{name: "get_teammates_task", identity: member, method: http.MethodGet, path: "/5"},
+		{name: "update_teammates_task", identity: member, method: http.MethodPut, path: "/5", body: `{"title":"Mine"}`},
+		{name: "list", identity: teammate, method: http.MethodGet, path: "/"},
+		{name: "list_by_project", identity: member, method: http.MethodGet, path: "/?project_id=1"},
+		{name: "list_invalid_project", identity: member, method: http.MethodGet, path: "/?project_id=web"},
+		{name: "set_project", identity: member, method: http.MethodPut, path: "/1/project", body: `{"project_id":1}`},
+		{name: "set_other_project", identity: member, method: http.MethodPut, path: "/1/project", body: `{"project_id":2}`},
+		{name: "clear_project", identity: member, method: http.MethodPut, path: "/4/project", body: `{"project_id":null}`},
+		{name: "set_project_of_teammates_task", identity: member, method: http.MethodPut, path: "/5/project", body: `{"project_id":null}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := seededProjectDB(t)
+			h := NewTaskHandler(service.NewTaskService(memory.NewTaskRepository(db), memory.NewWorkflowRepository(db), memory.NewProjectRepository(db), service.TaskOptions{}))
+			got := serve(t, h.Routes(), tt.identity, tt.method, tt.path, tt.body)
+			assertGolden(t, filepath.Join("testdata", "project_tasks", tt.name+".golden"), got)
+		})
+	}
+}
+
+func TestTaskHandlerAssignees(t *testing.T) {
+	tests := []struct {
+		name     string
+		identity *auth.Identity
+		method   string
+		path     string
+		body     string
+	}{
+		{name: "assign", identity: member, method: http.MethodPost, path: "/4/assignees", body: `{"user_id":2}`},
+		{name: "assign_twice", identity: member, method: http.MethodPost, path: "/4/assignees", body: `{"user_id":1}`},
+		{name: "assign_not_a_member", identity: member, method: http.MethodPost, path: "/4/assignees", body: `{"user_id":3}`},
+		{name: "assign_missing_user", identity: member, method: http.MethodPost, path: "/4/assignees", body: `{}`},
+		{name: "assign_teammates_task", identity: member, method: http.MethodPost, path: "/5/assignees", body: `{"user_id":1}`},
+		{name: "unassign", identity: member, method: http.MethodDelete, path: "/4/assignees/1"},
+		{name: "unassign_missing", identity: member, method: http.MethodDelete, path: "/4/assignees/2"},
+		{name: "unassign_invalid", identity: member, method: http.MethodDelete, path: "/4/assignees/abc"},
+		{name: "history", identity: teammate, method: http.MethodGet, path: "/4/history"},
+		{name: "history_not_visible", identity: teammate, method: http.MethodGet, path: "/1/history"},
+
+		{name: "list_assigned_to_me", identity: member, method: http.MethodGet, path: "/?assignee=me"},
+		{name: "list_assigned_to_user", identity: teammate, method: http.MethodGet, path: "/?assignee=1"},
+		{name: "list_unassigned", identity: teammate, method: http.MethodGet, path: "/?assignee=unassigned"},
+		{name: "list_invalid_assignee", identity: member, method: http.MethodGet, path: "/?assignee=bob"},
+		{name: "list_created_by_me", identity: teammate, method: http.MethodGet, path: "/?created_by=me"},
+		{name: "list_invalid_created_by", identity: member, method: http.MethodGet, path: "/?created_by=unassigned"},
+		{name: "my_work", identity: member, method: http.MethodGet, path: "/my-work?sort=id"},
+		{name: "my_work_of_teammate", identity: teammate, method: http.MethodGet, path: "/my-work"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := seededProjectDB(t)
+			tasks := memory.NewTaskRepository(db)
+			// member works on the landing page, teammate's contact form has nobody on it
+			if err := tasks.Assign(context.Background(), 1, "4", 1, 1); err != nil {
+				t.Fatalf("seed: %v", err)
+			}
+			h := NewTaskHandler(service.NewTaskService(tasks, memory.NewWorkflowRepository(db), memory.NewProjectRepository(db), service.TaskOptions{}))
+			got := serve(t, h.Routes(), tt.identity, tt.method, tt.path, tt.body)
+			assertGolden(t, filepath.Join("testdata", "assignees", tt.name+".golden"), got)
+		})
+	}
+}
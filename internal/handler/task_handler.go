@@ -1,11 +1,17 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/akhilbidhuri/taskkr/internal/auth"
+	"github.com/akhilbidhuri/taskkr/internal/middleware"
 	"github.com/akhilbidhuri/taskkr/internal/model"
 	"github.com/akhilbidhuri/taskkr/internal/service"
 	"github.com/akhilbidhuri/taskkr/internal/utils"
@@ -23,11 +29,29 @@ func NewTaskHandler(service *service.TaskService) *TaskHandler {
 
 func (h *TaskHandler) Routes() http.Handler {
 	r := chi.NewRouter()
-	r.Get("/{id}", h.GetTask)
-	r.Post("/", h.CreateTask)
-	r.Get("/", h.ListTasks)
-	r.Put("/{id}", h.UpdateTask)
-	r.Delete("/{id}", h.DeleteTask)
+	r.With(middleware.RequirePermission(auth.PermTaskRead)).Get("/my-work", h.ListMyWork)
+	r.With(middleware.RequirePermission(auth.PermTaskRead)).Get("/{id}", h.GetTask)
+	r.With(middleware.RequirePermission(auth.PermTaskWrite)).Post("/", h.CreateTask)
+	r.With(middleware.RequirePermission(auth.PermTaskRead)).Get("/", h.ListTasks)
+	r.With(middleware.RequirePermission(auth.PermTaskWrite)).Put("/{id}", h.UpdateTask)
+	r.With(middleware.RequirePermission(auth.PermTaskWrite)).Delete("/{id}", h.DeleteTask)
+	r.With(middleware.RequirePermission(auth.PermTaskRead)).Get("/{id}/subtree", h.GetSubtree)
+	r.With(middleware.RequirePermission(auth.PermTaskWrite)).Post("/{id}/move", h.MoveTask)
+	r.With(middleware.RequirePermission(auth.PermTaskWrite)).Post("/{id}/dependencies", h.AddDependency)
+	r.With(middleware.RequirePermission(auth.PermTaskWrite)).Delete("/{id}/dependencies/{blockedByID}", h.RemoveDependency)
+	r.With(middleware.RequirePermission(auth.PermTaskRead)).Get("/{id}/graph", h.GetGraph)
+	r.With(middleware.RequirePermission(auth.PermTaskWrite)).Put("/{id}/project", h.SetTaskProject)
+	r.With(middleware.RequirePermission(auth.PermTaskWrite)).Post("/{id}/assignees", h.AssignTask)
+	r.With(middleware.RequirePermission(auth.PermTaskWrite)).Delete("/{id}/assignees/{userID}", h.UnassignTask)
+	r.With(middleware.RequirePermission(auth.PermTaskRead)).Get("/{id}/history", h.GetHistory)
+	return r
+}
+
+// ProjectRoutes serves the tasks of the project in the id URL parameter, the project routes mount them
+func (h *TaskHandler) ProjectRoutes() http.Handler {
+	r := chi.NewRouter()
+	r.With(middleware.RequirePermission(auth.PermTaskWrite)).Post("/", h.CreateProjectTask)
+	r.With(middleware.RequirePermission(auth.PermTaskRead)).Get("/", h.ListProjectTasks)
 	return r
 }
 
@@ -38,48 +62,121 @@ func (h *TaskHandler) Routes() http.Handler {
 // @Accept  json
 // @Produce  json
 // @Param id path int false "ID filter"
+// @Param progress query bool false "Add the progress of the direct subtasks"
 // @Success 200 {object} model.Task
 // @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
 // @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Router /tasks/{id} [get]
 func (h *TaskHandler) GetTask(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	task, err := h.service.GetByID(r.Context(), id)
 	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "", err)
+		serviceError(w, r, err)
 		return
 	}
 	if task == nil {
-		utils.Error(w, http.StatusNotFound, "Task not found", nil)
+		serviceError(w, r, utils.NoEntryError)
 		return
 	}
+	if r.URL.Query().Get("progress") != "" {
+		progress, err := strconv.ParseBool(r.URL.Query().Get("progress"))
+		if err != nil {
+			utils.Error(w, r, http.StatusBadRequest, "Invalid progress value", nil)
+			return
+		}
+		if progress {
+			if err := h.service.Progress(r.Context(), task); err != nil {
+				serviceError(w, r, err)
+				return
+			}
+		}
+	}
 	utils.Success(w, http.StatusOK, "", task)
 }
 
 // CreateTask godoc
 // @Summary Create a new task
-// @Description Create a task with title, description, etc.
+// @Description Create a task with title, description, etc. The task is owned by the authenticated user,
+// @Description set parent_id to make it a subtask of one of their tasks. The status must be one of the task's workflow,
+// @Description set with workflow_id, and defaults to its first todo status. Set project_id to put it in a project
+// @Description the user is a member of, subtasks are in the project of their parent.
 // @Tags tasks
 // @Accept  json
 // @Produce  json
 // @Param task body model.Task true "Task info"
 // @Success 201 {object} model.Task
 // @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
 // @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Router /tasks [post]
 func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
+	h.createTask(w, r, "")
+}
+
+// CreateProjectTask godoc
+// @Summary Create a task in a project
+// @Description Create a task like POST /tasks, in the project with given ID. The authenticated user must be a member of it.
+// @Tags projects
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Project ID"
+// @Param task body model.Task true "Task info"
+// @Success 201 {object} model.Task
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /projects/{id}/tasks [post]
+func (h *TaskHandler) CreateProjectTask(w http.ResponseWriter, r *http.Request) {
+	h.createTask(w, r, chi.URLParam(r, "id"))
+}
+
+// createTask creates the task in the request body, in the project with projectID unless it is empty
+func (h *TaskHandler) createTask(w http.ResponseWriter, r *http.Request, projectID string) {
 	var task model.Task
 	if err := json.NewDecoder(r.Body).Decode(&task); err != nil {
-		utils.Error(w, http.StatusBadRequest, "Invalid request body", err)
+		utils.Error(w, r, http.StatusBadRequest, "Invalid request body", err)
 		return
 	}
-	if task.UserID == 0 || task.Title == "" {
-		utils.Error(w, http.StatusBadRequest, "missing values in body", nil)
+	if task.Title == "" {
+		utils.Error(w, r, http.StatusBadRequest, "missing values in body", nil)
 		return
 	}
-	err := h.service.Create(r.Context(), &task)
+	if task.Status != "" {
+		status, err := getStatus(string(task.Status))
+		if err != nil {
+			utils.Error(w, r, http.StatusBadRequest, "", err)
+			return
+		}
+		task.Status = status
+	}
+	if task.Priority != "" {
+		priority, err := getPriority(string(task.Priority))
+		if err != nil {
+			utils.Error(w, r, http.StatusBadRequest, "", err)
+			return
+		}
+		task.Priority = priority
+	}
+	var err error
+	if projectID == "" {
+		err = h.service.Create(r.Context(), &task)
+	} else {
+		err = h.service.CreateInProject(r.Context(), projectID, &task)
+	}
 	if err != nil {
-		utils.Error(w, http.StatusBadRequest, "", err)
+		serviceError(w, r, err)
 		return
 	}
 	utils.Success(w, http.StatusCreated, "", task)
@@ -87,19 +184,118 @@ func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 
 // GetTasks godoc
 // @Summary Get list of tasks
-// @Description Get all tasks with pagination and optional filtering
+// @Description Get all tasks with pagination and optional filtering. Follow next_cursor and prev_cursor
+// @Description for stable pages while tasks are added, page and page_size keep working. The tasks of the
+// @Description projects the user is a member of are listed with their own.
 // @Tags tasks
 // @Accept  json
 // @Produce  json
-// @Param status query string false "Filter by status" Enums(pending, in_process, completed)
+// @Param project_id query int false "Only the tasks of the project"
+// @Param assignee query string false "Only the tasks assigned to the user, me for the authenticated user, or unassigned for the tasks without assignee"
+// @Param created_by query string false "Only the tasks created by the user, me for the authenticated user"
+// @Param status query string false "Filter by status, built-in or of a workflow"
+// @Param category query string false "Filter by status category" Enums(todo, doing, done)
+// @Param priority query string false "Filter by priority" Enums(low, medium, high, urgent)
 // @Param title query string false "Title filter"
+// @Param label query string false "Comma separated label names"
+// @Param label_match query string false "Whether tasks need any (default) or all of the labels" Enums(any, all)
+// @Param due_before query string false "Only tasks due before this RFC 3339 time"
+// @Param due_after query string false "Only tasks due after this RFC 3339 time"
+// @Param overdue query bool false "Only tasks past their due date that aren't done"
+// @Param sort query string false "Comma separated fields to sort by, prefixed with - for descending order, e.g. -priority,due_at. Sortable fields are id, title, status, priority (most urgent first), start_at, due_at, created_at and updated_at"
 // @Param page query string false "Page filter"
 // @Param page_size query string false "PageSize filter"
-// @Success 200 {array} model.Task
+// @Param after query string false "Cursor from next_cursor, lists the tasks after it instead of a page"
+// @Param before query string false "Cursor from prev_cursor, lists the tasks before it instead of a page"
+// @Param total query bool false "Count the matching tasks when listing with a cursor, pages always have the total"
+// @Success 200 {object} model.TaskPage
 // @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
 // @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Router /tasks [get]
 func (h *TaskHandler) ListTasks(w http.ResponseWriter, r *http.Request) {
+	h.listTasks(w, r, h.service.List)
+}
+
+// ListProjectTasks godoc
+// @Summary Get the tasks of a project
+// @Description List the tasks of the project with given ID like GET /tasks, whichever member owns them.
+// @Tags projects
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Project ID"
+// @Param assignee query string false "Only the tasks assigned to the user, me for the authenticated user, or unassigned for the tasks without assignee"
+// @Param created_by query string false "Only the tasks created by the user, me for the authenticated user"
+// @Param status query string false "Filter by status, built-in or of a workflow"
+// @Param category query string false "Filter by status category" Enums(todo, doing, done)
+// @Param priority query string false "Filter by priority" Enums(low, medium, high, urgent)
+// @Param title query string false "Title filter"
+// @Param label query string false "Comma separated label names"
+// @Param label_match query string false "Whether tasks need any (default) or all of the labels" Enums(any, all)
+// @Param due_before query string false "Only tasks due before this RFC 3339 time"
+// @Param due_after query string false "Only tasks due after this RFC 3339 time"
+// @Param overdue query bool false "Only tasks past their due date that aren't done"
+// @Param sort query string false "Comma separated fields to sort by, prefixed with - for descending order, e.g. -priority,due_at. Sortable fields are id, title, status, priority (most urgent first), start_at, due_at, created_at and updated_at"
+// @Param page query string false "Page filter"
+// @Param page_size query string false "PageSize filter"
+// @Param after query string false "Cursor from next_cursor, lists the tasks after it instead of a page"
+// @Param before query string false "Cursor from prev_cursor, lists the tasks before it instead of a page"
+// @Param total query bool false "Count the matching tasks when listing with a cursor, pages always have the total"
+// @Success 200 {object} model.TaskPage
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /projects/{id}/tasks [get]
+func (h *TaskHandler) ListProjectTasks(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	h.listTasks(w, r, func(ctx context.Context, filter *model.TaskFilter) (*model.TaskPage, error) {
+		return h.service.ListInProject(ctx, projectID, filter)
+	})
+}
+
+// ListMyWork godoc
+// @Summary Get my work
+// @Description List the tasks the authenticated user created or is assigned to like GET /tasks, the other filters apply on top.
+// @Tags tasks
+// @Accept  json
+// @Produce  json
+// @Param project_id query int false "Only the tasks of the project"
+// @Param status query string false "Filter by status, built-in or of a workflow"
+// @Param category query string false "Filter by status category" Enums(todo, doing, done)
+// @Param priority query string false "Filter by priority" Enums(low, medium, high, urgent)
+// @Param title query string false "Title filter"
+// @Param label query string false "Comma separated label names"
+// @Param label_match query string false "Whether tasks need any (default) or all of the labels" Enums(any, all)
+// @Param due_before query string false "Only tasks due before this RFC 3339 time"
+// @Param due_after query string false "Only tasks due after this RFC 3339 time"
+// @Param overdue query bool false "Only tasks past their due date that aren't done"
+// @Param sort query string false "Comma separated fields to sort by, prefixed with - for descending order, e.g. -priority,due_at. Sortable fields are id, title, status, priority (most urgent first), start_at, due_at, created_at and updated_at"
+// @Param page query string false "Page filter"
+// @Param page_size query string false "PageSize filter"
+// @Param after query string false "Cursor from next_cursor, lists the tasks after it instead of a page"
+// @Param before query string false "Cursor from prev_cursor, lists the tasks before it instead of a page"
+// @Param total query bool false "Count the matching tasks when listing with a cursor, pages always have the total"
+// @Success 200 {object} model.TaskPage
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /tasks/my-work [get]
+func (h *TaskHandler) ListMyWork(w http.ResponseWriter, r *http.Request) {
+	h.listTasks(w, r, h.service.ListMyWork)
+}
+
+// listTasks lists the tasks matching the query with list
+func (h *TaskHandler) listTasks(w http.ResponseWriter, r *http.Request, list func(context.Context, *model.TaskFilter) (*model.TaskPage, error)) {
 	params := r.URL.Query()
 
 	filter := &model.TaskFilter{
@@ -107,21 +303,111 @@ func (h *TaskHandler) ListTasks(w http.ResponseWriter, r *http.Request) {
 		PageSize: 10,
 	}
 
+	if params.Get("project_id") != "" {
+		id, err := strconv.ParseUint(params.Get("project_id"), 10, 64)
+		if err != nil {
+			utils.Error(w, r, http.StatusBadRequest, "Invalid project_id value", nil)
+			return
+		}
+		project := uint(id)
+		filter.ProjectID = &project
+	}
+	if params.Get("assignee") == "unassigned" {
+		filter.Unassigned = true
+	} else if params.Get("assignee") != "" {
+		assignee, err := getUser(r, params.Get("assignee"))
+		if err != nil {
+			utils.Error(w, r, http.StatusBadRequest, "Invalid assignee value, must be me, unassigned or a user id", nil)
+			return
+		}
+		filter.AssigneeID = &assignee
+	}
+	if params.Get("created_by") != "" {
+		creator, err := getUser(r, params.Get("created_by"))
+		if err != nil {
+			utils.Error(w, r, http.StatusBadRequest, "Invalid created_by value, must be me or a user id", nil)
+			return
+		}
+		filter.CreatedBy = &creator
+	}
 	if params.Get("status") != "" {
 		status, err := getStatus(params.Get("status"))
 		if err != nil {
-			utils.Error(w, http.StatusBadRequest, "", err)
+			utils.Error(w, r, http.StatusBadRequest, "", err)
 			return
 		}
 		filter.Status = status
 	}
+	if params.Get("category") != "" {
+		category := model.StatusCategory(params.Get("category"))
+		if !category.IsValid() {
+			utils.Error(w, r, http.StatusBadRequest, "", errors.New("Invalid category value"))
+			return
+		}
+		filter.Category = category
+	}
+	if params.Get("priority") != "" {
+		priority, err := getPriority(params.Get("priority"))
+		if err != nil {
+			utils.Error(w, r, http.StatusBadRequest, "", err)
+			return
+		}
+		filter.Priority = priority
+	}
+	if params.Get("sort") != "" {
+		sort, err := getSort(params.Get("sort"))
+		if err != nil {
+			utils.Error(w, r, http.StatusBadRequest, "Invalid sort value", err)
+			return
+		}
+		filter.Sort = sort
+	}
 	if params.Get("title") != "" {
 		filter.Title = params.Get("title")
 	}
+	if params.Get("label") != "" {
+		for _, name := range strings.Split(params.Get("label"), ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				filter.Labels = append(filter.Labels, name)
+			}
+		}
+	}
+	if params.Get("label_match") != "" {
+		match, err := getLabelMatch(params.Get("label_match"))
+		if err != nil {
+			utils.Error(w, r, http.StatusBadRequest, "", err)
+			return
+		}
+		filter.LabelMatch = match
+	}
+	if params.Get("due_before") != "" {
+		dueBefore, err := time.Parse(time.RFC3339, params.Get("due_before"))
+		if err != nil {
+			utils.Error(w, r, http.StatusBadRequest, "Invalid due_before value, must be an RFC 3339 time", nil)
+			return
+		}
+		filter.DueBefore = &dueBefore
+	}
+	if params.Get("due_after") != "" {
+		dueAfter, err := time.Parse(time.RFC3339, params.Get("due_after"))
+		if err != nil {
+			utils.Error(w, r, http.StatusBadRequest, "Invalid due_after value, must be an RFC 3339 time", nil)
+			return
+		}
+		filter.DueAfter = &dueAfter
+	}
+	if params.Get("overdue") != "" {
+		overdue, err := strconv.ParseBool(params.Get("overdue"))
+		if err != nil {
+			utils.Error(w, r, http.StatusBadRequest, "Invalid overdue value", nil)
+			return
+		}
+		filter.Overdue = overdue
+	}
 	if params.Get("page") != "" {
 		page, err := strconv.ParseUint(params.Get("page"), 10, 32)
 		if err != nil {
-			utils.Error(w, http.StatusBadRequest, "Invalid page value", nil)
+			utils.Error(w, r, http.StatusBadRequest, "Invalid page value", nil)
 			return
 		}
 		filter.Page = uint(page)
@@ -129,58 +415,112 @@ func (h *TaskHandler) ListTasks(w http.ResponseWriter, r *http.Request) {
 	if params.Get("page_size") != "" {
 		pageSize, err := strconv.ParseUint(params.Get("page_size"), 10, 32)
 		if err != nil || pageSize > 100 {
-			utils.Error(w, http.StatusBadRequest, "Invalid page value", nil)
+			utils.Error(w, r, http.StatusBadRequest, "Invalid page_size value, must be at most 100", nil)
 			return
 		}
 		filter.PageSize = uint(pageSize)
 	}
-	tasks, total, err := h.service.List(r.Context(), filter)
+	if params.Get("after") != "" || params.Get("before") != "" {
+		if params.Get("page") != "" || (params.Get("after") != "" && params.Get("before") != "") {
+			utils.Error(w, r, http.StatusBadRequest, "Only one of page, after and before can be set", nil)
+			return
+		}
+		var err error
+		if params.Get("after") != "" {
+			filter.After, err = decodeCursor(params.Get("after"), filter.Sort)
+		} else {
+			filter.Before, err = decodeCursor(params.Get("before"), filter.Sort)
+		}
+		if err != nil {
+			utils.Error(w, r, http.StatusBadRequest, "", err)
+			return
+		}
+		// counting every page defeats the purpose of cursors, it's opt-in
+		filter.SkipTotal = true
+		if params.Get("total") != "" {
+			total, err := strconv.ParseBool(params.Get("total"))
+			if err != nil {
+				utils.Error(w, r, http.StatusBadRequest, "Invalid total value", nil)
+				return
+			}
+			filter.SkipTotal = !total
+		}
+	}
+	page, err := list(r.Context(), filter)
 	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "", err)
+		serviceError(w, r, err)
 		return
 	}
-
-	resp := map[string]interface{}{
-		"total": total,
-		"tasks": tasks,
+	if len(page.Tasks) > 0 {
+		if page.HasNext {
+			page.NextCursor = encodeCursor(page.Tasks[len(page.Tasks)-1], filter.Sort)
+		}
+		if page.HasPrev {
+			page.PrevCursor = encodeCursor(page.Tasks[0], filter.Sort)
+		}
 	}
-	utils.Success(w, http.StatusOK, "", resp)
+	utils.Success(w, http.StatusOK, "", page)
 }
 
 // UpdateTasks godoc
 // @Summary Update a task
-// @Description UPdate a task with given ID and values
+// @Description UPdate a task with given ID and values. Completing a task can be refused with 409 while it has
+// @Description subtasks that aren't completed, when the service runs with REQUIRE_CHILDREN_COMPLETED.
+// @Description Starting a blocked task is refused with 409 unless force is set.
+// @Description The status must be one of the task's workflow. Tasks follow the configured transitions, between
+// @Description the categories of the statuses for custom workflows, a change that isn't allowed is refused with 409
+// @Description and one missing a required field, like the resolution, with 422.
 // @Tags tasks
 // @Accept  json
 // @Produce  json
 // @Param id path int true "ID filter"
+// @Param force query bool false "Start the task even if it is blocked"
 // @Param task body model.UpdateTask true "Task update info"
 // @Success 202 {array} model.Task
 // @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Failure 422 {object} utils.Response
 // @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Router /tasks/{id} [put]
 func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	var updateTask model.UpdateTask
 	if err := json.NewDecoder(r.Body).Decode(&updateTask); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		utils.Error(w, r, http.StatusBadRequest, "Invalid request body", err)
 		return
 	}
 	if updateTask.Status != "" {
 		status, err := getStatus(string(updateTask.Status))
 		if err != nil {
-			utils.Error(w, http.StatusBadRequest, "", err)
+			utils.Error(w, r, http.StatusBadRequest, "", err)
 			return
 		}
 		updateTask.Status = status
 	}
-	task, err := h.service.Update(r.Context(), id, &updateTask)
-	if err != nil {
-		if err == utils.NoEntryError {
-			utils.Error(w, http.StatusNotFound, "", err)
+	if updateTask.Priority != "" {
+		priority, err := getPriority(string(updateTask.Priority))
+		if err != nil {
+			utils.Error(w, r, http.StatusBadRequest, "", err)
 			return
 		}
-		utils.Error(w, http.StatusInternalServerError, "", err)
+		updateTask.Priority = priority
+	}
+	force := false
+	if r.URL.Query().Get("force") != "" {
+		var err error
+		if force, err = strconv.ParseBool(r.URL.Query().Get("force")); err != nil {
+			utils.Error(w, r, http.StatusBadRequest, "Invalid force value", nil)
+			return
+		}
+	}
+	task, err := h.service.Update(r.Context(), id, &updateTask, force)
+	if err != nil {
+		serviceError(w, r, err)
 		return
 	}
 	utils.Success(w, http.StatusAccepted, "", task)
@@ -193,28 +533,364 @@ func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 // @Accept  json
 // @Produce  json
 // @Param id path string false "ID filter"
-// @Success 202 {array} model.Task
+// @Success 204
 // @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
 // @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Router /tasks/{id} [delete]
 func (h *TaskHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
-	err := h.service.Delete(r.Context(), id)
+	if err := h.service.Delete(r.Context(), id); err != nil {
+		serviceError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetSubtree godoc
+// @Summary Get a task with its subtasks
+// @Description Get the task with given ID and all its subtasks, each nested under its parent
+// @Tags tasks
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Task ID"
+// @Success 200 {object} model.TaskTree
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /tasks/{id}/subtree [get]
+func (h *TaskHandler) GetSubtree(w http.ResponseWriter, r *http.Request) {
+	tree, err := h.service.Subtree(r.Context(), chi.URLParam(r, "id"))
 	if err != nil {
-		if err == utils.NoEntryError {
-			utils.Error(w, http.StatusNotFound, "", err)
-			return
-		}
-		utils.Error(w, http.StatusInternalServerError, "", err)
+		serviceError(w, r, err)
+		return
+	}
+	utils.Success(w, http.StatusOK, "", tree)
+}
+
+// MoveTask godoc
+// @Summary Move a task under another parent
+// @Description Make the task with given ID a subtask of parent_id, or a top level task when parent_id is null.
+// @Description A task can't be moved under itself or one of its subtasks.
+// @Tags tasks
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Task ID"
+// @Param move body model.MoveTask true "New parent"
+// @Success 202 {object} model.Task
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /tasks/{id}/move [post]
+func (h *TaskHandler) MoveTask(w http.ResponseWriter, r *http.Request) {
+	var move model.MoveTask
+	if err := json.NewDecoder(r.Body).Decode(&move); err != nil {
+		utils.Error(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	task, err := h.service.Move(r.Context(), chi.URLParam(r, "id"), move.ParentID)
+	if err != nil {
+		serviceError(w, r, err)
+		return
+	}
+	utils.Success(w, http.StatusAccepted, "", task)
+}
+
+// SetTaskProject godoc
+// @Summary Move a task to another project
+// @Description Put the task with given ID and all its subtasks in project_id, a project the user is a member of,
+// @Description or take them out of their project when project_id is null. Subtasks follow their top level task.
+// @Tags tasks
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Task ID"
+// @Param project body model.SetTaskProject true "New project"
+// @Success 202 {object} model.Task
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /tasks/{id}/project [put]
+func (h *TaskHandler) SetTaskProject(w http.ResponseWriter, r *http.Request) {
+	var project model.SetTaskProject
+	if err := json.NewDecoder(r.Body).Decode(&project); err != nil {
+		utils.Error(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	task, err := h.service.SetProject(r.Context(), chi.URLParam(r, "id"), project.ProjectID)
+	if err != nil {
+		serviceError(w, r, err)
 		return
 	}
-	utils.Success(w, http.StatusNoContent, "", nil)
+	utils.Success(w, http.StatusAccepted, "", task)
 }
 
+// AddDependency godoc
+// @Summary Block a task by another task
+// @Description Make the task with given ID wait for blocked_by_id, another task of the same owner.
+// @Description Dependencies that would close a cycle are refused.
+// @Tags tasks
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Task ID"
+// @Param dependency body model.AddDependency true "Blocking task"
+// @Success 201 {object} model.Task
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /tasks/{id}/dependencies [post]
+func (h *TaskHandler) AddDependency(w http.ResponseWriter, r *http.Request) {
+	var dependency model.AddDependency
+	if err := json.NewDecoder(r.Body).Decode(&dependency); err != nil {
+		utils.Error(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if dependency.BlockedByID == 0 {
+		utils.Error(w, r, http.StatusBadRequest, "missing values in body", nil)
+		return
+	}
+	task, err := h.service.AddDependency(r.Context(), chi.URLParam(r, "id"), dependency.BlockedByID)
+	if err != nil {
+		serviceError(w, r, err)
+		return
+	}
+	utils.Success(w, http.StatusCreated, "", task)
+}
+
+// RemoveDependency godoc
+// @Summary Unblock a task
+// @Description Remove the dependency of the task with given ID on the task blockedByID
+// @Tags tasks
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Task ID"
+// @Param blockedByID path int true "Blocking task ID"
+// @Success 204
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /tasks/{id}/dependencies/{blockedByID} [delete]
+func (h *TaskHandler) RemoveDependency(w http.ResponseWriter, r *http.Request) {
+	blockedByID, err := strconv.ParseUint(chi.URLParam(r, "blockedByID"), 10, 64)
+	if err != nil {
+		serviceError(w, r, utils.NoEntryError)
+		return
+	}
+	if err := h.service.RemoveDependency(r.Context(), chi.URLParam(r, "id"), uint(blockedByID)); err != nil {
+		serviceError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AssignTask godoc
+// @Summary Assign a user to a task
+// @Description Add user_id to the assignees of the task with given ID, its owner or a member of its project.
+// @Description The change is recorded in the history of the task.
+// @Tags tasks
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Task ID"
+// @Param assignee body model.AssignTask true "User to assign"
+// @Success 201 {object} model.Task
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /tasks/{id}/assignees [post]
+func (h *TaskHandler) AssignTask(w http.ResponseWriter, r *http.Request) {
+	var assign model.AssignTask
+	if err := json.NewDecoder(r.Body).Decode(&assign); err != nil {
+		utils.Error(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	task, err := h.service.Assign(r.Context(), chi.URLParam(r, "id"), assign.UserID)
+	if err != nil {
+		serviceError(w, r, err)
+		return
+	}
+	utils.Success(w, http.StatusCreated, "", task)
+}
+
+// UnassignTask godoc
+// @Summary Unassign a user from a task
+// @Description Remove userID from the assignees of the task with given ID, the change is recorded in its history
+// @Tags tasks
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Task ID"
+// @Param userID path int true "Assigned user ID"
+// @Success 204
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /tasks/{id}/assignees/{userID} [delete]
+func (h *TaskHandler) UnassignTask(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseUint(chi.URLParam(r, "userID"), 10, 64)
+	if err != nil {
+		serviceError(w, r, utils.NoEntryError)
+		return
+	}
+	if err := h.service.Unassign(r.Context(), chi.URLParam(r, "id"), uint(userID)); err != nil {
+		serviceError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetHistory godoc
+// @Summary Get the history of a task
+// @Description Get the recorded changes of the task with given ID oldest first, the users assigned to it and unassigned from it
+// @Tags tasks
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Task ID"
+// @Success 200 {array} model.TaskEvent
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /tasks/{id}/history [get]
+func (h *TaskHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	history, err := h.service.History(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		serviceError(w, r, err)
+		return
+	}
+	utils.Success(w, http.StatusOK, "", history)
+}
+
+// GetGraph godoc
+// @Summary Get the dependency graph of a task
+// @Description Get the task with given ID, the tasks blocking it and the tasks it blocks, directly or not, with the dependencies between them
+// @Tags tasks
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Task ID"
+// @Success 200 {object} model.TaskGraph
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /tasks/{id}/graph [get]
+func (h *TaskHandler) GetGraph(w http.ResponseWriter, r *http.Request) {
+	graph, err := h.service.Graph(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		serviceError(w, r, err)
+		return
+	}
+	utils.Success(w, http.StatusOK, "", graph)
+}
+
+// serviceError maps errors returned by the service layer to an error response
+func serviceError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, utils.InvalidInputError):
+		utils.Error(w, r, http.StatusBadRequest, "", err)
+	case errors.Is(err, utils.NoEntryError):
+		utils.Error(w, r, http.StatusNotFound, "", err)
+	case errors.Is(err, utils.UnauthenticatedError):
+		utils.Error(w, r, http.StatusUnauthorized, "", err)
+	case errors.Is(err, utils.ForbiddenError):
+		utils.Error(w, r, http.StatusForbidden, "", err)
+	case errors.Is(err, utils.ConflictError):
+		utils.Error(w, r, http.StatusConflict, "", err)
+	case errors.Is(err, utils.UnprocessableError):
+		utils.Error(w, r, http.StatusUnprocessableEntity, "", err)
+	default:
+		utils.Error(w, r, http.StatusInternalServerError, "", err)
+	}
+}
+
+// getStatus checks the status is well formed, the service checks it is part of the task's workflow
 func getStatus(statusStr string) (model.TaskStatus, error) {
-	switch model.TaskStatus(statusStr) {
-	case model.StatusPending, model.StatusCompleted, model.StatusInProcess:
-		return model.TaskStatus(statusStr), nil
+	if status := model.TaskStatus(statusStr); model.ValidStatusName(status) {
+		return status, nil
+	}
+	return "", errors.New("Invalid status value")
+}
+
+// getUser parses a user id, me stands for the authenticated user
+func getUser(r *http.Request, userStr string) (uint, error) {
+	if userStr == "me" {
+		identity, ok := auth.FromContext(r.Context())
+		if !ok {
+			return 0, utils.UnauthenticatedError
+		}
+		return identity.UserID, nil
+	}
+	userID, err := strconv.ParseUint(userStr, 10, 64)
+	if err != nil || userID == 0 {
+		return 0, errors.New("Invalid user id")
+	}
+	return uint(userID), nil
+}
+
+func getPriority(priorityStr string) (model.TaskPriority, error) {
+	switch model.TaskPriority(priorityStr) {
+	case model.PriorityLow, model.PriorityMedium, model.PriorityHigh, model.PriorityUrgent:
+		return model.TaskPriority(priorityStr), nil
+	}
+	return "", errors.New("Invalid priority value")
+}
+
+func getLabelMatch(matchStr string) (model.LabelMatch, error) {
+	switch model.LabelMatch(matchStr) {
+	case model.LabelMatchAny, model.LabelMatchAll:
+		return model.LabelMatch(matchStr), nil
+	}
+	return "", errors.New("Invalid label_match value")
+}
+
+// getSort parses a comma separated list of fields, each prefixed with - for descending order
+func getSort(sortStr string) ([]model.TaskSort, error) {
+	var fields []model.TaskSort
+	seen := map[model.TaskSortField]bool{}
+	for _, name := range strings.Split(sortStr, ",") {
+		var sort model.TaskSort
+		name, sort.Desc = strings.CutPrefix(strings.TrimSpace(name), "-")
+		sort.Field = model.TaskSortField(name)
+		if !sort.Field.Valid() {
+			return nil, fmt.Errorf("unknown sort field %q", name)
+		}
+		if seen[sort.Field] {
+			return nil, fmt.Errorf("sort field %q repeated", name)
+		}
+		seen[sort.Field] = true
+		fields = append(fields, sort)
 	}
-	return "", errors.New("Invlaid status value")
+	return fields, nil
 }
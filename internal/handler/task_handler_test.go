@@ -0,0 +1,467 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akhilbidhuri/taskkr/internal/auth"
+	"github.com/akhilbidhuri/taskkr/internal/middleware"
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/repository"
+	"github.com/akhilbidhuri/taskkr/internal/repository/memory"
+	"github.com/akhilbidhuri/taskkr/internal/requestid"
+	"github.com/akhilbidhuri/taskkr/internal/service"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files with the actual responses")
+
+const testRequestID = "test-request-id"
+
+var (
+	member   = &auth.Identity{UserID: 1, Subject: "1", Role: auth.RoleMember}
+	admin    = &auth.Identity{UserID: 9, Subject: "9", Role: auth.RoleAdmin}
+	readOnly = &auth.Identity{UserID: 3, Subject: "3", Role: auth.RoleReadOnly}
+)
+
+// seedLabels, seedWorkflows and seed are stored before every test case, ids follow the order
+var seedLabels = []*model.Label{
+	{UserID: 1, Name: "bug", Color: "#d73a4a"},
+	{UserID: 1, Name: "backend"},
+	{UserID: 2, Name: "docs"},
+}
+
+var seedWorkflows = []*model.Workflow{
+	{UserID: 1, Name: "Code review", Statuses: []model.WorkflowStatus{
+		{Name: "todo", Category: model.CategoryTodo},
+		{Name: "review", Category: model.CategoryDoing},
+		{Name: "shipped", Category: model.CategoryDone},
+	}},
+	{UserID: 1, Name: "Simple", Statuses: []model.WorkflowStatus{
+		{Name: "open", Category: model.CategoryTodo},
+		{Name: "closed", Category: model.CategoryDone},
+	}},
+	{UserID: 2, Name: "Editorial", Statuses: []model.WorkflowStatus{
+		{Name: "draft", Category: model.CategoryTodo},
+		{Name: "published", Category: model.CategoryDone},
+	}},
+}
+
+var seed = []*model.Task{
+	{UserID: 1, Title: "Write docs", Description: "for the API", Status: model.StatusPending},
+	{UserID: 1, Title: "Fix bug", Status: model.StatusInProcess, Priority: model.PriorityUrgent, DueAt: &pastDue, Labels: []string{"bug", "backend"}},
+	{UserID: 2, Title: "Review docs", Status: model.StatusCompleted, DueAt: &pastDue, Labels: []string{"docs"}},
+}
+
+var pastDue = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestTaskHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		identity *auth.Identity
+		method   string
+		path     string
+		body     string
+		// repo replaces the seeded in-memory repository
+		repo repository.TaskRepository
+		opts service.TaskOptions
+	}{
+		{name: "create", identity: member, method: http.MethodPost, path: "/", body: `{"title":"New task","description":"details"}`},
+		{name: "create_ignores_owner", identity: member, method: http.MethodPost, path: "/", body: `{"title":"Mine","user_id":2,"id":40,"created_at":"2020-01-01T00:00:00Z"}`},
+		{name: "create_with_dates", identity: member, method: http.MethodPost, path: "/", body: `{"title":"Plan","start_at":"2030-01-01T09:00:00+05:30","due_at":"2030-01-02T17:00:00-08:00"}`},
+		{name: "create_due_before_start", identity: member, method: http.MethodPost, path: "/", body: `{"title":"Plan","start_at":"2030-01-02T00:00:00Z","due_at":"2030-01-01T00:00:00Z"}`},
+		{name: "create_invalid_date", identity: member, method: http.MethodPost, path: "/", body: `{"title":"Plan","due_at":"tomorrow"}`},
+		{name: "create_with_labels", identity: member, method: http.MethodPost, path: "/", body: `{"title":"Crash","labels":["bug"," backend"]}`},
+		{name: "create_unknown_label", identity: member, method: http.MethodPost, path: "/", body: `{"title":"Crash","labels":["docs"]}`},
+		{name: "create_with_priority", identity: member, method: http.MethodPost, path: "/", body: `{"title":"Hotfix","priority":"high"}`},
+		{name: "create_invalid_priority", identity: member, method: http.MethodPost, path: "/", body: `{"title":"Hotfix","priority":"asap"}`},
+		{name: "create_malformed_json", identity: member, method: http.MethodPost, path: "/", body: `{"title":`},
+		{name: "create_with_workflow", identity: member, method: http.MethodPost, path: "/", body: `{"title":"Spec","workflow_id":1}`},
+		{name: "create_with_workflow_status", identity: member, method: http.MethodPost, path: "/", body: `{"title":"Spec","workflow_id":1,"status":"review"}`},
+		{name: "create_status_not_in_workflow", identity: member, method: http.MethodPost, path: "/", body: `{"title":"Spec","workflow_id":1,"status":"pending"}`},
+		{name: "create_with_other_users_workflow", identity: member, method: http.MethodPost, path: "/", body: `{"title":"Spec","workflow_id":3}`},
+		{name: "create_invalid_status", identity: member, method: http.MethodPost, path: "/", body: `{"title":"New task","status":"done"}`},
+		{name: "create_missing_title", identity: member, method: http.MethodPost, path: "/", body: `{"description":"no title"}`},
+		{name: "create_unauthenticated", method: http.MethodPost, path: "/", body: `{"title":"New task"}`},
+		{name: "create_read_only", identity: readOnly, method: http.MethodPost, path: "/", body: `{"title":"New task"}`},
+		{name: "create_database_error", identity: member, method: http.MethodPost, path: "/", body: `{"title":"New task"}`, repo: failingRepository{}},
+
+		{name: "get", identity: member, method: http.MethodGet, path: "/1"},
+		{name: "get_missing_id", identity: member, method: http.MethodGet, path: "/99"},
+		{name: "get_invalid_id", identity: member, method: http.MethodGet, path: "/abc"},
+		{name: "get_other_users_task", identity: member, method: http.MethodGet, path: "/3"},
+		{name: "get_as_admin", identity: admin, method: http.MethodGet, path: "/3"},
+		{name: "get_database_error", identity: member, method: http.MethodGet, path: "/1", repo: failingRepository{}},
+
+		{name: "list", identity: member, method: http.MethodGet, path: "/"},
+		{name: "list_as_read_only", identity: readOnly, method: http.MethodGet, path: "/"},
+		{name: "list_filtered", identity: admin, method: http.MethodGet, path: "/?status=completed&title=DOCS"},
+		{name: "list_paginated", identity: member, method: http.MethodGet, path: "/?page=2&page_size=1"},
+		{name: "list_past_last_page", identity: member, method: http.MethodGet, path: "/?page=5"},
+		{name: "list_overdue", identity: admin, method: http.MethodGet, path: "/?overdue=true"},
+		{name: "list_due_between", identity: admin, method: http.MethodGet, path: "/?due_after=2019-12-31T21:00:00-02:00&due_before=2030-01-01T00:00:00Z"},
+		{name: "list_invalid_due_before", identity: member, method: http.MethodGet, path: "/?due_before=2030-01-01"},
+		{name: "list_invalid_overdue", identity: member, method: http.MethodGet, path: "/?overdue=maybe"},
+		{name: "list_by_label", identity: admin, method: http.MethodGet, path: "/?label=bug,docs"},
+		{name: "list_by_all_labels", identity: member, method: http.MethodGet, path: "/?label=bug,backend&label_match=all"},
+		{name: "list_invalid_label_match", identity: member, method: http.MethodGet, path: "/?label=bug&label_match=some"},
+		{name: "list_by_priority", identity: member, method: http.MethodGet, path: "/?priority=urgent"},
+		{name: "list_sorted_by_priority", identity: admin, method: http.MethodGet, path: "/?sort=priority,due_at"},
+		{name: "list_sorted", identity: admin, method: http.MethodGet, path: "/?sort=-status,title"},
+		{name: "list_invalid_priority", identity: member, method: http.MethodGet, path: "/?priority=asap"},
+		{name: "list_invalid_sort", identity: member, method: http.MethodGet, path: "/?sort=title,owner"},
+		{name: "list_repeated_sort", identity: member, method: http.MethodGet, path: "/?sort=title,-title"},
+		{name: "list_first_page", identity: admin, method: http.MethodGet, path: "/?page_size=1"},
+		{name: "list_after_cursor", identity: admin, method: http.MethodGet, path: "/?page_size=1&after=" + encodeCursor(&model.Task{ID: 1}, nil)},
+		{name: "list_before_cursor", identity: admin, method: http.MethodGet, path: "/?page_size=1&before=" + encodeCursor(&model.Task{ID: 3}, nil)},
+		{name: "list_after_cursor_with_total", identity: admin, method: http.MethodGet, path: "/?total=true&after=" + encodeCursor(&model.Task{ID: 1}, nil)},
+		{name: "list_sorted_after_cursor", identity: admin, method: http.MethodGet, path: "/?sort=-priority&after=" + encodeCursor(&model.Task{ID: 1, Priority: model.PriorityMedium}, []model.TaskSort{{Field: model.SortPriority, Desc: true}})},
+		{name: "list_invalid_cursor", identity: member, method: http.MethodGet, path: "/?after=not-a-cursor"},
+		{name: "list_cursor_of_other_sort", identity: member, method: http.MethodGet, path: "/?sort=title&after=" + encodeCursor(&model.Task{ID: 1}, nil)},
+		{name: "list_cursor_and_page", identity: member, method: http.MethodGet, path: "/?page=2&after=" + encodeCursor(&model.Task{ID: 1}, nil)},
+		{name: "list_by_category", identity: admin, method: http.MethodGet, path: "/?category=done"},
+		{name: "list_invalid_category", identity: member, method: http.MethodGet, path: "/?category=closed"},
+		{name: "list_invalid_status", identity: member, method: http.MethodGet, path: "/?status=In%20Process"},
+		{name: "list_invalid_page", identity: member, method: http.MethodGet, path: "/?page=-1"},
+		{name: "list_page_size_too_large", identity: member, method: http.MethodGet, path: "/?page_size=101"},
+		{name: "list_database_error", identity: member, method: http.MethodGet, path: "/", repo: failingRepository{}},
+
+		{name: "update", identity: member, method: http.MethodPut, path: "/1", body: `{"status":"completed","title":"Docs written","resolution":"published"}`},
+		{name: "update_start", identity: member, method: http.MethodPut, path: "/1", body: `{"status":"in_process"}`},
+		{name: "update_complete_without_resolution", identity: member, method: http.MethodPut, path: "/1", body: `{"status":"completed","resolution":"  "}`},
+		{name: "update_same_status", identity: member, method: http.MethodPut, path: "/1", body: `{"status":"pending","title":"Docs"}`},
+		{name: "update_reopen", identity: admin, method: http.MethodPut, path: "/3", body: `{"status":"in_process"}`},
+		{name: "update_illegal_transition", identity: admin, method: http.MethodPut, path: "/3", body: `{"status":"pending"}`},
+		{name: "update_status_of_other_workflow", identity: member, method: http.MethodPut, path: "/1", body: `{"status":"review"}`},
+		{name: "update_configured_transitions", identity: member, method: http.MethodPut, path: "/2", body: `{"status":"pending"}`, opts: service.TaskOptions{Transitions: []service.TaskTransition{
+			{From: model.StatusPending, To: model.StatusInProcess},
+			{From: model.StatusInProcess, To: model.StatusCompleted, Requires: []string{service.FieldResolution}},
+		}}},
+		{name: "update_due_at", identity: member, method: http.MethodPut, path: "/1", body: `{"due_at":"2030-06-30T12:00:00+02:00"}`},
+		{name: "update_due_before_start", identity: member, method: http.MethodPut, path: "/2", body: `{"start_at":"2021-01-01T00:00:00Z"}`},
+		{name: "update_labels", identity: member, method: http.MethodPut, path: "/2", body: `{"labels":["backend"]}`},
+		{name: "update_clear_labels", identity: member, method: http.MethodPut, path: "/2", body: `{"labels":[]}`},
+		{name: "update_priority", identity: member, method: http.MethodPut, path: "/1", body: `{"priority":"low"}`},
+		{name: "update_invalid_priority", identity: member, method: http.MethodPut, path: "/1", body: `{"priority":"asap"}`},
+		{name: "update_malformed_json", identity: member, method: http.MethodPut, path: "/1", body: `not json`},
+		{name: "update_invalid_status", identity: member, method: http.MethodPut, path: "/1", body: `{"status":"done"}`},
+		{name: "update_missing_id", identity: member, method: http.MethodPut, path: "/99", body: `{"title":"Nothing"}`},
+		{name: "update_other_users_task", identity: member, method: http.MethodPut, path: "/3", body: `{"title":"Stolen"}`},
+		{name: "update_read_only", identity: readOnly, method: http.MethodPut, path: "/1", body: `{"title":"Nope"}`},
+
+		{name: "delete", identity: member, method: http.MethodDelete, path: "/1"},
+		{name: "delete_missing_id", identity: member, method: http.MethodDelete, path: "/99"},
+		{name: "delete_other_users_task", identity: member, method: http.MethodDelete, path: "/3"},
+		{name: "delete_database_error", identity: member, method: http.MethodDelete, path: "/1", repo: failingRepository{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := seededDB(t)
+			repo := tt.repo
+			if repo == nil {
+				repo = memory.NewTaskRepository(db)
+			}
+			h := NewTaskHandler(service.NewTaskService(repo, memory.NewWorkflowRepository(db), memory.NewProjectRepository(db), tt.opts))
+			got := serve(t, h.Routes(), tt.identity, tt.method, tt.path, tt.body)
+			assertGolden(t, filepath.Join("testdata", "tasks", tt.name+".golden"), got)
+		})
+	}
+}
+
+// subtasks are stored after seed for the subtask tests, "Fix bug" (2) gets a small tree
+var subtasks = []*model.Task{
+	{UserID: 1, Title: "Reproduce", Status: model.StatusCompleted, ParentID: ptr[uint](2)},
+	{UserID: 1, Title: "Patch", ParentID: ptr[uint](2)},
+	{UserID: 1, Title: "Write regression test", ParentID: ptr[uint](5)},
+}
+
+func TestTaskHandlerSubtasks(t *testing.T) {
+	tests := []struct {
+		name     string
+		identity *auth.Identity
+		method   string
+		path     string
+		body     string
+		opts     service.TaskOptions
+	}{
+		{name: "create_subtask", identity: member, method: http.MethodPost, path: "/", body: `{"title":"Deploy","parent_id":2}`},
+		{name: "create_under_other_users_task", identity: member, method: http.MethodPost, path: "/", body: `{"title":"Deploy","parent_id":3}`},
+		{name: "get_with_progress", identity: member, method: http.MethodGet, path: "/2?progress=true"},
+		{name: "get_leaf_with_progress", identity: member, method: http.MethodGet, path: "/6?progress=true"},
+		{name: "get_invalid_progress", identity: member, method: http.MethodGet, path: "/2?progress=lots"},
+		{name: "subtree", identity: member, method: http.MethodGet, path: "/2/subtree"},
+		{name: "subtree_missing_id", identity: member, method: http.MethodGet, path: "/99/subtree"},
+		{name: "subtree_other_users_task", identity: member, method: http.MethodGet, path: "/3/subtree"},
+		{name: "move", identity: member, method: http.MethodPost, path: "/6/move", body: `{"parent_id":1}`},
+		{name: "move_to_top_level", identity: member, method: http.MethodPost, path: "/5/move", body: `{"parent_id":null}`},
+		{name: "move_under_descendant", identity: member, method: http.MethodPost, path: "/2/move", body: `{"parent_id":6}`},
+		{name: "move_under_itself", identity: member, method: http.MethodPost, path: "/2/move", body: `{"parent_id":2}`},
+		{name: "move_under_other_users_task", identity: member, method: http.MethodPost, path: "/1/move", body: `{"parent_id":3}`},
+		{name: "move_read_only", identity: readOnly, method: http.MethodPost, path: "/6/move", body: `{"parent_id":1}`},
+		{name: "complete_with_open_subtasks", identity: member, method: http.MethodPut, path: "/2", body: `{"status":"completed"}`, opts: service.TaskOptions{RequireChildrenCompleted: true}},
+		{name: "complete_with_open_subtasks_allowed", identity: member, method: http.MethodPut, path: "/2", body: `{"status":"completed"}`},
+		{name: "complete_leaf", identity: member, method: http.MethodPut, path: "/6", body: `{"status":"completed","resolution":"covered"}`, opts: service.TaskOptions{RequireChildrenCompleted: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := seededDB(t)
+			repo := memory.NewTaskRepository(db)
+			for _, task := range subtasks {
+				task := *task
+				if err := repo.Create(context.Background(), &task); err != nil {
+					t.Fatalf("seed: %v", err)
+				}
+			}
+			h := NewTaskHandler(service.NewTaskService(repo, memory.NewWorkflowRepository(db), memory.NewProjectRepository(db), tt.opts))
+			got := serve(t, h.Routes(), tt.identity, tt.method, tt.path, tt.body)
+			assertGolden(t, filepath.Join("testdata", "subtasks", tt.name+".golden"), got)
+		})
+	}
+}
+
+func TestTaskHandlerDependencies(t *testing.T) {
+	tests := []struct {
+		name     string
+		identity *auth.Identity
+		method   string
+		path     string
+		body     string
+	}{
+		{name: "add", identity: member, method: http.MethodPost, path: "/1/dependencies", body: `{"blocked_by_id":2}`},
+		{name: "add_missing_blocker", identity: member, method: http.MethodPost, path: "/1/dependencies", body: `{}`},
+		{name: "add_other_users_blocker", identity: member, method: http.MethodPost, path: "/1/dependencies", body: `{"blocked_by_id":3}`},
+		{name: "add_cycle", identity: member, method: http.MethodPost, path: "/2/dependencies", body: `{"blocked_by_id":4}`},
+		{name: "add_duplicate", identity: member, method: http.MethodPost, path: "/4/dependencies", body: `{"blocked_by_id":2}`},
+		{name: "add_read_only", identity: readOnly, method: http.MethodPost, path: "/1/dependencies", body: `{"blocked_by_id":2}`},
+		{name: "remove", identity: member, method: http.MethodDelete, path: "/4/dependencies/2"},
+		{name: "remove_missing", identity: member, method: http.MethodDelete, path: "/4/dependencies/3"},
+		{name: "remove_invalid_id", identity: member, method: http.MethodDelete, path: "/4/dependencies/abc"},
+		{name: "get_blocked", identity: member, method: http.MethodGet, path: "/4"},
+		{name: "graph", identity: member, method: http.MethodGet, path: "/2/graph"},
+		{name: "graph_missing_id", identity: member, method: http.MethodGet, path: "/99/graph"},
+		{name: "start_blocked", identity: member, method: http.MethodPut, path: "/4", body: `{"status":"in_process"}`},
+		{name: "start_blocked_forced", identity: member, method: http.MethodPut, path: "/4?force=true", body: `{"status":"in_process"}`},
+		{name: "start_invalid_force", identity: member, method: http.MethodPut, path: "/4?force=please", body: `{"status":"in_process"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := seededDB(t)
+			repo := memory.NewTaskRepository(db)
+			// "Release" (4) waits for "Write docs" (1) and "Fix bug" (2)
+			release := &model.Task{UserID: 1, Title: "Release"}
+			if err := repo.Create(context.Background(), release); err != nil {
+				t.Fatalf("seed: %v", err)
+			}
+			for _, blockedBy := range []uint{1, 2} {
+				if err := repo.AddDependency(context.Background(), 1, "4", blockedBy); err != nil {
+					t.Fatalf("seed: %v", err)
+				}
+			}
+			h := NewTaskHandler(service.NewTaskService(repo, memory.NewWorkflowRepository(db), memory.NewProjectRepository(db), service.TaskOptions{}))
+			got := serve(t, h.Routes(), tt.identity, tt.method, tt.path, tt.body)
+			assertGolden(t, filepath.Join("testdata", "dependencies", tt.name+".golden"), got)
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func seededDB(t *testing.T) *memory.DB {
+	t.Helper()
+	db := memory.NewDB()
+	labels := memory.NewLabelRepository(db)
+	for _, label := range seedLabels {
+		label := *label
+		if err := labels.Create(context.Background(), &label); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	workflows := memory.NewWorkflowRepository(db)
+	for _, workflow := range seedWorkflows {
+		workflow := *workflow
+		if err := workflows.Create(context.Background(), &workflow); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	repo := memory.NewTaskRepository(db)
+	for _, task := range seed {
+		task := *task
+		if err := repo.Create(context.Background(), &task); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	return db
+}
+
+// withIdentity stands in for the Auth middleware, a nil identity leaves the request unauthenticated
+func withIdentity(identity *auth.Identity, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if identity != nil {
+			r = r.WithContext(auth.NewContext(r.Context(), identity))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// serve sends the request to routes as identity and renders the response
+func serve(t *testing.T, routes http.Handler, identity *auth.Identity, method, path, body string) []byte {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(requestid.Header, testRequestID)
+	rec := httptest.NewRecorder()
+	middleware.RequestID(withIdentity(identity, routes)).ServeHTTP(rec, req)
+	return response(t, rec)
+}
+
+// response renders the status, content type and normalized body of the recorded response
+func response(t *testing.T, rec *httptest.ResponseRecorder) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "%d %s\n", rec.Code, http.StatusText(rec.Code))
+	if ct := rec.Header().Get("Content-Type"); ct != "" {
+		fmt.Fprintf(&out, "Content-Type: %s\n", ct)
+	}
+	if rec.Body.Len() == 0 {
+		return out.Bytes()
+	}
+
+	var body interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("response body isn't JSON: %v\n%s", err, rec.Body)
+	}
+	normalize(body)
+	out.WriteByte('\n')
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(body); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+// normalize replaces the values that change on every run
+func normalize(v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			switch key {
+			case "created_at", "updated_at", "started_at", "completed_at":
+				if value != nil {
+					v[key] = "<timestamp>"
+				}
+			default:
+				normalize(value)
+			}
+		}
+	case []interface{}:
+		for _, value := range v {
+			normalize(value)
+		}
+	}
+}
+
+func assertGolden(t *testing.T, path string, got []byte) {
+	t.Helper()
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v, run the tests with -update to create it", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("response differs from %s\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+// failingRepository fails every call like a database that is down
+type failingRepository struct{}
+
+var errDatabaseDown = errors.New("database is down")
+
+func (failingRepository) Create(context.Context, *model.Task) error {
+	return errDatabaseDown
+}
+
+func (failingRepository) GetByID(context.Context, uint, string) (*model.Task, error) {
+	return nil, errDatabaseDown
+}
+
+func (failingRepository) Update(context.Context, uint, string, *model.UpdateTask) (*model.Task, error) {
+	return nil, errDatabaseDown
+}
+
+func (failingRepository) Delete(context.Context, uint, string) error {
+	return errDatabaseDown
+}
+
+func (failingRepository) List(context.Context, *model.TaskFilter) ([]*model.Task, int, error) {
+	return nil, 0, errDatabaseDown
+}
+
+func (failingRepository) CountByStatus(context.Context) (map[model.TaskStatus]int, error) {
+	return nil, errDatabaseDown
+}
+
+func (failingRepository) CountByCategory(context.Context) (map[model.StatusCategory]int, error) {
+	return nil, errDatabaseDown
+}
+
+func (failingRepository) Subtree(context.Context, uint, string) ([]*model.Task, error) {
+	return nil, errDatabaseDown
+}
+
+func (failingRepository) Move(context.Context, uint, string, *uint) (*model.Task, error) {
+	return nil, errDatabaseDown
+}
+
+func (failingRepository) SetProject(context.Context, uint, string, *uint) (*model.Task, error) {
+	return nil, errDatabaseDown
+}
+
+func (failingRepository) CountChildren(context.Context, uint) (map[model.StatusCategory]int, error) {
+	return nil, errDatabaseDown
+}
+
+func (failingRepository) AddDependency(context.Context, uint, string, uint) error {
+	return errDatabaseDown
+}
+
+func (failingRepository) RemoveDependency(context.Context, uint, string, uint) error {
+	return errDatabaseDown
+}
+
+func (failingRepository) Graph(context.Context, uint, string) (*model.TaskGraph, error) {
+	return nil, errDatabaseDown
+}
+
+func (failingRepository) Assign(context.Context, uint, string, uint, uint) error {
+	return errDatabaseDown
+}
+
+func (failingRepository) Unassign(context.Context, uint, string, uint, uint) error {
+	return errDatabaseDown
+}
+
+func (failingRepository) History(context.Context, uint, string) ([]*model.TaskEvent, error) {
+	return nil, errDatabaseDown
+}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/akhilbidhuri/taskkr/internal/auth"
+	"github.com/akhilbidhuri/taskkr/internal/middleware"
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/service"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type WorkflowHandler struct {
+	service *service.WorkflowService
+}
+
+func NewWorkflowHandler(service *service.WorkflowService) *WorkflowHandler {
+	return &WorkflowHandler{service: service}
+}
+
+func (h *WorkflowHandler) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.With(middleware.RequirePermission(auth.PermTaskRead)).Get("/", h.ListWorkflows)
+	r.With(middleware.RequirePermission(auth.PermTaskWrite)).Post("/", h.CreateWorkflow)
+	r.With(middleware.RequirePermission(auth.PermTaskRead)).Get("/{id}", h.GetWorkflow)
+	r.With(middleware.RequirePermission(auth.PermTaskWrite)).Put("/{id}", h.UpdateWorkflow)
+	r.With(middleware.RequirePermission(auth.PermTaskWrite)).Delete("/{id}", h.DeleteWorkflow)
+	return r
+}
+
+// CreateWorkflow godoc
+// @Summary Create a workflow
+// @Description Create a workflow owned by the authenticated user, names are unique per user. Statuses are listed in order,
+// @Description each with a category of todo, doing or done, and at least a todo and a done status are needed.
+// @Description New tasks of the workflow start in its first todo status.
+// @Tags workflows
+// @Accept  json
+// @Produce  json
+// @Param workflow body model.Workflow true "Workflow info"
+// @Success 201 {object} model.Workflow
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /workflows [post]
+func (h *WorkflowHandler) CreateWorkflow(w http.ResponseWriter, r *http.Request) {
+	var workflow model.Workflow
+	if err := json.NewDecoder(r.Body).Decode(&workflow); err != nil {
+		utils.Error(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if err := h.service.Create(r.Context(), &workflow); err != nil {
+		serviceError(w, r, err)
+		return
+	}
+	utils.Success(w, http.StatusCreated, "", workflow)
+}
+
+// ListWorkflows godoc
+// @Summary List workflows
+// @Description List the workflows of the authenticated user sorted by name, tasks without a workflow
+// @Description use the built-in pending (todo), in_process (doing) and completed (done) statuses
+// @Tags workflows
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} model.Workflow
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /workflows [get]
+func (h *WorkflowHandler) ListWorkflows(w http.ResponseWriter, r *http.Request) {
+	workflows, err := h.service.List(r.Context())
+	if err != nil {
+		serviceError(w, r, err)
+		return
+	}
+	utils.Success(w, http.StatusOK, "", workflows)
+}
+
+// GetWorkflow godoc
+// @Summary Get a workflow
+// @Description Get the workflow with given ID and its statuses
+// @Tags workflows
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Workflow ID"
+// @Success 200 {object} model.Workflow
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /workflows/{id} [get]
+func (h *WorkflowHandler) GetWorkflow(w http.ResponseWriter, r *http.Request) {
+	workflow, err := h.service.GetByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		serviceError(w, r, err)
+		return
+	}
+	if workflow == nil {
+		serviceError(w, r, utils.NoEntryError)
+		return
+	}
+	utils.Success(w, http.StatusOK, "", workflow)
+}
+
+// UpdateWorkflow godoc
+// @Summary Update a workflow
+// @Description Rename the workflow with given ID or replace its statuses, its tasks move to the new categories.
+// @Description Dropping a status some task still has is refused with 409.
+// @Tags workflows
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Workflow ID"
+// @Param workflow body model.UpdateWorkflow true "Workflow update info"
+// @Success 202 {object} model.Workflow
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /workflows/{id} [put]
+func (h *WorkflowHandler) UpdateWorkflow(w http.ResponseWriter, r *http.Request) {
+	var update model.UpdateWorkflow
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		utils.Error(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	workflow, err := h.service.Update(r.Context(), chi.URLParam(r, "id"), &update)
+	if err != nil {
+		serviceError(w, r, err)
+		return
+	}
+	utils.Success(w, http.StatusAccepted, "", workflow)
+}
+
+// DeleteWorkflow godoc
+// @Summary Delete a workflow
+// @Description Delete the workflow with given ID, refused with 409 while tasks use it
+// @Tags workflows
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Workflow ID"
+// @Success 204
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Failure 409 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /workflows/{id} [delete]
+func (h *WorkflowHandler) DeleteWorkflow(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.Delete(r.Context(), chi.URLParam(r, "id")); err != nil {
+		serviceError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
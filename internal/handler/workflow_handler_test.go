@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/akhilbidhuri/taskkr/internal/auth"
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/repository/memory"
+	"github.com/akhilbidhuri/taskkr/internal/service"
+)
+
+// spec is stored after seed for the workflow tests, it is in the review status of "Code review" (1)
+var spec = &model.Task{UserID: 1, Title: "Spec", WorkflowID: ptr[uint](1), Status: "review", Category: model.CategoryDoing}
+
+func seededWorkflowDB(t *testing.T) *memory.DB {
+	t.Helper()
+	db := seededDB(t)
+	task := *spec
+	if err := memory.NewTaskRepository(db).Create(context.Background(), &task); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	return db
+}
+
+func TestWorkflowHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		identity *auth.Identity
+		method   string
+		path     string
+		body     string
+	}{
+		{name: "create", identity: member, method: http.MethodPost, path: "/", body: `{"name":" Kanban ","user_id":2,"statuses":[{"name":"backlog","category":"todo"},{"name":"qa","category":"doing"},{"name":"done","category":"done"}]}`},
+		{name: "create_duplicate", identity: member, method: http.MethodPost, path: "/", body: `{"name":"Simple","statuses":[{"name":"open","category":"todo"},{"name":"closed","category":"done"}]}`},
+		{name: "create_missing_name", identity: member, method: http.MethodPost, path: "/", body: `{"statuses":[{"name":"open","category":"todo"},{"name":"closed","category":"done"}]}`},
+		{name: "create_without_done", identity: member, method: http.MethodPost, path: "/", body: `{"name":"Endless","statuses":[{"name":"open","category":"todo"},{"name":"working","category":"doing"}]}`},
+		{name: "create_invalid_status_name", identity: member, method: http.MethodPost, path: "/", body: `{"name":"Loud","statuses":[{"name":"To Do","category":"todo"},{"name":"closed","category":"done"}]}`},
+		{name: "create_unknown_category", identity: member, method: http.MethodPost, path: "/", body: `{"name":"Odd","statuses":[{"name":"open","category":"todo"},{"name":"closed","category":"finished"}]}`},
+		{name: "create_repeated_status", identity: member, method: http.MethodPost, path: "/", body: `{"name":"Twice","statuses":[{"name":"open","category":"todo"},{"name":"open","category":"done"}]}`},
+		{name: "create_read_only", identity: readOnly, method: http.MethodPost, path: "/", body: `{"name":"Simple","statuses":[{"name":"open","category":"todo"},{"name":"closed","category":"done"}]}`},
+
+		{name: "list", identity: member, method: http.MethodGet, path: "/"},
+		{name: "get", identity: member, method: http.MethodGet, path: "/1"},
+		{name: "get_other_users_workflow", identity: member, method: http.MethodGet, path: "/3"},
+
+		{name: "update_name", identity: member, method: http.MethodPut, path: "/1", body: `{"name":"Review"}`},
+		{name: "update_duplicate_name", identity: member, method: http.MethodPut, path: "/1", body: `{"name":"Simple"}`},
+		{name: "update_statuses", identity: member, method: http.MethodPut, path: "/1", body: `{"statuses":[{"name":"todo","category":"todo"},{"name":"review","category":"done"}]}`},
+		{name: "update_drop_used_status", identity: member, method: http.MethodPut, path: "/1", body: `{"statuses":[{"name":"todo","category":"todo"},{"name":"shipped","category":"done"}]}`},
+		{name: "update_invalid_statuses", identity: member, method: http.MethodPut, path: "/1", body: `{"statuses":[]}`},
+		{name: "update_other_users_workflow", identity: member, method: http.MethodPut, path: "/3", body: `{"name":"Mine"}`},
+
+		{name: "delete", identity: member, method: http.MethodDelete, path: "/2"},
+		{name: "delete_in_use", identity: member, method: http.MethodDelete, path: "/1"},
+		{name: "delete_missing_id", identity: member, method: http.MethodDelete, path: "/99"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewWorkflowHandler(service.NewWorkflowService(memory.NewWorkflowRepository(seededWorkflowDB(t))))
+			got := serve(t, h.Routes(), tt.identity, tt.method, tt.path, tt.body)
+			assertGolden(t, filepath.Join("testdata", "workflows", tt.name+".golden"), got)
+		})
+	}
+}
+
+func TestTaskHandlerWorkflows(t *testing.T) {
+	tests := []struct {
+		name     string
+		identity *auth.Identity
+		method   string
+		path     string
+		body     string
+	}{
+		{name: "ship", identity: member, method: http.MethodPut, path: "/4", body: `{"status":"shipped"}`},
+		{name: "move_back_to_todo", identity: member, method: http.MethodPut, path: "/4", body: `{"status":"todo"}`},
+		{name: "built_in_status", identity: member, method: http.MethodPut, path: "/4", body: `{"status":"completed"}`},
+		{name: "list_by_status", identity: member, method: http.MethodGet, path: "/?status=review"},
+		{name: "list_by_category", identity: member, method: http.MethodGet, path: "/?category=doing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := seededWorkflowDB(t)
+			h := NewTaskHandler(service.NewTaskService(memory.NewTaskRepository(db), memory.NewWorkflowRepository(db), memory.NewProjectRepository(db), service.TaskOptions{}))
+			got := serve(t, h.Routes(), tt.identity, tt.method, tt.path, tt.body)
+			assertGolden(t, filepath.Join("testdata", "workflow_tasks", tt.name+".golden"), got)
+		})
+	}
+}
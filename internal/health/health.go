@@ -0,0 +1,151 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/akhilbidhuri/taskkr/internal/logger"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+)
+
+// Check reports an error when the dependency it checks isn't usable
+type Check func(ctx context.Context) error
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// CheckResult is the outcome of a single check, the probes are unauthenticated
+// so the error of a failed check is only logged
+type CheckResult struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Duration string `json:"duration"`
+}
+
+// Report is returned by the probes in verbose mode
+type Report struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker serves the liveness and readiness probes. Readiness runs every
+// registered check and fails as soon as the server starts shutting down.
+type Checker struct {
+	timeout      time.Duration
+	mu           sync.RWMutex
+	checks       []namedCheck
+	shuttingDown atomic.Bool
+}
+
+// New creates a checker, each check is cancelled after timeout
+func New(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// AddReadinessCheck registers a check run by the readiness probe
+func (c *Checker) AddReadinessCheck(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// SetShuttingDown makes the readiness probe fail so no new traffic is routed here
+func (c *Checker) SetShuttingDown() {
+	c.shuttingDown.Store(true)
+}
+
+// Ready runs all readiness checks concurrently
+func (c *Checker) Ready(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := append([]namedCheck(nil), c.checks...)
+	c.mu.RUnlock()
+
+	report := Report{Status: StatusUp, Checks: make([]CheckResult, len(checks))}
+	var wg sync.WaitGroup
+	for i, nc := range checks {
+		wg.Add(1)
+		go func(i int, nc namedCheck) {
+			defer wg.Done()
+			report.Checks[i] = c.run(ctx, nc)
+		}(i, nc)
+	}
+	wg.Wait()
+
+	if c.shuttingDown.Load() {
+		report.Checks = append(report.Checks, CheckResult{Name: "shutdown", Status: StatusDown})
+	}
+	for _, result := range report.Checks {
+		if result.Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, nc namedCheck) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := nc.check(ctx)
+	result := CheckResult{Name: nc.name, Status: StatusUp, Duration: time.Since(start).String()}
+	if err != nil {
+		result.Status = StatusDown
+		logger.FromContext(ctx).Warn("readiness check failed", "check", nc.name, "error", err)
+	}
+	return result
+}
+
+// LiveHandler reports that the process is running, it doesn't check dependencies
+// so a database outage doesn't get the pod restarted
+func (c *Checker) LiveHandler(w http.ResponseWriter, r *http.Request) {
+	write(w, r, Report{Status: StatusUp, Checks: []CheckResult{}})
+}
+
+// ReadyHandler reports whether the service can serve traffic
+func (c *Checker) ReadyHandler(w http.ResponseWriter, r *http.Request) {
+	write(w, r, c.Ready(r.Context()))
+}
+
+// write sends a plain text status, or the full report as JSON when the verbose query param is set
+func write(w http.ResponseWriter, r *http.Request, report Report) {
+	code := http.StatusOK
+	if report.Status != StatusUp {
+		code = http.StatusServiceUnavailable
+	}
+
+	if verbose(r) {
+		utils.JSON(w, code, utils.Response{Success: code == http.StatusOK, Data: report})
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(code)
+	if code == http.StatusOK {
+		w.Write([]byte("OK"))
+		return
+	}
+	w.Write([]byte("NOT READY"))
+}
+
+func verbose(r *http.Request) bool {
+	if !r.URL.Query().Has("verbose") {
+		return false
+	}
+	v := r.URL.Query().Get("verbose")
+	if v == "" {
+		return true
+	}
+	b, err := strconv.ParseBool(v)
+	return err == nil && b
+}
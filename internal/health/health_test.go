@@ -0,0 +1,158 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func up(context.Context) error { return nil }
+
+// down fails like a database whose connection details shouldn't leak
+func down(context.Context) error {
+	return errors.New("dial tcp 10.0.0.5:5432: password authentication failed for user taskkr")
+}
+
+func serve(handler http.HandlerFunc, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec
+}
+
+func TestProbes(t *testing.T) {
+	tests := []struct {
+		name         string
+		checks       map[string]Check
+		shuttingDown bool
+		wantLive     int
+		wantReady    int
+	}{
+		{name: "no checks", wantLive: http.StatusOK, wantReady: http.StatusOK},
+		{name: "checks up", checks: map[string]Check{"database": up, "migrations": up}, wantLive: http.StatusOK, wantReady: http.StatusOK},
+		{name: "check down", checks: map[string]Check{"database": down, "migrations": up}, wantLive: http.StatusOK, wantReady: http.StatusServiceUnavailable},
+		{name: "shutting down", checks: map[string]Check{"database": up}, shuttingDown: true, wantLive: http.StatusOK, wantReady: http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(time.Second)
+			for name, check := range tt.checks {
+				c.AddReadinessCheck(name, check)
+			}
+			if tt.shuttingDown {
+				c.SetShuttingDown()
+			}
+
+			for _, probe := range []struct {
+				name    string
+				handler http.HandlerFunc
+				want    int
+			}{
+				{"livez", c.LiveHandler, tt.wantLive},
+				{"readyz", c.ReadyHandler, tt.wantReady},
+			} {
+				rec := serve(probe.handler, "/"+probe.name)
+				wantBody := "OK"
+				if probe.want != http.StatusOK {
+					wantBody = "NOT READY"
+				}
+				if rec.Code != probe.want || rec.Body.String() != wantBody {
+					t.Errorf("%s = %d %q, want %d %q", probe.name, rec.Code, rec.Body.String(), probe.want, wantBody)
+				}
+				if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+					t.Errorf("%s Content-Type = %q, want text/plain", probe.name, ct)
+				}
+			}
+		})
+	}
+}
+
+func TestReadyVerbose(t *testing.T) {
+	c := New(time.Second)
+	c.AddReadinessCheck("database", down)
+	c.AddReadinessCheck("migrations", up)
+	c.SetShuttingDown()
+
+	rec := serve(c.ReadyHandler, "/readyz?verbose")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "10.0.0.5") || strings.Contains(rec.Body.String(), "password") {
+		t.Errorf("body = %s, want the check error kept out of it", rec.Body.String())
+	}
+
+	var body struct {
+		Success bool `json:"success"`
+		Data    struct {
+			Status string                       `json:"status"`
+			Checks []map[string]json.RawMessage `json:"checks"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode %s: %v", rec.Body.String(), err)
+	}
+	if body.Success || body.Data.Status != StatusDown || len(body.Data.Checks) != 3 {
+		t.Fatalf("report = %s, want 3 checks down", rec.Body.String())
+	}
+	want := []struct{ name, status string }{{"database", StatusDown}, {"migrations", StatusUp}, {"shutdown", StatusDown}}
+	for i, check := range body.Data.Checks {
+		if len(check) != 3 || check["duration"] == nil {
+			t.Errorf("check %d = %v, want only name, status and duration", i, check)
+		}
+		if string(check["name"]) != `"`+want[i].name+`"` || string(check["status"]) != `"`+want[i].status+`"` {
+			t.Errorf("check %d = %s %s, want %s %s", i, check["name"], check["status"], want[i].name, want[i].status)
+		}
+	}
+
+	for _, target := range []string{"/readyz?verbose=false", "/readyz?verbose=nope"} {
+		if rec := serve(c.ReadyHandler, target); rec.Body.String() != "NOT READY" {
+			t.Errorf("%s = %q, want the plain text answer", target, rec.Body.String())
+		}
+	}
+	if rec := serve(c.LiveHandler, "/livez?verbose=true"); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"status":"up"`) {
+		t.Errorf("livez?verbose = %d %s, want the report up", rec.Code, rec.Body.String())
+	}
+}
+
+func TestReadyRunsChecksConcurrently(t *testing.T) {
+	c := New(time.Second)
+	// each check waits for the other one to start, run one after the other they would time out
+	started := make(chan struct{})
+	meet := func(ctx context.Context) error {
+		select {
+		case started <- struct{}{}:
+			return nil
+		case <-started:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	c.AddReadinessCheck("first", meet)
+	c.AddReadinessCheck("second", meet)
+
+	if report := c.Ready(context.Background()); report.Status != StatusUp {
+		t.Errorf("Ready = %+v, want both checks up", report)
+	}
+}
+
+func TestReadyTimeout(t *testing.T) {
+	c := New(20 * time.Millisecond)
+	c.AddReadinessCheck("hanging", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	start := time.Now()
+	report := c.Ready(context.Background())
+	if report.Status != StatusDown || report.Checks[0].Status != StatusDown {
+		t.Errorf("Ready = %+v, want the hanging check down", report)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Ready took %v, want the check cancelled after its timeout", elapsed)
+	}
+}
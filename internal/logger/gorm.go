@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// GormLogger sends GORM logs to the logger of the query's context so SQL
+// logs carry the same request attributes as the rest of the request
+type GormLogger struct {
+	SlowThreshold time.Duration
+}
+
+func NewGormLogger(slowThreshold time.Duration) *GormLogger {
+	return &GormLogger{SlowThreshold: slowThreshold}
+}
+
+// LogMode is a no-op, the level is controlled by the slog handler
+func (l *GormLogger) LogMode(gormlogger.LogLevel) gormlogger.Interface {
+	return l
+}
+
+func (l *GormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	FromContext(ctx).InfoContext(ctx, msg, "args", args)
+}
+
+func (l *GormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	FromContext(ctx).WarnContext(ctx, msg, "args", args)
+}
+
+func (l *GormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	FromContext(ctx).ErrorContext(ctx, msg, "args", args)
+}
+
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	log := FromContext(ctx)
+	elapsed := time.Since(begin)
+
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		sql, rows := fc()
+		log.ErrorContext(ctx, "query failed", "sql", sql, "rows", rows, "duration", elapsed, "error", err)
+	case l.SlowThreshold > 0 && elapsed > l.SlowThreshold:
+		sql, rows := fc()
+		log.WarnContext(ctx, "slow query", "sql", sql, "rows", rows, "duration", elapsed)
+	case log.Enabled(ctx, slog.LevelDebug):
+		sql, rows := fc()
+		log.DebugContext(ctx, "query", "sql", sql, "rows", rows, "duration", elapsed)
+	}
+}
+
+// ParamsFilter keeps query parameters out of the logs, SQL is logged with placeholders
+func (l *GormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// New creates a logger writing to w, format is either "json" or "text"
+// and level one of debug, info, warn or error
+func New(w io.Writer, level, format string) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: ParseLevel(level)}
+	if strings.EqualFold(format, "text") {
+		return slog.New(slog.NewTextHandler(w, opts))
+	}
+	return slog.New(slog.NewJSONHandler(w, opts))
+}
+
+// ParseLevel converts a level name to a slog.Level, unknown names fall back to info
+func ParseLevel(level string) slog.Level {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return slog.LevelInfo
+	}
+	return l
+}
+
+type loggerKey struct{}
+
+type fieldsKey struct{}
+
+// fields collects attributes added while a request is handled so they can be
+// part of the access log line written once the request is done
+type fields struct {
+	mu    sync.Mutex
+	attrs []slog.Attr
+}
+
+// NewContext returns a copy of ctx carrying the logger
+func NewContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext returns the logger stored in ctx, or the default logger
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+// NewRequestContext stores the request scoped logger in ctx and starts
+// collecting the attributes added with WithAttrs
+func NewRequestContext(ctx context.Context, l *slog.Logger) context.Context {
+	ctx = context.WithValue(ctx, fieldsKey{}, &fields{})
+	return NewContext(ctx, l)
+}
+
+// WithAttrs adds the attributes to the logger in ctx and to the request's access log line
+func WithAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	if f, ok := ctx.Value(fieldsKey{}).(*fields); ok {
+		f.mu.Lock()
+		f.attrs = append(f.attrs, attrs...)
+		f.mu.Unlock()
+	}
+	args := make([]any, len(attrs))
+	for i, attr := range attrs {
+		args[i] = attr
+	}
+	return NewContext(ctx, FromContext(ctx).With(args...))
+}
+
+// RequestAttrs returns the attributes added with WithAttrs during the request
+func RequestAttrs(ctx context.Context) []slog.Attr {
+	f, ok := ctx.Value(fieldsKey{}).(*fields)
+	if !ok {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]slog.Attr(nil), f.attrs...)
+}
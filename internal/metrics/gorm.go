@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"gorm.io/gorm"
+)
+
+const startTimeKey = "taskkr:metrics_start"
+
+// InstrumentGorm records the duration of every query run through db and
+// exports the connection pool stats of the underlying sql.DB
+func (m *Metrics) InstrumentGorm(db *gorm.DB, dbName string) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	if err := m.Register(collectors.NewDBStatsCollector(sqlDB, dbName)); err != nil {
+		return err
+	}
+
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("taskkr:metrics_start", startTimer),
+		cb.Create().After("gorm:create").Register("taskkr:metrics_observe", m.observeQuery("create")),
+		cb.Query().Before("gorm:query").Register("taskkr:metrics_start", startTimer),
+		cb.Query().After("gorm:query").Register("taskkr:metrics_observe", m.observeQuery("query")),
+		cb.Update().Before("gorm:update").Register("taskkr:metrics_start", startTimer),
+		cb.Update().After("gorm:update").Register("taskkr:metrics_observe", m.observeQuery("update")),
+		cb.Delete().Before("gorm:delete").Register("taskkr:metrics_start", startTimer),
+		cb.Delete().After("gorm:delete").Register("taskkr:metrics_observe", m.observeQuery("delete")),
+		cb.Row().Before("gorm:row").Register("taskkr:metrics_start", startTimer),
+		cb.Row().After("gorm:row").Register("taskkr:metrics_observe", m.observeQuery("row")),
+		cb.Raw().Before("gorm:raw").Register("taskkr:metrics_start", startTimer),
+		cb.Raw().After("gorm:raw").Register("taskkr:metrics_observe", m.observeQuery("raw")),
+	)
+}
+
+func startTimer(db *gorm.DB) {
+	db.InstanceSet(startTimeKey, time.Now())
+}
+
+func (m *Metrics) observeQuery(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(startTimeKey)
+		if !ok {
+			return
+		}
+		start, ok := v.(time.Time)
+		if !ok {
+			return
+		}
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		failed := db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound)
+		m.ObserveDBQuery(operation, table, failed, time.Since(start))
+	}
+}
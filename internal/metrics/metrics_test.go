@@ -0,0 +1,127 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akhilbidhuri/taskkr/internal/model"
+
+	"github.com/glebarez/sqlite"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func TestObserveHTTPRequest(t *testing.T) {
+	m := New()
+	for i := 0; i < 3; i++ {
+		m.ObserveHTTPRequest("GET", "/api/v1/tasks/{id}", 200, 10*time.Millisecond)
+	}
+	m.ObserveHTTPRequest("GET", "/api/v1/tasks/{id}", 404, time.Millisecond)
+
+	if got := testutil.CollectAndCount(m.httpDuration); got != 2 {
+		t.Errorf("duration series = %d, want one per status", got)
+	}
+	if got := testutil.ToFloat64(m.httpRequests.WithLabelValues("GET", "/api/v1/tasks/{id}", "200")); got != 3 {
+		t.Errorf("requests = %v, want 3", got)
+	}
+}
+
+// stubCounter returns fixed counts, or err
+type stubCounter struct {
+	err error
+}
+
+func (c stubCounter) CountByStatus(context.Context) (map[model.TaskStatus]int, error) {
+	return map[model.TaskStatus]int{model.StatusPending: 2, "review": 1}, c.err
+}
+
+func (c stubCounter) CountByCategory(context.Context) (map[model.StatusCategory]int, error) {
+	return map[model.StatusCategory]int{model.CategoryTodo: 2, model.CategoryDoing: 1}, c.err
+}
+
+func TestTaskCollector(t *testing.T) {
+	expected := `
+# HELP taskkr_tasks Number of tasks by status, built-in or of a workflow, deleted tasks are not counted.
+# TYPE taskkr_tasks gauge
+taskkr_tasks{status="completed"} 0
+taskkr_tasks{status="in_process"} 0
+taskkr_tasks{status="pending"} 2
+taskkr_tasks{status="review"} 1
+# HELP taskkr_tasks_by_category Number of tasks by status category, deleted tasks are not counted.
+# TYPE taskkr_tasks_by_category gauge
+taskkr_tasks_by_category{category="doing"} 1
+taskkr_tasks_by_category{category="done"} 0
+taskkr_tasks_by_category{category="todo"} 2
+`
+	if err := testutil.CollectAndCompare(NewTaskCollector(stubCounter{}), strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+
+	m := New()
+	if err := m.Register(NewTaskCollector(stubCounter{err: errors.New("database down")})); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if _, err := m.registry.Gather(); err == nil {
+		t.Error("Gather succeeded, want the count error reported")
+	}
+}
+
+type widget struct {
+	ID   uint
+	Name string
+}
+
+func TestInstrumentGorm(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "metrics.db")), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	m := New()
+	if err := m.InstrumentGorm(db, "taskkr"); err != nil {
+		t.Fatalf("InstrumentGorm: %v", err)
+	}
+
+	if err := db.Exec("CREATE TABLE widgets (id INTEGER PRIMARY KEY, name TEXT)").Error; err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	if err := db.Create(&widget{Name: "gear"}).Error; err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	var widgets []widget
+	if err := db.Find(&widgets).Error; err != nil {
+		t.Fatalf("find: %v", err)
+	}
+	db.Table("missing").Find(&widgets)
+
+	want := map[string]bool{"raw unknown false": true, "create widgets false": true, "query widgets false": true, "query missing true": true}
+	families, err := m.registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+	got := map[string]bool{}
+	for _, family := range families {
+		if family.GetName() != "taskkr_db_query_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, pair := range metric.GetLabel() {
+				labels[pair.GetName()] = pair.GetValue()
+			}
+			got[labels["operation"]+" "+labels["table"]+" "+labels["error"]] = true
+		}
+	}
+	for series := range want {
+		if !got[series] {
+			t.Errorf("query series = %v, want %q", got, series)
+		}
+	}
+	if testutil.CollectAndCount(m.dbQueryDuration) != len(got) {
+		t.Errorf("CollectAndCount differs from the gathered series %v", got)
+	}
+}
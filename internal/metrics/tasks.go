@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/akhilbidhuri/taskkr/internal/model"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// StatusCounter counts the tasks of every user grouped by status and by status category
+type StatusCounter interface {
+	CountByStatus(ctx context.Context) (map[model.TaskStatus]int, error)
+	CountByCategory(ctx context.Context) (map[model.StatusCategory]int, error)
+}
+
+// taskCollector exposes the number of tasks per status and per category, counted at scrape time
+type taskCollector struct {
+	counter      StatusCounter
+	timeout      time.Duration
+	desc         *prometheus.Desc
+	categoryDesc *prometheus.Desc
+}
+
+func NewTaskCollector(counter StatusCounter) prometheus.Collector {
+	return &taskCollector{
+		counter: counter,
+		timeout: 5 * time.Second,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "tasks"),
+			"Number of tasks by status, built-in or of a workflow, deleted tasks are not counted.",
+			[]string{"status"}, nil,
+		),
+		categoryDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "tasks_by_category"),
+			"Number of tasks by status category, deleted tasks are not counted.",
+			[]string{"category"}, nil,
+		),
+	}
+}
+
+func (c *taskCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+	ch <- c.categoryDesc
+}
+
+func (c *taskCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	counts, err := c.counter.CountByStatus(ctx)
+	if err != nil {
+		slog.Error("failed to count tasks for metrics", "error", err)
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+	for _, status := range []model.TaskStatus{model.StatusPending, model.StatusInProcess, model.StatusCompleted} {
+		if _, ok := counts[status]; !ok {
+			counts[status] = 0
+		}
+	}
+	for status, count := range counts {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(count), string(status))
+	}
+
+	categories, err := c.counter.CountByCategory(ctx)
+	if err != nil {
+		slog.Error("failed to count tasks for metrics", "error", err)
+		ch <- prometheus.NewInvalidMetric(c.categoryDesc, err)
+		return
+	}
+	for _, category := range model.Categories {
+		ch <- prometheus.MustNewConstMetric(c.categoryDesc, prometheus.GaugeValue, float64(categories[category]), string(category))
+	}
+}
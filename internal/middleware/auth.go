@@ -1 +1,79 @@
 package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/akhilbidhuri/taskkr/internal/auth"
+	"github.com/akhilbidhuri/taskkr/internal/logger"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+)
+
+// Auth rejects requests without a valid bearer token or API key and stores the
+// caller identity in the request context for the downstream handlers.
+// API keys are accepted in the X-API-Key header or as a bearer token.
+func Auth(verifier *auth.TokenVerifier, keys auth.KeyAuthenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var identity *auth.Identity
+			var err error
+			if key := r.Header.Get("X-API-Key"); key != "" {
+				identity, err = keys.Authenticate(r.Context(), key)
+			} else if token := bearerToken(r); auth.IsAPIKey(token) {
+				identity, err = keys.Authenticate(r.Context(), token)
+			} else {
+				identity, err = verifier.Verify(token)
+			}
+			if err != nil {
+				unauthorized(w, r, err)
+				return
+			}
+			ctx := auth.NewContext(r.Context(), identity)
+			ctx = logger.WithAttrs(ctx, slog.Uint64("user_id", uint64(identity.UserID)))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// unauthorized rejects the credentials with a 401, other failures such as a
+// database outage while looking up an API key are logged and answered with a
+// 500 without their details
+func unauthorized(w http.ResponseWriter, r *http.Request, err error) {
+	if !errors.Is(err, auth.ErrMissingToken) && !errors.Is(err, auth.ErrInvalidToken) && !errors.Is(err, auth.ErrInvalidAPIKey) {
+		logger.FromContext(r.Context()).Error("authentication failed", "error", err)
+		utils.Error(w, r, http.StatusInternalServerError, "Internal server error", nil)
+		return
+	}
+	w.Header().Set("WWW-Authenticate", `Bearer realm="taskkr"`)
+	utils.Error(w, r, http.StatusUnauthorized, "Unauthorized", err)
+}
+
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// RequirePermission rejects requests whose caller's role doesn't grant perm,
+// it must run after Auth so the identity is present in the context
+func RequirePermission(perm auth.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity, ok := auth.FromContext(r.Context())
+			if !ok {
+				utils.Error(w, r, http.StatusUnauthorized, "Unauthorized", utils.UnauthenticatedError)
+				return
+			}
+			if !identity.Can(perm) {
+				utils.Error(w, r, http.StatusForbidden, "Forbidden", utils.ForbiddenError)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akhilbidhuri/taskkr/internal/auth"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// stubKeys authenticates "tk_valid" and fails other keys with err
+type stubKeys struct {
+	err error
+}
+
+func (k stubKeys) Authenticate(_ context.Context, key string) (*auth.Identity, error) {
+	if key == "tk_valid" {
+		return &auth.Identity{UserID: 9, Subject: "api_key:1", Role: auth.RoleMember}, nil
+	}
+	return nil, k.err
+}
+
+func TestAuth(t *testing.T) {
+	verifier, err := auth.NewTokenVerifier("test-secret", "", "", "", false)
+	if err != nil {
+		t.Fatalf("NewTokenVerifier: %v", err)
+	}
+	sign := func(secret string, expiresIn time.Duration) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+			Subject:   "7",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
+		}).SignedString([]byte(secret))
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		return token
+	}
+	token := sign("test-secret", time.Hour)
+	databaseDown := errors.New("dial tcp 10.0.0.5:5432: connection refused")
+
+	tests := []struct {
+		name       string
+		header     string
+		value      string
+		keyErr     error
+		wantStatus int
+		wantUser   uint
+	}{
+		{name: "jwt", header: "Authorization", value: "Bearer " + token, wantStatus: http.StatusOK, wantUser: 7},
+		{name: "api key header", header: "X-API-Key", value: "tk_valid", wantStatus: http.StatusOK, wantUser: 9},
+		{name: "api key bearer", header: "Authorization", value: "Bearer tk_valid", wantStatus: http.StatusOK, wantUser: 9},
+		{name: "jwt lowercase scheme", header: "Authorization", value: "bearer " + token, wantStatus: http.StatusOK, wantUser: 7},
+		{name: "missing", wantStatus: http.StatusUnauthorized},
+		{name: "empty bearer", header: "Authorization", value: "Bearer ", wantStatus: http.StatusUnauthorized},
+		{name: "basic scheme", header: "Authorization", value: "Basic " + token, wantStatus: http.StatusUnauthorized},
+		{name: "without scheme", header: "Authorization", value: token, wantStatus: http.StatusUnauthorized},
+		{name: "invalid jwt", header: "Authorization", value: "Bearer not.a.jwt", wantStatus: http.StatusUnauthorized},
+		{name: "expired jwt", header: "Authorization", value: "Bearer " + sign("test-secret", -time.Minute), wantStatus: http.StatusUnauthorized},
+		{name: "jwt of another secret", header: "Authorization", value: "Bearer " + sign("other-secret", time.Hour), wantStatus: http.StatusUnauthorized},
+		{name: "invalid api key", header: "X-API-Key", value: "tk_other", keyErr: fmt.Errorf("%w: key expired", auth.ErrInvalidAPIKey), wantStatus: http.StatusUnauthorized},
+		{name: "api key lookup failure", header: "X-API-Key", value: "tk_other", keyErr: databaseDown, wantStatus: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotUser uint
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				identity, _ := auth.FromContext(r.Context())
+				gotUser = identity.UserID
+			})
+			req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+			Auth(verifier, stubKeys{err: tt.keyErr})(next).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus || gotUser != tt.wantUser {
+				t.Fatalf("status %d for user %d, want %d for user %d: %s", rec.Code, gotUser, tt.wantStatus, tt.wantUser, rec.Body)
+			}
+			if tt.wantStatus == http.StatusOK {
+				return
+			}
+			var body utils.Response
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			if body.Success || strings.Contains(rec.Body.String(), "10.0.0.5") {
+				t.Errorf("body = %s, want an error without the cause of a server failure", rec.Body)
+			}
+			if hasChallenge := rec.Header().Get("WWW-Authenticate") != ""; hasChallenge != (tt.wantStatus == http.StatusUnauthorized) {
+				t.Errorf("WWW-Authenticate = %q for status %d", rec.Header().Get("WWW-Authenticate"), rec.Code)
+			}
+		})
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	tests := []struct {
+		name       string
+		identity   *auth.Identity
+		perm       auth.Permission
+		wantStatus int
+	}{
+		{name: "member writes", identity: &auth.Identity{UserID: 1, Role: auth.RoleMember}, perm: auth.PermTaskWrite, wantStatus: http.StatusOK},
+		{name: "read only reads", identity: &auth.Identity{UserID: 1, Role: auth.RoleReadOnly}, perm: auth.PermTaskRead, wantStatus: http.StatusOK},
+		{name: "read only writes", identity: &auth.Identity{UserID: 1, Role: auth.RoleReadOnly}, perm: auth.PermTaskWrite, wantStatus: http.StatusForbidden},
+		{name: "member manages keys", identity: &auth.Identity{UserID: 1, Role: auth.RoleMember}, perm: auth.PermAPIKeyManage, wantStatus: http.StatusForbidden},
+		{name: "admin manages keys", identity: &auth.Identity{UserID: 1, Role: auth.RoleAdmin}, perm: auth.PermAPIKeyManage, wantStatus: http.StatusOK},
+		{name: "unknown role", identity: &auth.Identity{UserID: 1, Role: "root"}, perm: auth.PermTaskRead, wantStatus: http.StatusForbidden},
+		{name: "anonymous", perm: auth.PermTaskRead, wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+			if tt.identity != nil {
+				req = req.WithContext(auth.NewContext(req.Context(), tt.identity))
+			}
+			rec := httptest.NewRecorder()
+			RequirePermission(tt.perm)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})).ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+		})
+	}
+}
@@ -1 +1,68 @@
 package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+
+	"github.com/akhilbidhuri/taskkr/internal/logger"
+	"github.com/akhilbidhuri/taskkr/internal/requestid"
+)
+
+// RequestLogger stores a request scoped logger in the context and writes
+// one structured access log line per request once it's served
+func RequestLogger(l *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			reqLogger := l
+			if id := requestid.FromContext(r.Context()); id != "" {
+				reqLogger = l.With("request_id", id)
+			}
+			ctx := logger.NewRequestContext(r.Context(), reqLogger)
+			ww := chiMiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			route := routePattern(r)
+			if route == "" {
+				route = r.URL.Path
+			}
+			attrs := []slog.Attr{
+				slog.String("method", r.Method),
+				slog.String("route", route),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", status),
+				slog.Duration("latency", time.Since(start)),
+				slog.Int("bytes", ww.BytesWritten()),
+				slog.String("remote_addr", r.RemoteAddr),
+			}
+			attrs = append(attrs, logger.RequestAttrs(ctx)...)
+
+			level := slog.LevelInfo
+			switch {
+			case status >= http.StatusInternalServerError:
+				level = slog.LevelError
+			case status >= http.StatusBadRequest:
+				level = slog.LevelWarn
+			}
+			reqLogger.LogAttrs(ctx, level, "request", attrs...)
+		})
+	}
+}
+
+// routePattern returns the chi route pattern matched by the request,
+// it's only complete once the request has been routed
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		return rctx.RoutePattern()
+	}
+	return ""
+}
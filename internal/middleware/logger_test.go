@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/akhilbidhuri/taskkr/internal/auth"
+	"github.com/akhilbidhuri/taskkr/internal/logger"
+	"github.com/akhilbidhuri/taskkr/internal/requestid"
+)
+
+func TestRequestLogger(t *testing.T) {
+	var logs bytes.Buffer
+	r := chi.NewRouter()
+	r.Use(RequestID, RequestLogger(slog.New(slog.NewJSONHandler(&logs, nil))))
+	r.With(Auth(nil, stubKeys{err: auth.ErrInvalidAPIKey})).Get("/api/v1/tasks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		logger.FromContext(r.Context()).Info("loading task")
+		w.Write([]byte("{}"))
+	})
+
+	tests := []struct {
+		name      string
+		path      string
+		key       string
+		wantLines int
+		want      map[string]any
+	}{
+		{
+			name: "authenticated", path: "/api/v1/tasks/12", key: "tk_valid", wantLines: 2,
+			want: map[string]any{"level": "INFO", "method": "GET", "route": "/api/v1/tasks/{id}", "path": "/api/v1/tasks/12", "status": 200.0, "bytes": 2.0, "user_id": 9.0},
+		},
+		{
+			name: "rejected", path: "/api/v1/tasks/12", key: "tk_other", wantLines: 1,
+			want: map[string]any{"level": "WARN", "route": "/api/v1/tasks/{id}", "status": 401.0, "user_id": nil},
+		},
+		{
+			name: "unmatched", path: "/nowhere", wantLines: 1,
+			want: map[string]any{"level": "WARN", "route": "/nowhere", "status": 404.0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set(requestid.Header, "req-"+tt.name)
+			if tt.key != "" {
+				req.Header.Set("X-API-Key", tt.key)
+			}
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+			if len(lines) != tt.wantLines {
+				t.Fatalf("logged %d lines, want %d: %s", len(lines), tt.wantLines, logs.String())
+			}
+			entries := make([]map[string]any, len(lines))
+			for i, line := range lines {
+				if err := json.Unmarshal([]byte(line), &entries[i]); err != nil {
+					t.Fatalf("decode %s: %v", line, err)
+				}
+				// every line of the request, not only the access log, carries its id
+				if entries[i]["request_id"] != "req-"+tt.name {
+					t.Errorf("request_id = %v, want %q: %s", entries[i]["request_id"], "req-"+tt.name, line)
+				}
+			}
+			if tt.wantLines == 2 && entries[0]["user_id"] != 9.0 {
+				t.Errorf("service log = %v, want the user id", entries[0])
+			}
+
+			access := entries[len(entries)-1]
+			if access["msg"] != "request" {
+				t.Fatalf("last line = %v, want the access log", access)
+			}
+			for key, want := range tt.want {
+				if access[key] != want {
+					t.Errorf("%s = %v, want %v", key, access[key], want)
+				}
+			}
+			if _, ok := access["latency"].(float64); !ok {
+				t.Errorf("latency = %v, want a duration", access["latency"])
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+
+	"github.com/akhilbidhuri/taskkr/internal/metrics"
+)
+
+// Metrics records the count and latency of requests per route pattern and status
+func Metrics(m *metrics.Metrics) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ww := chiMiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			route := routePattern(r)
+			if route == "" {
+				// keep the label cardinality bounded for unknown paths
+				route = "unmatched"
+			}
+			m.ObserveHTTPRequest(r.Method, route, status, time.Since(start))
+		})
+	}
+}
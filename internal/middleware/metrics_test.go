@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/akhilbidhuri/taskkr/internal/metrics"
+)
+
+func TestMetrics(t *testing.T) {
+	m := metrics.New()
+	r := chi.NewRouter()
+	r.Use(Metrics(m))
+	r.Route("/api/v1/tasks", func(r chi.Router) {
+		r.Get("/{id}", func(w http.ResponseWriter, r *http.Request) {
+			if chi.URLParam(r, "id") == "404" {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		})
+	})
+
+	for _, path := range []string{"/api/v1/tasks/1", "/api/v1/tasks/2", "/api/v1/tasks/3", "/api/v1/tasks/404", "/unknown/7", "/unknown/8"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	// the route pattern is the label, task ids would give a series per task
+	server := httptest.NewServer(m.Handler())
+	defer server.Close()
+	expected := `
+# HELP taskkr_http_requests_total Number of HTTP requests by method, route pattern and status.
+# TYPE taskkr_http_requests_total counter
+taskkr_http_requests_total{method="GET",route="/api/v1/tasks/{id}",status="200"} 3
+taskkr_http_requests_total{method="GET",route="/api/v1/tasks/{id}",status="404"} 1
+taskkr_http_requests_total{method="GET",route="unmatched",status="404"} 2
+`
+	if err := testutil.ScrapeAndCompare(server.URL, strings.NewReader(expected), "taskkr_http_requests_total"); err != nil {
+		t.Error(err)
+	}
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if got := strings.Count(rec.Body.String(), "taskkr_http_request_duration_seconds_count{"); got != 3 {
+		t.Errorf("duration series = %d, want one per route and status", got)
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/akhilbidhuri/taskkr/internal/requestid"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		reused bool
+	}{
+		{name: "caller id", header: "trace-01:retry_2.a", reused: true},
+		{name: "missing"},
+		{name: "invalid characters", header: "id */ DROP TABLE tasks"},
+		{name: "too long", header: strings.Repeat("a", 129)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = requestid.FromContext(r.Context())
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/tasks", nil)
+			if tt.header != "" {
+				req.Header.Set(requestid.Header, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			echoed := rec.Header().Get(requestid.Header)
+			if !requestid.Valid(echoed) || seen != echoed {
+				t.Errorf("context id %q, echoed %q, want the same valid id", seen, echoed)
+			}
+			if (echoed == tt.header) != tt.reused {
+				t.Errorf("echoed %q for %q, want reused %v", echoed, tt.header, tt.reused)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package migrate_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/akhilbidhuri/taskkr/internal/config"
+	"github.com/akhilbidhuri/taskkr/internal/migrate"
+	"github.com/akhilbidhuri/taskkr/internal/repository/sqlite"
+	"github.com/akhilbidhuri/taskkr/migrations"
+)
+
+func file(sql string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(sql)}
+}
+
+func TestLoad(t *testing.T) {
+	got, err := migrate.Load(fstest.MapFS{
+		"002_add_column.up.sql":     file("ALTER TABLE t ADD c INT;"),
+		"001_create_table.up.sql":   file("CREATE TABLE t (id INT);"),
+		"001_create_table.down.sql": file("DROP TABLE t;"),
+		"readme.md":                 file("not a migration"),
+		"003.up.sql":                file("no name"),
+		"v4_named.up.sql":           file("no version"),
+		"005_sub/006_x.up.sql":      file("in a directory"),
+	})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Load = %+v, want 2 migrations", got)
+	}
+	if got[0].Version != 1 || got[0].Name != "create_table" || got[0].Up != "CREATE TABLE t (id INT);" || got[0].Down != "DROP TABLE t;" {
+		t.Errorf("first = %+v", got[0])
+	}
+	if got[1].Version != 2 || got[1].Name != "add_column" || got[1].Down != "" {
+		t.Errorf("second = %+v", got[1])
+	}
+
+	for name, fsys := range map[string]fstest.MapFS{
+		"down without up": {"001_init.down.sql": file("DROP TABLE t;")},
+		"different names": {"001_init.up.sql": file("CREATE TABLE t (id INT);"), "001_other.down.sql": file("DROP TABLE t;")},
+		"duplicate version": {
+			"001_init.up.sql": file("CREATE TABLE t (id INT);"),
+			"1_init.up.sql":   file("CREATE TABLE u (id INT);"),
+		},
+		"version overflow": {"99999999999999999999_init.up.sql": file("CREATE TABLE t (id INT);")},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got, err := migrate.Load(fsys); err == nil {
+				t.Errorf("Load = %+v, want an error", got)
+			}
+		})
+	}
+}
+
+func TestMigratorSQLite(t *testing.T) {
+	ctx := context.Background()
+	m, db := newMigrator(t)
+	total := len(mustStatus(t, m))
+
+	// a fresh database reports everything pending without creating schema_migrations
+	if pending := countPending(mustStatus(t, m)); pending != total {
+		t.Errorf("pending = %d, want all %d", pending, total)
+	}
+	if err := m.Check(ctx); err == nil {
+		t.Error("Check on a fresh database succeeded, want pending migrations")
+	}
+	var tables int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'schema_migrations'").Scan(&tables); err != nil || tables != 0 {
+		t.Errorf("schema_migrations tables = %d, %v, want Status not to create it", tables, err)
+	}
+
+	applied, err := m.Up(ctx)
+	if err != nil || len(applied) != total {
+		t.Fatalf("Up = %d migrations, %v, want %d", len(applied), err, total)
+	}
+	if err := m.Check(ctx); err != nil {
+		t.Errorf("Check after Up: %v", err)
+	}
+	for _, s := range mustStatus(t, m) {
+		if !s.Applied || s.AppliedAt == nil {
+			t.Errorf("status of %d = %+v, want applied", s.Version, s)
+		}
+	}
+	if applied, err := m.Up(ctx); err != nil || len(applied) != 0 {
+		t.Errorf("second Up = %d migrations, %v, want none", len(applied), err)
+	}
+
+	reverted, err := m.Down(ctx, 2)
+	if err != nil || len(reverted) != 2 {
+		t.Fatalf("Down(2) = %d migrations, %v", len(reverted), err)
+	}
+	statuses := mustStatus(t, m)
+	if reverted[0].Version != statuses[total-1].Version || reverted[1].Version != statuses[total-2].Version {
+		t.Errorf("Down(2) reverted %d and %d, want the latest two", reverted[0].Version, reverted[1].Version)
+	}
+	if pending := countPending(statuses); pending != 2 || statuses[total-1].Applied || statuses[total-2].Applied {
+		t.Errorf("pending after Down(2) = %d, want the latest two", pending)
+	}
+	if err := m.Check(ctx); err == nil {
+		t.Error("Check after Down succeeded, want pending migrations")
+	}
+
+	if applied, err := m.Up(ctx); err != nil || len(applied) != 2 {
+		t.Errorf("Up after Down(2) = %d migrations, %v, want 2", len(applied), err)
+	}
+	if reverted, err := m.Down(ctx, total+1); err != nil || len(reverted) != total {
+		t.Errorf("Down(all) = %d migrations, %v, want %d", len(reverted), err, total)
+	}
+	if pending := countPending(mustStatus(t, m)); pending != total {
+		t.Errorf("pending after reverting all = %d, want %d", pending, total)
+	}
+}
+
+func TestMigratorReadOnly(t *testing.T) {
+	ctx := context.Background()
+	m, db := newMigrator(t)
+	if _, err := db.Exec("PRAGMA query_only = 1"); err != nil {
+		t.Fatalf("read only: %v", err)
+	}
+
+	if err := m.Check(ctx); err == nil {
+		t.Error("Check succeeded, want pending migrations")
+	}
+	if _, err := m.Status(ctx); err != nil {
+		t.Errorf("Status with a read-only connection: %v", err)
+	}
+	if _, err := m.Up(ctx); err == nil {
+		t.Error("Up with a read-only connection succeeded, want an error")
+	}
+}
+
+func TestNewUnknownDialect(t *testing.T) {
+	if _, err := migrate.New(nil, "mysql", fstest.MapFS{}); err == nil {
+		t.Error("New(mysql) succeeded, want an error")
+	}
+}
+
+// newMigrator returns a migrator of the embedded SQLite migrations on an empty
+// database, with the single connection of that database
+func newMigrator(t *testing.T) (*migrate.Migrator, *sql.DB) {
+	t.Helper()
+	gormDB := sqlite.NewSQLiteDB(&config.Config{SQLitePath: filepath.Join(t.TempDir(), "taskkr.db")})
+	db, err := gormDB.DB()
+	if err != nil {
+		t.Fatalf("database handle: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	files, err := migrations.For(migrate.SQLite)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	m, err := migrate.New(db, migrate.SQLite, files)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return m, db
+}
+
+func mustStatus(t *testing.T, m *migrate.Migrator) []migrate.Status {
+	t.Helper()
+	statuses, err := m.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	return statuses
+}
+
+func countPending(statuses []migrate.Status) int {
+	pending := 0
+	for _, s := range statuses {
+		if !s.Applied {
+			pending++
+		}
+	}
+	return pending
+}
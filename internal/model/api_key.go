@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+// APIKey lets service to service callers authenticate without a JWT,
+// only the SHA-256 hash of the key is stored
+type APIKey struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	Name       string     `gorm:"size:255;not null" json:"name"`
+	Prefix     string     `gorm:"size:16;not null" json:"prefix"` // first characters of the key, helps to identify it
+	KeyHash    string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	UserID     uint       `gorm:"not null;index" json:"user_id"` // user the caller acts as
+	Role       string     `gorm:"size:20;not null" json:"role"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	UsageCount int64      `gorm:"not null;default:0" json:"usage_count"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// CreateAPIKey is the request body to issue a new key
+type CreateAPIKey struct {
+	Name      string     `json:"name"`
+	UserID    uint       `json:"user_id"`
+	Role      string     `json:"role"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// IssuedAPIKey is returned once when a key is created, the plain key can't be retrieved later
+type IssuedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// Label tags tasks, each user has their own set of labels
+type Label struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_labels_user_id_name" json:"user_id"`
+	Name      string    `gorm:"size:50;not null;uniqueIndex:idx_labels_user_id_name" json:"name"`
+	Color     string    `gorm:"size:7" json:"color,omitempty"` // e.g. #d73a4a
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// UpdateLabel is the request body to rename or recolor a label
+type UpdateLabel struct {
+	Name  string `json:"name,omitempty"`
+	Color string `json:"color,omitempty"`
+}
+
+// LabelMatch tells whether a task must have any or all of the labels filtered on
+type LabelMatch string
+
+const (
+	LabelMatchAny LabelMatch = "any"
+	LabelMatchAll LabelMatch = "all"
+)
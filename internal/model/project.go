@@ -0,0 +1,48 @@
+package model
+
+import "time"
+
+// ProjectRole is what a member may do in a project
+type ProjectRole string
+
+const (
+	// RoleOwner is the user who created the project, they manage it and its members
+	RoleOwner ProjectRole = "owner"
+	// RoleMember sees the project and all its tasks
+	RoleMember ProjectRole = "member"
+)
+
+// Project groups the tasks of a team, its members see every task in it
+type Project struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	UserID      uint      `gorm:"not null;uniqueIndex:idx_projects_user_id_name" json:"user_id"` // The owner
+	Name        string    `gorm:"size:100;not null;uniqueIndex:idx_projects_user_id_name" json:"name"`
+	Description string    `gorm:"type:text" json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ProjectMember gives a user access to a project, the owner is a member too
+type ProjectMember struct {
+	ProjectID uint        `gorm:"primaryKey" json:"-"`
+	UserID    uint        `gorm:"primaryKey" json:"user_id"`
+	Role      ProjectRole `gorm:"type:varchar(10);not null" json:"role"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// UpdateProject is the request body to rename or describe a project
+type UpdateProject struct {
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// AddProjectMember is the request body to add a member to a project
+type AddProjectMember struct {
+	UserID uint `json:"user_id"`
+}
+
+// SetTaskProject is the request body to move a task to another project
+type SetTaskProject struct {
+	// ProjectID is the new project, null takes the task out of its project
+	ProjectID *uint `json:"project_id"`
+}
@@ -14,13 +14,88 @@ const (
 	StatusCompleted TaskStatus = "completed"
 )
 
+type TaskPriority string
+
+const (
+	PriorityLow    TaskPriority = "low"
+	PriorityMedium TaskPriority = "medium"
+	PriorityHigh   TaskPriority = "high"
+	PriorityUrgent TaskPriority = "urgent"
+)
+
+// Priorities lists the priorities from the least to the most urgent
+var Priorities = []TaskPriority{PriorityLow, PriorityMedium, PriorityHigh, PriorityUrgent}
+
+// Rank orders the priorities, higher is more urgent and unknown ones rank 0
+func (p TaskPriority) Rank() int {
+	for i, priority := range Priorities {
+		if p == priority {
+			return i + 1
+		}
+	}
+	return 0
+}
+
 type Task struct {
 	ID          uint           `gorm:"primaryKey" json:"id"`
-	UserID      uint           `gorm:"not null" json:"user_id"` // Associate task with a user
+	UserID      uint           `gorm:"not null;index" json:"user_id"`    // Associate task with a user
+	CreatedBy   uint           `gorm:"not null;index" json:"created_by"` // The user who created the task, user_id is its owner
+	ParentID    *uint          `gorm:"index" json:"parent_id,omitempty"` // Set on subtasks, the parent has the same owner
 	Title       string         `gorm:"size:255;not null" json:"title"`
 	Description string         `gorm:"type:text" json:"description"`
+	ProjectID   *uint          `gorm:"index" json:"project_id,omitempty"`  // Subtasks are in the project of their parent
+	WorkflowID  *uint          `gorm:"index" json:"workflow_id,omitempty"` // The workflow the status belongs to, nil for the default one
 	Status      TaskStatus     `gorm:"type:varchar(20);default:'pending'" json:"status"`
+	Category    StatusCategory `gorm:"type:varchar(10);default:'todo'" json:"category"` // Category of the status in the task's workflow
+	Priority    TaskPriority   `gorm:"type:varchar(10);default:'medium'" json:"priority"`
+	StartAt     *time.Time     `json:"start_at,omitempty"`            // Stored and returned in UTC
+	DueAt       *time.Time     `gorm:"index" json:"due_at,omitempty"` // Stored and returned in UTC
+	StartedAt   *time.Time     `json:"started_at,omitempty"`          // First time the task went to a doing status
+	CompletedAt *time.Time     `json:"completed_at,omitempty"`        // Set while the task has a done status
+	Assignees   []uint         `gorm:"-" json:"assignees,omitempty"`  // Users working on the task, in task_assignees
+	Resolution  string         `gorm:"type:text" json:"resolution,omitempty"`
+	Labels      []string       `gorm:"-" json:"labels,omitempty"`   // Names of the owner's labels, in task_labels
+	Progress    *TaskProgress  `gorm:"-" json:"progress,omitempty"` // Only filled in when asked for
+	Blocked     bool           `gorm:"-" json:"blocked,omitempty"`  // Some task blocking this one isn't done
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
 }
+
+// StampStatus records the change of the task's status to status, of the
+// category, at now. The repositories call it so the timestamps follow the
+// status whoever changes it.
+func (t *Task) StampStatus(status TaskStatus, category StatusCategory, now time.Time) {
+	if category == CategoryDoing && t.StartedAt == nil {
+		t.StartedAt = &now
+	}
+	if category == CategoryDone {
+		if t.Category != CategoryDone || t.CompletedAt == nil {
+			t.CompletedAt = &now
+		}
+	} else {
+		t.CompletedAt = nil
+	}
+	t.Status, t.Category = status, category
+}
+
+// TaskProgress sums up the direct subtasks of a task
+type TaskProgress struct {
+	Children int `json:"children"`
+	// Completed counts the children with a done status
+	Completed int `json:"completed"`
+	// Percent of the children completed, 0 without children
+	Percent int `json:"percent"`
+}
+
+// TaskTree is a task with its subtasks, nested to any depth
+type TaskTree struct {
+	*Task
+	Children []*TaskTree `json:"children"`
+}
+
+// MoveTask is the request body to move a task under another parent
+type MoveTask struct {
+	// ParentID is the new parent, null makes the task a top level one
+	ParentID *uint `json:"parent_id"`
+}
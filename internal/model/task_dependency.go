@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// TaskDependency records that TaskID can't start before BlockedByID is completed
+type TaskDependency struct {
+	TaskID      uint      `gorm:"primaryKey;autoIncrement:false" json:"task_id"`
+	BlockedByID uint      `gorm:"primaryKey;autoIncrement:false" json:"blocked_by_id"`
+	CreatedAt   time.Time `json:"-"`
+}
+
+// AddDependency is the request body to block a task by another one
+type AddDependency struct {
+	BlockedByID uint `json:"blocked_by_id"`
+}
+
+// TaskGraph is the dependency graph around a task
+type TaskGraph struct {
+	// Tasks holds the task, the tasks blocking it and the tasks it blocks, directly or not
+	Tasks []*Task           `json:"tasks"`
+	Edges []*TaskDependency `json:"edges"`
+}
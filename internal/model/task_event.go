@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+type TaskEventType string
+
+const (
+	EventAssigned   TaskEventType = "assigned"
+	EventUnassigned TaskEventType = "unassigned"
+)
+
+// TaskEvent is an entry of the history of a task, recorded with the change
+type TaskEvent struct {
+	ID      uint          `gorm:"primaryKey" json:"id"`
+	TaskID  uint          `gorm:"not null;index" json:"task_id"`
+	ActorID uint          `gorm:"not null" json:"actor_id"` // The user who made the change
+	Type    TaskEventType `gorm:"type:varchar(20);not null" json:"type"`
+	// AssigneeID is the user assigned or unassigned
+	AssigneeID *uint     `json:"assignee_id,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// AssignTask is the request body to assign a user to a task
+type AssignTask struct {
+	UserID uint `json:"user_id"`
+}
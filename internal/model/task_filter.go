@@ -1,8 +1,50 @@
 package model
 
+import "time"
+
 type TaskFilter struct {
+	UserID   uint
 	Status   TaskStatus
+	Category StatusCategory
+	Priority TaskPriority
 	Title    string
-	Page     uint
-	PageSize uint
+	// Labels keeps the tasks with any or, with LabelMatchAll, all of the label names
+	Labels     []string
+	LabelMatch LabelMatch
+	DueBefore  *time.Time
+	DueAfter   *time.Time
+	// Overdue keeps the tasks past their due date that aren't done
+	Overdue bool
+	// ProjectID keeps the tasks of the project, nil keeps all the tasks the user can see
+	ProjectID *uint
+	// AssigneeID keeps the tasks assigned to the user, Unassigned the tasks without assignee
+	AssigneeID *uint
+	Unassigned bool
+	// CreatedBy keeps the tasks created by the user
+	CreatedBy *uint
+	// InvolvedID keeps the tasks created by or assigned to the user, the work of the user
+	InvolvedID *uint
+	// Sort orders the tasks by each field in turn, ties are broken by id
+	Sort []TaskSort
+	// After and Before hold the sort key of a task, only its ID and the sorted
+	// fields are used. The list then starts right after the task, or ends right
+	// before it, and Page is ignored.
+	After  *Task
+	Before *Task
+	// SkipTotal leaves the total out, it saves a count query on every page
+	SkipTotal bool
+	Page      uint
+	PageSize  uint
+}
+
+// TaskPage is one page of a task list
+type TaskPage struct {
+	Tasks []*Task `json:"tasks"`
+	// Total counts the tasks matching the filter on all pages, it's left out when not requested
+	Total *int `json:"total,omitempty"`
+	// NextCursor and PrevCursor are passed as after and before to get the adjacent pages
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	HasNext    bool   `json:"-"`
+	HasPrev    bool   `json:"-"`
 }
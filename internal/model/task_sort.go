@@ -0,0 +1,36 @@
+package model
+
+// TaskSortField is a task attribute the task list can be ordered by
+type TaskSortField string
+
+const (
+	SortID        TaskSortField = "id"
+	SortTitle     TaskSortField = "title"
+	SortStatus    TaskSortField = "status"
+	SortPriority  TaskSortField = "priority" // most urgent first
+	SortStartAt   TaskSortField = "start_at"
+	SortDueAt     TaskSortField = "due_at"
+	SortCreatedAt TaskSortField = "created_at"
+	SortUpdatedAt TaskSortField = "updated_at"
+)
+
+// SortFields lists the fields tasks can be sorted by
+var SortFields = []TaskSortField{
+	SortID, SortTitle, SortStatus, SortPriority, SortStartAt, SortDueAt, SortCreatedAt, SortUpdatedAt,
+}
+
+// Valid reports whether tasks can be sorted by f
+func (f TaskSortField) Valid() bool {
+	for _, field := range SortFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// TaskSort orders tasks by one field, tasks without a start or due date always come last
+type TaskSort struct {
+	Field TaskSortField
+	Desc  bool
+}
@@ -1,7 +1,21 @@
 package model
 
+import "time"
+
 type UpdateTask struct {
-	Title       string     `json:"title,omitempty"`
-	Description string     `json:"description,omitempty"`
-	Status      TaskStatus `json:"status,omitempty"`
+	Title       string       `json:"title,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Status      TaskStatus   `json:"status,omitempty"`
+	Priority    TaskPriority `json:"priority,omitempty"`
+	StartAt     *time.Time   `json:"start_at,omitempty"`
+	DueAt       *time.Time   `json:"due_at,omitempty"`
+	// Resolution notes how the task ended, some status transitions require it
+	Resolution string `json:"resolution,omitempty"`
+	// Category of the new status, the service sets it from the task's workflow
+	Category StatusCategory `json:"-"`
+	// FromStatus is the status the service checked the move from, when set the
+	// update fails with a conflict if the task isn't in that status anymore
+	FromStatus TaskStatus `gorm:"-" json:"-"`
+	// Labels replaces the task's labels when set, an empty list removes them all
+	Labels *[]string `gorm:"-" json:"labels,omitempty"`
 }
@@ -0,0 +1,101 @@
+package model
+
+import (
+	"regexp"
+	"time"
+)
+
+// StatusCategory groups the statuses of every workflow, the service reasons
+// about categories so it doesn't need to know the status names
+type StatusCategory string
+
+const (
+	CategoryTodo  StatusCategory = "todo"
+	CategoryDoing StatusCategory = "doing"
+	CategoryDone  StatusCategory = "done"
+)
+
+// Categories lists the categories in the order a task goes through them
+var Categories = []StatusCategory{CategoryTodo, CategoryDoing, CategoryDone}
+
+func (c StatusCategory) IsValid() bool {
+	switch c {
+	case CategoryTodo, CategoryDoing, CategoryDone:
+		return true
+	}
+	return false
+}
+
+// statusName matches the names a workflow status can have, they fit the tasks.status column
+var statusName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,19}$`)
+
+// ValidStatusName reports whether status is a well formed status name, it
+// says nothing about the status being part of a workflow
+func ValidStatusName(status TaskStatus) bool {
+	return statusName.MatchString(string(status))
+}
+
+// Workflow is a set of statuses a user can move their tasks through, tasks
+// without a workflow use DefaultWorkflow
+type Workflow struct {
+	ID        uint             `gorm:"primaryKey" json:"id"`
+	UserID    uint             `gorm:"not null;uniqueIndex:idx_workflows_user_id_name" json:"user_id"`
+	Name      string           `gorm:"size:50;not null;uniqueIndex:idx_workflows_user_id_name" json:"name"`
+	Statuses  []WorkflowStatus `gorm:"-" json:"statuses"` // In order, stored in workflow_statuses
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+// WorkflowStatus is one status of a workflow
+type WorkflowStatus struct {
+	WorkflowID uint           `gorm:"primaryKey" json:"-"`
+	Name       TaskStatus     `gorm:"primaryKey;type:varchar(20)" json:"name"`
+	Category   StatusCategory `gorm:"type:varchar(10);not null" json:"category"`
+	Position   int            `gorm:"not null" json:"-"`
+}
+
+// UpdateWorkflow is the request body to rename a workflow or replace its statuses
+type UpdateWorkflow struct {
+	Name string `json:"name,omitempty"`
+	// Statuses replaces the statuses when set, the ones still used by tasks must be kept
+	Statuses *[]WorkflowStatus `json:"statuses,omitempty"`
+}
+
+// DefaultWorkflow holds the built-in statuses
+var DefaultWorkflow = &Workflow{
+	Name: "default",
+	Statuses: []WorkflowStatus{
+		{Name: StatusPending, Category: CategoryTodo},
+		{Name: StatusInProcess, Category: CategoryDoing, Position: 1},
+		{Name: StatusCompleted, Category: CategoryDone, Position: 2},
+	},
+}
+
+// Status returns the status of the workflow with the name, nil if there is none
+func (w *Workflow) Status(name TaskStatus) *WorkflowStatus {
+	for i := range w.Statuses {
+		if w.Statuses[i].Name == name {
+			return &w.Statuses[i]
+		}
+	}
+	return nil
+}
+
+// InitialStatus is the status new tasks get, the first one in the todo category
+func (w *Workflow) InitialStatus() TaskStatus {
+	for _, status := range w.Statuses {
+		if status.Category == CategoryTodo {
+			return status.Name
+		}
+	}
+	return ""
+}
+
+// DefaultCategory is the category of a built-in status, statuses it doesn't
+// know are put in todo
+func DefaultCategory(status TaskStatus) StatusCategory {
+	if s := DefaultWorkflow.Status(status); s != nil {
+		return s.Category
+	}
+	return CategoryTodo
+}
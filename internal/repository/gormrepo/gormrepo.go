@@ -0,0 +1,45 @@
+// Package gormrepo implements the repositories on top of GORM, they work
+// with every SQL database the service supports (Postgres and SQLite)
+package gormrepo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/akhilbidhuri/taskkr/internal/config"
+	"github.com/akhilbidhuri/taskkr/internal/logger"
+
+	"gorm.io/gorm"
+)
+
+// Open connects to the database through the dialector with the
+// service's logger and the request id SQL comments set up
+func Open(dialector gorm.Dialector, cfg *config.Config) (*gorm.DB, error) {
+	db, err := gorm.Open(dialector, &gorm.Config{
+		Logger: logger.NewGormLogger(cfg.LogSlowQuery),
+		// SQLite compares times as text, they only sort right with a single offset
+		NowFunc: func() time.Time { return time.Now().UTC() },
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := registerRequestIDComment(db); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// isDuplicateKey reports whether err is a unique or primary key violation, like
+// a row a concurrent request inserted first
+func isDuplicateKey(db *gorm.DB, err error) bool {
+	if translator, ok := db.Dialector.(gorm.ErrorTranslator); ok {
+		err = translator.Translate(err)
+	}
+	return errors.Is(err, gorm.ErrDuplicatedKey)
+}
+
+// isPostgres reports whether db talks to Postgres, the few queries
+// depending on the dialect use it to pick the right SQL
+func isPostgres(db *gorm.DB) bool {
+	return db.Dialector.Name() == "postgres"
+}
@@ -0,0 +1,18 @@
+package gormrepo
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// PingCheck returns a readiness check pinging the database
+func PingCheck(db *gorm.DB) func(context.Context) error {
+	return func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	}
+}
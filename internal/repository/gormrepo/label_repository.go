@@ -0,0 +1,119 @@
+package gormrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/repository"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+type labelRepository struct {
+	db *gorm.DB
+}
+
+func NewLabelRepository(db *gorm.DB) repository.LabelRepository {
+	return &labelRepository{db: db}
+}
+
+func (r *labelRepository) Create(ctx context.Context, label *model.Label) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := nameAvailable(tx, label.UserID, label.Name, 0); err != nil {
+			return err
+		}
+		return tx.Create(label).Error
+	})
+}
+
+func (r *labelRepository) GetByID(ctx context.Context, userID uint, id string) (*model.Label, error) {
+	labelID, ok := parseID(id)
+	if !ok {
+		return nil, nil
+	}
+	var label model.Label
+	err := ownedBy(r.db.WithContext(ctx), userID).First(&label, "id = ?", labelID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &label, nil
+}
+
+func (r *labelRepository) List(ctx context.Context, userID uint) ([]*model.Label, error) {
+	var labels []*model.Label
+	err := ownedBy(r.db.WithContext(ctx), userID).Order("name").Order("id").Find(&labels).Error
+	if err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+func (r *labelRepository) Update(ctx context.Context, userID uint, id string, update *model.UpdateLabel) (*model.Label, error) {
+	labelID, ok := parseID(id)
+	if !ok {
+		return nil, utils.NoEntryError
+	}
+	var label model.Label
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ownedBy(tx, userID).First(&label, "id = ?", labelID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return utils.NoEntryError
+			}
+			return err
+		}
+		if update.Name != "" && update.Name != label.Name {
+			if err := nameAvailable(tx, label.UserID, update.Name, label.ID); err != nil {
+				return err
+			}
+		}
+		result := tx.Model(&label).Updates(update)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return utils.NoEntryError
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &label, nil
+}
+
+func (r *labelRepository) Delete(ctx context.Context, userID uint, id string) error {
+	labelID, ok := parseID(id)
+	if !ok {
+		return utils.NoEntryError
+	}
+	// task_labels rows go with the label, ON DELETE CASCADE
+	result := ownedBy(r.db.WithContext(ctx), userID).Delete(&model.Label{}, "id = ?", labelID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return utils.NoEntryError
+	}
+	return nil
+}
+
+// nameAvailable fails when the user has another label with the name
+func nameAvailable(tx *gorm.DB, userID uint, name string, exceptID uint) error {
+	var count int64
+	err := tx.Model(&model.Label{}).
+		Where("user_id = ? AND name = ? AND id <> ?", userID, name, exceptID).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: label %q already exists", utils.ConflictError, name)
+	}
+	return nil
+}
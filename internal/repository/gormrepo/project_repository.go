@@ -0,0 +1,234 @@
+package gormrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/repository"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+type projectRepository struct {
+	db *gorm.DB
+}
+
+func NewProjectRepository(db *gorm.DB) repository.ProjectRepository {
+	return &projectRepository{db: db}
+}
+
+func (r *projectRepository) Create(ctx context.Context, project *model.Project) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := projectNameAvailable(tx, project.UserID, project.Name, 0); err != nil {
+			return err
+		}
+		if err := tx.Create(project).Error; err != nil {
+			return err
+		}
+		owner := &model.ProjectMember{ProjectID: project.ID, UserID: project.UserID, Role: model.RoleOwner}
+		return tx.Create(owner).Error
+	})
+}
+
+func (r *projectRepository) GetByID(ctx context.Context, userID uint, id string) (*model.Project, error) {
+	projectID, ok := parseID(id)
+	if !ok {
+		return nil, nil
+	}
+	var project model.Project
+	err := memberOf(r.db.WithContext(ctx), userID).First(&project, "id = ?", projectID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &project, nil
+}
+
+func (r *projectRepository) List(ctx context.Context, userID uint) ([]*model.Project, error) {
+	var projects []*model.Project
+	err := memberOf(r.db.WithContext(ctx), userID).Order("name").Order("id").Find(&projects).Error
+	if err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+func (r *projectRepository) Update(ctx context.Context, userID uint, id string, update *model.UpdateProject) (*model.Project, error) {
+	projectID, ok := parseID(id)
+	if !ok {
+		return nil, utils.NoEntryError
+	}
+	var project model.Project
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ownedBy(tx, userID).First(&project, "id = ?", projectID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return utils.NoEntryError
+			}
+			return err
+		}
+		if update.Name != "" && update.Name != project.Name {
+			if err := projectNameAvailable(tx, project.UserID, update.Name, project.ID); err != nil {
+				return err
+			}
+		}
+		result := tx.Model(&project).Updates(update)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return utils.NoEntryError
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+func (r *projectRepository) Delete(ctx context.Context, userID uint, id string) error {
+	projectID, ok := parseID(id)
+	if !ok {
+		return utils.NoEntryError
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var project model.Project
+		if err := ownedBy(tx, userID).First(&project, "id = ?", projectID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return utils.NoEntryError
+			}
+			return err
+		}
+		var task model.Task
+		err := tx.Select("id").Where("project_id = ?", project.ID).Take(&task).Error
+		if err == nil {
+			return fmt.Errorf("%w: the project still has task %d", utils.ConflictError, task.ID)
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		// the members go with the project, ON DELETE CASCADE, and deleted
+		// tasks lose it, ON DELETE SET NULL
+		return tx.Delete(&project).Error
+	})
+}
+
+func (r *projectRepository) Members(ctx context.Context, userID uint, id string) ([]*model.ProjectMember, error) {
+	project, err := r.GetByID(ctx, userID, id)
+	if err != nil || project == nil {
+		return nil, err
+	}
+	var members []*model.ProjectMember
+	err = r.db.WithContext(ctx).Where("project_id = ?", project.ID).Order("user_id").Find(&members).Error
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+func (r *projectRepository) AddMember(ctx context.Context, userID uint, id string, member *model.ProjectMember) error {
+	projectID, ok := parseID(id)
+	if !ok {
+		return utils.NoEntryError
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var project model.Project
+		if err := ownedBy(tx, userID).First(&project, "id = ?", projectID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return utils.NoEntryError
+			}
+			return err
+		}
+		var count int64
+		err := tx.Model(&model.ProjectMember{}).
+			Where("project_id = ? AND user_id = ?", project.ID, member.UserID).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("%w: user %d is a member of the project already", utils.ConflictError, member.UserID)
+		}
+		member.ProjectID = project.ID
+		return tx.Create(member).Error
+	})
+}
+
+func (r *projectRepository) RemoveMember(ctx context.Context, userID uint, id string, memberID uint) error {
+	projectID, ok := parseID(id)
+	if !ok {
+		return utils.NoEntryError
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var project model.Project
+		if err := ownedBy(tx, userID).First(&project, "id = ?", projectID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return utils.NoEntryError
+			}
+			return err
+		}
+		if memberID == project.UserID {
+			return fmt.Errorf("%w: the owner can't be removed from the project", utils.InvalidInputError)
+		}
+		// the member would no longer see the tasks of others they work on
+		var assigned []uint
+		err := tx.Table("task_assignees").
+			Select("task_assignees.task_id").
+			Joins("JOIN tasks ON tasks.id = task_assignees.task_id").
+			Where("tasks.project_id = ? AND tasks.user_id <> ? AND tasks.deleted_at IS NULL", project.ID, memberID).
+			Where("task_assignees.user_id = ?", memberID).
+			Order("task_assignees.task_id").
+			Limit(1).
+			Scan(&assigned).Error
+		if err != nil {
+			return err
+		}
+		if len(assigned) > 0 {
+			return fmt.Errorf("%w: user %d is still assigned to task %d of the project", utils.ConflictError, memberID, assigned[0])
+		}
+		result := tx.Where("project_id = ? AND user_id = ?", project.ID, memberID).Delete(&model.ProjectMember{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return utils.NoEntryError
+		}
+		return nil
+	})
+}
+
+// memberOf restricts the query to the projects userID is a member of unless it is repository.AllUsers
+func memberOf(query *gorm.DB, userID uint) *gorm.DB {
+	if userID == repository.AllUsers {
+		return query
+	}
+	return query.Where("id IN (?)", memberships(query, userID))
+}
+
+// memberships selects the ids of the projects userID is a member of
+func memberships(db *gorm.DB, userID uint) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true}).
+		Model(&model.ProjectMember{}).
+		Select("project_id").
+		Where("user_id = ?", userID)
+}
+
+// projectNameAvailable fails when the user owns another project with the name
+func projectNameAvailable(tx *gorm.DB, userID uint, name string, exceptID uint) error {
+	var count int64
+	err := tx.Model(&model.Project{}).
+		Where("user_id = ? AND name = ? AND id <> ?", userID, name, exceptID).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: project %q already exists", utils.ConflictError, name)
+	}
+	return nil
+}
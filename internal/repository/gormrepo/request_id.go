@@ -0,0 +1,84 @@
+package gormrepo
+
+import (
+	"strings"
+
+	"github.com/akhilbidhuri/taskkr/internal/requestid"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// commentPrefix starts the comment carrying the request id
+const commentPrefix = "/* request_id="
+
+// registerRequestIDComment prefixes the SQL sent by GORM with a
+// /* request_id=... */ comment when the query context carries a request id,
+// so slow query logs of the database can be matched with the request
+func registerRequestIDComment(db *gorm.DB) error {
+	// dialects building a clause themselves, like INSERT on SQLite, skip its
+	// BeforeExpression, it's written here before their own SQL
+	for _, name := range []string{"INSERT", "SELECT", "UPDATE", "DELETE"} {
+		if build, ok := db.ClauseBuilders[name]; ok {
+			db.ClauseBuilders[name] = func(c clause.Clause, builder clause.Builder) {
+				if c.BeforeExpression != nil {
+					c.BeforeExpression.Build(builder)
+					builder.WriteByte(' ')
+					c.BeforeExpression = nil
+				}
+				build(c, builder)
+			}
+		}
+	}
+
+	cb := db.Callback()
+	// Raw statements, like the recursive queries of the task tree, run through
+	// the raw callbacks for Exec and the row callbacks for Scan
+	if err := cb.Raw().Before("gorm:raw").Register("taskkr:request_id", requestIDComment()); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register("taskkr:request_id", requestIDComment("SELECT")); err != nil {
+		return err
+	}
+	if err := cb.Create().Before("gorm:create").Register("taskkr:request_id", requestIDComment("INSERT")); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register("taskkr:request_id", requestIDComment("SELECT")); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("taskkr:request_id", requestIDComment("UPDATE")); err != nil {
+		return err
+	}
+	// soft deletes are built as UPDATE statements
+	return cb.Delete().Before("gorm:delete").Register("taskkr:request_id", requestIDComment("DELETE", "UPDATE"))
+}
+
+// requestIDComment adds the comment before the given clauses of the statement,
+// or before its SQL when it was written by hand
+func requestIDComment(clauses ...string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		// subqueries are built in dry run sessions, they end up in a statement having the comment
+		if db.DryRun || db.Statement == nil || db.Statement.Context == nil {
+			return
+		}
+		id := requestid.FromContext(db.Statement.Context)
+		if id == "" {
+			return
+		}
+		comment := commentPrefix + strings.ReplaceAll(id, "*/", "") + " */"
+
+		if sql := db.Statement.SQL.String(); sql != "" {
+			// a statement built by Raw is executed again as is, it has the comment already
+			if !strings.HasPrefix(sql, commentPrefix) {
+				db.Statement.SQL.Reset()
+				db.Statement.SQL.WriteString(comment + " " + sql)
+			}
+			return
+		}
+		for _, name := range clauses {
+			c := db.Statement.Clauses[name]
+			c.BeforeExpression = clause.Expr{SQL: comment}
+			db.Statement.Clauses[name] = c
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package gormrepo_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/akhilbidhuri/taskkr/internal/config"
+	"github.com/akhilbidhuri/taskkr/internal/logger"
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/repository/gormrepo"
+	"github.com/akhilbidhuri/taskkr/internal/repository/sqlite"
+	"github.com/akhilbidhuri/taskkr/internal/requestid"
+)
+
+func TestRequestIDComment(t *testing.T) {
+	cfg := &config.Config{SQLitePath: filepath.Join(t.TempDir(), "taskkr.db")}
+	db := sqlite.NewSQLiteDB(cfg)
+	migrateUp(t, db, config.DriverSQLite)
+	repo := gormrepo.NewTaskRepository(db)
+
+	// the SQL logger writes every query of the context's logger at debug level
+	var logs bytes.Buffer
+	ctx := logger.NewContext(context.Background(), slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	ctx = requestid.NewContext(ctx, "req-42")
+
+	task := &model.Task{UserID: 1, Title: "Parent", Status: model.StatusPending}
+	if err := repo.Create(ctx, task); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := repo.Subtree(ctx, 1, strconv.FormatUint(uint64(task.ID), 10)); err != nil {
+		t.Fatalf("Subtree: %v", err)
+	}
+
+	var queries []string
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var entry struct {
+			SQL string `json:"sql"`
+		}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("decode %s: %v", line, err)
+		}
+		if entry.SQL != "" {
+			queries = append(queries, entry.SQL)
+		}
+	}
+	var insert, recursive bool
+	for _, sql := range queries {
+		if !strings.HasPrefix(sql, "/* request_id=req-42 */ ") || strings.Count(sql, "request_id=") != 1 {
+			t.Errorf("query %q, want it to start with the request id comment once", sql)
+		}
+		insert = insert || strings.Contains(sql, "INSERT INTO")
+		recursive = recursive || strings.Contains(sql, "WITH RECURSIVE")
+	}
+	if !insert || !recursive {
+		t.Errorf("queries = %q, want an INSERT and a recursive query", queries)
+	}
+
+	// without a request id the SQL is left alone
+	logs.Reset()
+	if _, err := repo.Subtree(logger.NewContext(context.Background(), slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))), 1, strconv.FormatUint(uint64(task.ID), 10)); err != nil {
+		t.Fatalf("Subtree: %v", err)
+	}
+	if logs.Len() == 0 || strings.Contains(logs.String(), "request_id=") {
+		t.Errorf("logs = %s, want queries without a comment", logs.String())
+	}
+}
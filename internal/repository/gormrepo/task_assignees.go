@@ -0,0 +1,178 @@
+package gormrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// taskAssignee links a task to a user working on it
+type taskAssignee struct {
+	TaskID    uint `gorm:"primaryKey;autoIncrement:false"`
+	UserID    uint `gorm:"primaryKey;autoIncrement:false"`
+	CreatedAt time.Time
+}
+
+func (taskAssignee) TableName() string {
+	return "task_assignees"
+}
+
+func (r *taskRepository) Assign(ctx context.Context, userID uint, id string, assigneeID, actorID uint) error {
+	taskID, ok := parseID(id)
+	if !ok {
+		return utils.NoEntryError
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var task model.Task
+		if err := ownedBy(tx, userID).First(&task, "id = ?", taskID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return utils.NoEntryError
+			}
+			return err
+		}
+		if err := checkAssignee(tx, &task, assigneeID); err != nil {
+			return err
+		}
+
+		var count int64
+		err := tx.Model(&taskAssignee{}).
+			Where("task_id = ? AND user_id = ?", task.ID, assigneeID).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("%w: user %d is already assigned to task %d", utils.ConflictError, assigneeID, task.ID)
+		}
+		if err := tx.Create(&taskAssignee{TaskID: task.ID, UserID: assigneeID}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&model.TaskEvent{TaskID: task.ID, ActorID: actorID, Type: model.EventAssigned, AssigneeID: &assigneeID}).Error
+	})
+}
+
+func (r *taskRepository) Unassign(ctx context.Context, userID uint, id string, assigneeID, actorID uint) error {
+	taskID, ok := parseID(id)
+	if !ok {
+		return utils.NoEntryError
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var task model.Task
+		if err := ownedBy(tx, userID).First(&task, "id = ?", taskID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return utils.NoEntryError
+			}
+			return err
+		}
+		result := tx.Where("task_id = ? AND user_id = ?", task.ID, assigneeID).Delete(&taskAssignee{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return utils.NoEntryError
+		}
+		return tx.Create(&model.TaskEvent{TaskID: task.ID, ActorID: actorID, Type: model.EventUnassigned, AssigneeID: &assigneeID}).Error
+	})
+}
+
+func (r *taskRepository) History(ctx context.Context, userID uint, id string) ([]*model.TaskEvent, error) {
+	taskID, ok := parseID(id)
+	if !ok {
+		return nil, nil
+	}
+	var task model.Task
+	err := visibleTo(r.db.WithContext(ctx), userID).Select("id").First(&task, "id = ?", taskID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	events := []*model.TaskEvent{}
+	if err := r.db.WithContext(ctx).Where("task_id = ?", task.ID).Order("id").Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// checkAssignee fails unless assigneeID sees the task, as its owner or a member of its project
+func checkAssignee(tx *gorm.DB, task *model.Task, assigneeID uint) error {
+	if assigneeID == task.UserID {
+		return nil
+	}
+	if task.ProjectID != nil {
+		var count int64
+		err := tx.Model(&model.ProjectMember{}).
+			Where("project_id = ? AND user_id = ?", *task.ProjectID, assigneeID).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: user %d can't see task %d, only its owner and the members of its project can be assigned", utils.InvalidInputError, assigneeID, task.ID)
+}
+
+// checkAssigneesIn fails when an assignee of the tasks, other than their owner,
+// isn't a member of the project they move to. A nil projectID leaves only the owners.
+func checkAssigneesIn(tx *gorm.DB, ids []uint, projectID *uint) error {
+	query := tx.Table("task_assignees").
+		Select("task_assignees.task_id, task_assignees.user_id").
+		Joins("JOIN tasks ON tasks.id = task_assignees.task_id").
+		Where("task_assignees.task_id IN ? AND task_assignees.user_id <> tasks.user_id", ids)
+	if projectID != nil {
+		members := tx.Session(&gorm.Session{NewDB: true}).
+			Model(&model.ProjectMember{}).
+			Select("user_id").
+			Where("project_id = ?", *projectID)
+		query = query.Where("task_assignees.user_id NOT IN (?)", members)
+	}
+	var rows []taskAssignee
+	if err := query.Order("task_assignees.task_id").Limit(1).Scan(&rows).Error; err != nil {
+		return err
+	}
+	if len(rows) > 0 {
+		return fmt.Errorf("%w: user %d is assigned to task %d and wouldn't see it in the project", utils.InvalidInputError, rows[0].UserID, rows[0].TaskID)
+	}
+	return nil
+}
+
+// loadAssignees fills in the assignees of the tasks
+func loadAssignees(db *gorm.DB, tasks ...*model.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	byID := make(map[uint]*model.Task, len(tasks))
+	ids := make([]uint, len(tasks))
+	for i, task := range tasks {
+		byID[task.ID] = task
+		ids[i] = task.ID
+		task.Assignees = nil
+	}
+
+	var rows []taskAssignee
+	if err := db.Where("task_id IN ?", ids).Order("user_id").Find(&rows).Error; err != nil {
+		return err
+	}
+	for _, row := range rows {
+		task := byID[row.TaskID]
+		task.Assignees = append(task.Assignees, row.UserID)
+	}
+	return nil
+}
+
+// assignedTo selects the ids of the tasks userID is assigned to
+func assignedTo(db *gorm.DB, userID uint) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true}).
+		Model(&taskAssignee{}).
+		Select("task_id").
+		Where("user_id = ?", userID)
+}
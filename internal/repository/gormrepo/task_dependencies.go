@@ -0,0 +1,216 @@
+package gormrepo
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// upstreamEdges selects the dependencies a task is blocked by, directly or not
+const upstreamEdges = `WITH RECURSIVE upstream (task_id, blocked_by_id) AS (
+	SELECT task_id, blocked_by_id FROM task_dependencies WHERE task_id = ?
+	UNION
+	SELECT task_dependencies.task_id, task_dependencies.blocked_by_id
+	FROM task_dependencies JOIN upstream ON task_dependencies.task_id = upstream.blocked_by_id
+) SELECT task_id, blocked_by_id FROM upstream`
+
+// downstreamEdges selects the dependencies on a task, directly or not
+const downstreamEdges = `WITH RECURSIVE downstream (task_id, blocked_by_id) AS (
+	SELECT task_id, blocked_by_id FROM task_dependencies WHERE blocked_by_id = ?
+	UNION
+	SELECT task_dependencies.task_id, task_dependencies.blocked_by_id
+	FROM task_dependencies JOIN downstream ON task_dependencies.blocked_by_id = downstream.task_id
+) SELECT task_id, blocked_by_id FROM downstream`
+
+func (r *taskRepository) AddDependency(ctx context.Context, userID uint, id string, blockedByID uint) error {
+	taskID, ok := parseID(id)
+	if !ok {
+		return utils.NoEntryError
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var task model.Task
+		if err := ownedBy(tx, userID).First(&task, "id = ?", taskID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return utils.NoEntryError
+			}
+			return err
+		}
+		// without the lock two requests could both pass the checks below and
+		// store a duplicate or, together, close a cycle
+		if err := lockDependenciesOf(tx, task.UserID); err != nil {
+			return err
+		}
+		if err := checkBlocker(tx, &task, blockedByID); err != nil {
+			return err
+		}
+
+		var count int64
+		err := tx.Model(&model.TaskDependency{}).
+			Where("task_id = ? AND blocked_by_id = ?", task.ID, blockedByID).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("%w: task %d is already blocked by task %d", utils.ConflictError, task.ID, blockedByID)
+		}
+		var upstream []*model.TaskDependency
+		if err := tx.Raw(upstreamEdges, blockedByID).Scan(&upstream).Error; err != nil {
+			return err
+		}
+		if slices.ContainsFunc(upstream, func(edge *model.TaskDependency) bool { return edge.BlockedByID == task.ID }) {
+			return fmt.Errorf("%w: task %d already depends on task %d, the dependency would close a cycle", utils.InvalidInputError, blockedByID, task.ID)
+		}
+		if err := tx.Create(&model.TaskDependency{TaskID: task.ID, BlockedByID: blockedByID}).Error; err != nil {
+			if isDuplicateKey(tx, err) {
+				return fmt.Errorf("%w: task %d is already blocked by task %d", utils.ConflictError, task.ID, blockedByID)
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+func (r *taskRepository) RemoveDependency(ctx context.Context, userID uint, id string, blockedByID uint) error {
+	taskID, ok := parseID(id)
+	if !ok {
+		return utils.NoEntryError
+	}
+	task := ownedBy(r.db.WithContext(ctx).Model(&model.Task{}).Select("id"), userID).Where("id = ?", taskID)
+	result := r.db.WithContext(ctx).
+		Where("task_id IN (?) AND blocked_by_id = ?", task, blockedByID).
+		Delete(&model.TaskDependency{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return utils.NoEntryError
+	}
+	return nil
+}
+
+func (r *taskRepository) Graph(ctx context.Context, userID uint, id string) (*model.TaskGraph, error) {
+	root, err := r.GetByID(ctx, userID, id)
+	if err != nil || root == nil {
+		return nil, err
+	}
+
+	var upstream, downstream []*model.TaskDependency
+	if err := r.db.WithContext(ctx).Raw(upstreamEdges, root.ID).Scan(&upstream).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.WithContext(ctx).Raw(downstreamEdges, root.ID).Scan(&downstream).Error; err != nil {
+		return nil, err
+	}
+	edges := append(upstream, downstream...)
+	ids := []uint{root.ID}
+	for _, edge := range edges {
+		ids = append(ids, edge.TaskID, edge.BlockedByID)
+	}
+
+	graph := &model.TaskGraph{}
+	err = visibleTo(r.db.WithContext(ctx), userID).Where("id IN ?", uniqueIDs(ids)).Order("id").Find(&graph.Tasks).Error
+	if err != nil {
+		return nil, err
+	}
+	graph.Edges = sortEdges(visibleEdges(edges, graph.Tasks))
+	if err := loadDetails(r.db.WithContext(ctx), graph.Tasks...); err != nil {
+		return nil, err
+	}
+	return graph, nil
+}
+
+// loadBlocked flags the tasks waiting for a task that isn't done
+func loadBlocked(db *gorm.DB, tasks ...*model.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+
+	var blocked []uint
+	err := db.Table("task_dependencies").
+		Distinct("task_dependencies.task_id").
+		Joins("JOIN tasks ON tasks.id = task_dependencies.blocked_by_id").
+		Where("task_dependencies.task_id IN ? AND tasks.category <> ? AND tasks.deleted_at IS NULL", ids, model.CategoryDone).
+		Scan(&blocked).Error
+	if err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		task.Blocked = slices.Contains(blocked, task.ID)
+	}
+	return nil
+}
+
+// checkBlocker fails unless blockedByID is another task of the task's owner
+func checkBlocker(tx *gorm.DB, task *model.Task, blockedByID uint) error {
+	if blockedByID == task.ID {
+		return fmt.Errorf("%w: a task can't be blocked by itself", utils.InvalidInputError)
+	}
+	var count int64
+	err := tx.Model(&model.Task{}).Where("id = ? AND user_id = ?", blockedByID, task.UserID).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("%w: blocking task %d not found", utils.InvalidInputError, blockedByID)
+	}
+	return nil
+}
+
+// dependencyLockSpace is the first key of the advisory locks taken by
+// lockDependenciesOf, the second one is the user id
+const dependencyLockSpace int32 = 7_238_519
+
+// lockDependenciesOf makes the dependency changes of a user wait for each other
+// until the end of the transaction, the dependencies are between tasks of one user.
+// It's an advisory lock so the tasks themselves stay writable, SQLite runs one
+// transaction at a time. Ids beyond int32 wrap, sharing a lock is only slower.
+func lockDependenciesOf(tx *gorm.DB, userID uint) error {
+	if !isPostgres(tx) {
+		return nil
+	}
+	return tx.Exec("SELECT pg_advisory_xact_lock(?, ?)", dependencyLockSpace, int32(userID)).Error
+}
+
+// sortEdges drops duplicate edges and orders them by task then blocking task
+func sortEdges(edges []*model.TaskDependency) []*model.TaskDependency {
+	compare := func(a, b *model.TaskDependency) int {
+		if a.TaskID != b.TaskID {
+			return cmp.Compare(a.TaskID, b.TaskID)
+		}
+		return cmp.Compare(a.BlockedByID, b.BlockedByID)
+	}
+	slices.SortFunc(edges, compare)
+	edges = slices.CompactFunc(edges, func(a, b *model.TaskDependency) bool { return compare(a, b) == 0 })
+	if edges == nil {
+		return []*model.TaskDependency{}
+	}
+	return edges
+}
+
+// visibleEdges drops the dependencies on tasks left out of tasks, the ones the user can't see
+func visibleEdges(edges []*model.TaskDependency, tasks []*model.Task) []*model.TaskDependency {
+	visible := make(map[uint]bool, len(tasks))
+	for _, task := range tasks {
+		visible[task.ID] = true
+	}
+	return slices.DeleteFunc(edges, func(edge *model.TaskDependency) bool {
+		return !visible[edge.TaskID] || !visible[edge.BlockedByID]
+	})
+}
+
+func uniqueIDs(ids []uint) []uint {
+	slices.Sort(ids)
+	return slices.Compact(ids)
+}
@@ -0,0 +1,111 @@
+package gormrepo
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// taskLabel links a task to one of its owner's labels
+type taskLabel struct {
+	TaskID  uint
+	LabelID uint
+}
+
+func (taskLabel) TableName() string {
+	return "task_labels"
+}
+
+// setLabels replaces the labels of the task with the owner's labels of the given names
+func setLabels(tx *gorm.DB, task *model.Task, names []string) error {
+	names = uniqueNames(names)
+	var labels []model.Label
+	if len(names) > 0 {
+		err := tx.Where("user_id = ? AND name IN ?", task.UserID, names).Find(&labels).Error
+		if err != nil {
+			return err
+		}
+	}
+	if len(labels) != len(names) {
+		for _, name := range names {
+			if !slices.ContainsFunc(labels, func(l model.Label) bool { return l.Name == name }) {
+				return fmt.Errorf("%w: unknown label %q", utils.InvalidInputError, name)
+			}
+		}
+	}
+
+	if err := tx.Where("task_id = ?", task.ID).Delete(&taskLabel{}).Error; err != nil {
+		return err
+	}
+	if len(labels) > 0 {
+		links := make([]taskLabel, len(labels))
+		for i, label := range labels {
+			links[i] = taskLabel{TaskID: task.ID, LabelID: label.ID}
+		}
+		if err := tx.Create(&links).Error; err != nil {
+			return err
+		}
+	}
+	task.Labels = names
+	return nil
+}
+
+// loadLabels fills in the label names of the tasks
+func loadLabels(db *gorm.DB, tasks ...*model.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	byID := make(map[uint]*model.Task, len(tasks))
+	ids := make([]uint, len(tasks))
+	for i, task := range tasks {
+		byID[task.ID] = task
+		ids[i] = task.ID
+		task.Labels = nil
+	}
+
+	var rows []struct {
+		TaskID uint
+		Name   string
+	}
+	err := db.Table("task_labels").
+		Select("task_labels.task_id, labels.name").
+		Joins("JOIN labels ON labels.id = task_labels.label_id").
+		Where("task_labels.task_id IN ?", ids).
+		Order("labels.name").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		task := byID[row.TaskID]
+		task.Labels = append(task.Labels, row.Name)
+	}
+	return nil
+}
+
+// withLabels restricts the query to the tasks having any, or all with match set
+// to model.LabelMatchAll, of the label names
+func withLabels(query *gorm.DB, names []string, match model.LabelMatch) *gorm.DB {
+	names = uniqueNames(names)
+	sub := query.Session(&gorm.Session{NewDB: true}).
+		Table("task_labels").
+		Select("task_labels.task_id").
+		Joins("JOIN labels ON labels.id = task_labels.label_id").
+		Where("labels.name IN ?", names)
+	if match == model.LabelMatchAll {
+		// a task links each name once, labels are unique per owner
+		sub = sub.Group("task_labels.task_id").Having("COUNT(*) = ?", len(names))
+	}
+	return query.Where("tasks.id IN (?)", sub)
+}
+
+// uniqueNames returns the sorted names without duplicates
+func uniqueNames(names []string) []string {
+	names = slices.Clone(names)
+	slices.Sort(names)
+	return slices.Compact(names)
+}
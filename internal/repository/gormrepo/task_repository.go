@@ -0,0 +1,344 @@
+package gormrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/repository"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+type taskRepository struct {
+	db *gorm.DB
+}
+
+func NewTaskRepository(db *gorm.DB) repository.TaskRepository {
+	return &taskRepository{db: db}
+}
+
+func (r *taskRepository) Create(ctx context.Context, task *model.Task) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		labels := task.Labels
+		if task.ParentID != nil {
+			projectID, err := checkParent(tx, task.UserID, *task.ParentID)
+			if err != nil {
+				return err
+			}
+			if task.ProjectID == nil {
+				task.ProjectID = projectID
+			} else if !sameID(task.ProjectID, projectID) {
+				return fmt.Errorf("%w: a subtask must be in the project of its parent", utils.InvalidInputError)
+			}
+		}
+		if task.CreatedBy == 0 {
+			task.CreatedBy = task.UserID
+		}
+		if task.Status == "" {
+			task.Status = model.StatusPending
+		}
+		if task.Category == "" {
+			task.Category = model.DefaultCategory(task.Status)
+		}
+		task.StampStatus(task.Status, task.Category, tx.NowFunc())
+		if err := tx.Create(task).Error; err != nil {
+			return err
+		}
+		if len(labels) == 0 {
+			task.Labels = nil
+			return nil
+		}
+		return setLabels(tx, task, labels)
+	})
+}
+
+func (r *taskRepository) GetByID(ctx context.Context, userID uint, id string) (*model.Task, error) {
+	taskID, ok := parseID(id)
+	if !ok {
+		return nil, nil
+	}
+	var task model.Task
+	err := visibleTo(r.db.WithContext(ctx), userID).First(&task, "id = ?", taskID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := loadDetails(r.db.WithContext(ctx), &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (r *taskRepository) List(ctx context.Context, filter *model.TaskFilter) ([]*model.Task, int, error) {
+	var tasks []*model.Task
+	query := visibleTo(r.db.WithContext(ctx).Model(&model.Task{}), filter.UserID)
+
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Category != "" {
+		query = query.Where("category = ?", filter.Category)
+	}
+	if filter.Priority != "" {
+		query = query.Where("priority = ?", filter.Priority)
+	}
+	if filter.DueBefore != nil {
+		query = query.Where("due_at < ?", filter.DueBefore.UTC())
+	}
+	if filter.DueAfter != nil {
+		query = query.Where("due_at > ?", filter.DueAfter.UTC())
+	}
+	if filter.Overdue {
+		query = query.Where("due_at < ? AND category <> ?", time.Now().UTC(), model.CategoryDone)
+	}
+	if filter.ProjectID != nil {
+		query = query.Where("project_id = ?", *filter.ProjectID)
+	}
+	if filter.AssigneeID != nil {
+		query = query.Where("tasks.id IN (?)", assignedTo(query, *filter.AssigneeID))
+	}
+	if filter.Unassigned {
+		query = query.Where("tasks.id NOT IN (?)", query.Session(&gorm.Session{NewDB: true}).Model(&taskAssignee{}).Select("task_id"))
+	}
+	if filter.CreatedBy != nil {
+		query = query.Where("created_by = ?", *filter.CreatedBy)
+	}
+	if filter.InvolvedID != nil {
+		query = query.Where("(created_by = ? OR tasks.id IN (?))", *filter.InvolvedID, assignedTo(query, *filter.InvolvedID))
+	}
+	if len(filter.Labels) > 0 {
+		query = withLabels(query, filter.Labels, filter.LabelMatch)
+	}
+	if filter.Title != "" {
+		// SQLite has no ILIKE, its LIKE is case-insensitive already
+		if isPostgres(r.db) {
+			query = query.Where("title ILIKE ?", "%"+filter.Title+"%")
+		} else {
+			query = query.Where("title LIKE ?", "%"+filter.Title+"%")
+		}
+	}
+
+	var total int64
+	if !filter.SkipTotal {
+		if err := query.Count(&total).Error; err != nil {
+			return nil, 0, err
+		}
+	}
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize <= 0 {
+		filter.PageSize = 10
+	}
+
+	switch {
+	case filter.After != nil:
+		keys := sortKeys(filter.Sort, filter.After, isPostgres(r.db))
+		query = orderBy(after(query, keys, false), keys, false)
+	case filter.Before != nil:
+		// the tasks right before the cursor are the first ones in reverse order
+		keys := sortKeys(filter.Sort, filter.Before, isPostgres(r.db))
+		query = orderBy(after(query, keys, true), keys, true)
+	default:
+		offset := (filter.Page - 1) * filter.PageSize
+		query = orderBy(query, sortKeys(filter.Sort, nil, isPostgres(r.db)), false).Offset(int(offset))
+	}
+	err := query.Limit(int(filter.PageSize)).Find(&tasks).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	if filter.Before != nil {
+		slices.Reverse(tasks)
+	}
+	if err := loadDetails(r.db.WithContext(ctx), tasks...); err != nil {
+		return nil, 0, err
+	}
+
+	return tasks, int(total), nil
+}
+
+func (r *taskRepository) CountByStatus(ctx context.Context) (map[model.TaskStatus]int, error) {
+	var rows []struct {
+		Status model.TaskStatus
+		Count  int
+	}
+	err := r.db.WithContext(ctx).
+		Model(&model.Task{}).
+		Select("status, COUNT(*) AS count").
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[model.TaskStatus]int, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *taskRepository) CountByCategory(ctx context.Context) (map[model.StatusCategory]int, error) {
+	var rows []struct {
+		Category model.StatusCategory
+		Count    int
+	}
+	err := r.db.WithContext(ctx).
+		Model(&model.Task{}).
+		Select("category, COUNT(*) AS count").
+		Group("category").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[model.StatusCategory]int, len(rows))
+	for _, row := range rows {
+		counts[row.Category] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *taskRepository) Update(ctx context.Context, userID uint, id string, task *model.UpdateTask) (*model.Task, error) {
+	taskID, ok := parseID(id)
+	if !ok {
+		return nil, utils.NoEntryError
+	}
+	var updatedTask model.Task
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ownedBy(tx, userID).First(&updatedTask, "id = ?", taskID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return utils.NoEntryError
+			}
+			return err
+		}
+
+		if task.FromStatus != "" {
+			// the row stays locked until the end of the transaction, a concurrent
+			// move out of the same status finds it changed and fails
+			result := tx.Model(&model.Task{}).
+				Where("id = ? AND status = ?", taskID, task.FromStatus).
+				Update("status", task.Status)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return fmt.Errorf("%w: the task isn't %s anymore, it was changed meanwhile", utils.ConflictError, task.FromStatus)
+			}
+		}
+
+		columns := *task
+		columns.Labels = nil
+		columns.FromStatus = ""
+		if columns == (model.UpdateTask{}) {
+			if task.Labels == nil {
+				return utils.NoEntryError
+			}
+			// only the labels change, the task still counts as updated
+			if err := tx.Model(&updatedTask).Update("updated_at", tx.NowFunc()).Error; err != nil {
+				return err
+			}
+		} else {
+			if task.Status != "" {
+				if columns.Category == "" {
+					columns.Category = model.DefaultCategory(task.Status)
+				}
+				stamped := updatedTask
+				stamped.StampStatus(task.Status, columns.Category, tx.NowFunc())
+				stamps := map[string]any{"started_at": stamped.StartedAt, "completed_at": stamped.CompletedAt}
+				if err := tx.Model(&updatedTask).Updates(stamps).Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.Model(&updatedTask).Updates(&columns).Error; err != nil {
+				return err
+			}
+		}
+		if task.Labels != nil {
+			if err := setLabels(tx, &updatedTask, *task.Labels); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.First(&updatedTask, "id = ?", taskID).Error; err != nil {
+			return err
+		}
+		return loadDetails(tx, &updatedTask)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updatedTask, nil
+}
+
+func (r *taskRepository) Delete(ctx context.Context, userID uint, id string) error {
+	taskID, ok := parseID(id)
+	if !ok {
+		return utils.NoEntryError
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var task model.Task
+		if err := ownedBy(tx, userID).First(&task, "id = ?", taskID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return utils.NoEntryError
+			}
+			return err
+		}
+		if err := tx.Delete(&task).Error; err != nil {
+			return err
+		}
+		err := tx.Where("task_id = ? OR blocked_by_id = ?", task.ID, task.ID).Delete(&model.TaskDependency{}).Error
+		if err != nil {
+			return err
+		}
+		// the subtasks stay in the tree under the parent of the deleted task
+		return tx.Model(&model.Task{}).Where("parent_id = ?", task.ID).Update("parent_id", task.ParentID).Error
+	})
+}
+
+// loadDetails fills in the fields of the tasks that aren't columns of the tasks table
+func loadDetails(db *gorm.DB, tasks ...*model.Task) error {
+	if err := loadLabels(db, tasks...); err != nil {
+		return err
+	}
+	if err := loadAssignees(db, tasks...); err != nil {
+		return err
+	}
+	return loadBlocked(db, tasks...)
+}
+
+// ownedBy restricts the query to the tasks of userID unless it is repository.AllUsers
+func ownedBy(query *gorm.DB, userID uint) *gorm.DB {
+	if userID == repository.AllUsers {
+		return query
+	}
+	return query.Where("user_id = ?", userID)
+}
+
+// visibleTo restricts the query to the tasks of userID and of the projects
+// userID is a member of, unless it is repository.AllUsers
+func visibleTo(query *gorm.DB, userID uint) *gorm.DB {
+	if userID == repository.AllUsers {
+		return query
+	}
+	return query.Where("(tasks.user_id = ? OR tasks.project_id IN (?))", userID, memberships(query, userID))
+}
+
+// parseID converts an id from the URL, ids that can't exist are reported
+// as not found instead of failing the query with a database error
+func parseID(id string) (uint, bool) {
+	taskID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil || taskID == 0 {
+		return 0, false
+	}
+	return uint(taskID), true
+}
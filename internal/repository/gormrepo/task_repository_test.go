@@ -0,0 +1,151 @@
+package gormrepo_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/akhilbidhuri/taskkr/internal/config"
+	"github.com/akhilbidhuri/taskkr/internal/migrate"
+	"github.com/akhilbidhuri/taskkr/internal/repository"
+	"github.com/akhilbidhuri/taskkr/internal/repository/gormrepo"
+	"github.com/akhilbidhuri/taskkr/internal/repository/repotest"
+	"github.com/akhilbidhuri/taskkr/internal/repository/sqlite"
+	"github.com/akhilbidhuri/taskkr/migrations"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// postgresDSNEnv names the variable holding the DSN of a disposable Postgres
+// database, the Postgres tests are skipped when it isn't set. Its tables are truncated.
+const postgresDSNEnv = "TASKKR_TEST_POSTGRES_DSN"
+
+func TestTaskRepositorySQLite(t *testing.T) {
+	repotest.RunTaskRepository(t, func(t *testing.T) repository.TaskRepository {
+		cfg := &config.Config{SQLitePath: filepath.Join(t.TempDir(), "taskkr.db")}
+		db := sqlite.NewSQLiteDB(cfg)
+		migrateUp(t, db, config.DriverSQLite)
+		return gormrepo.NewTaskRepository(db)
+	})
+}
+
+func TestLabelRepositorySQLite(t *testing.T) {
+	repotest.RunLabelRepository(t, func(t *testing.T) (repository.TaskRepository, repository.LabelRepository) {
+		cfg := &config.Config{SQLitePath: filepath.Join(t.TempDir(), "taskkr.db")}
+		db := sqlite.NewSQLiteDB(cfg)
+		migrateUp(t, db, config.DriverSQLite)
+		return gormrepo.NewTaskRepository(db), gormrepo.NewLabelRepository(db)
+	})
+}
+
+func TestWorkflowRepositorySQLite(t *testing.T) {
+	repotest.RunWorkflowRepository(t, func(t *testing.T) (repository.TaskRepository, repository.WorkflowRepository) {
+		cfg := &config.Config{SQLitePath: filepath.Join(t.TempDir(), "taskkr.db")}
+		db := sqlite.NewSQLiteDB(cfg)
+		migrateUp(t, db, config.DriverSQLite)
+		return gormrepo.NewTaskRepository(db), gormrepo.NewWorkflowRepository(db)
+	})
+}
+
+func TestProjectRepositorySQLite(t *testing.T) {
+	repotest.RunProjectRepository(t, func(t *testing.T) (repository.TaskRepository, repository.ProjectRepository) {
+		cfg := &config.Config{SQLitePath: filepath.Join(t.TempDir(), "taskkr.db")}
+		db := sqlite.NewSQLiteDB(cfg)
+		migrateUp(t, db, config.DriverSQLite)
+		return gormrepo.NewTaskRepository(db), gormrepo.NewProjectRepository(db)
+	})
+}
+
+func TestAPIKeyRepositorySQLite(t *testing.T) {
+	repotest.RunAPIKeyRepository(t, func(t *testing.T) repository.APIKeyRepository {
+		cfg := &config.Config{SQLitePath: filepath.Join(t.TempDir(), "taskkr.db")}
+		db := sqlite.NewSQLiteDB(cfg)
+		migrateUp(t, db, config.DriverSQLite)
+		return gormrepo.NewAPIKeyRepository(db)
+	})
+}
+
+func TestTaskRepositoryPostgres(t *testing.T) {
+	db := postgresDB(t)
+	repotest.RunTaskRepository(t, func(t *testing.T) repository.TaskRepository {
+		truncate(t, db)
+		return gormrepo.NewTaskRepository(db)
+	})
+}
+
+func TestLabelRepositoryPostgres(t *testing.T) {
+	db := postgresDB(t)
+	repotest.RunLabelRepository(t, func(t *testing.T) (repository.TaskRepository, repository.LabelRepository) {
+		truncate(t, db)
+		return gormrepo.NewTaskRepository(db), gormrepo.NewLabelRepository(db)
+	})
+}
+
+func TestWorkflowRepositoryPostgres(t *testing.T) {
+	db := postgresDB(t)
+	repotest.RunWorkflowRepository(t, func(t *testing.T) (repository.TaskRepository, repository.WorkflowRepository) {
+		truncate(t, db)
+		return gormrepo.NewTaskRepository(db), gormrepo.NewWorkflowRepository(db)
+	})
+}
+
+func TestProjectRepositoryPostgres(t *testing.T) {
+	db := postgresDB(t)
+	repotest.RunProjectRepository(t, func(t *testing.T) (repository.TaskRepository, repository.ProjectRepository) {
+		truncate(t, db)
+		return gormrepo.NewTaskRepository(db), gormrepo.NewProjectRepository(db)
+	})
+}
+
+func TestAPIKeyRepositoryPostgres(t *testing.T) {
+	db := postgresDB(t)
+	repotest.RunAPIKeyRepository(t, func(t *testing.T) repository.APIKeyRepository {
+		truncate(t, db)
+		return gormrepo.NewAPIKeyRepository(db)
+	})
+}
+
+// postgresDB connects to the database in postgresDSNEnv and migrates it, the test is skipped without one
+func postgresDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv(postgresDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s not set", postgresDSNEnv)
+	}
+	db, err := gormrepo.Open(postgres.Open(dsn), &config.Config{})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	migrateUp(t, db, config.DriverPostgres)
+	return db
+}
+
+func truncate(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	if err := db.Exec("TRUNCATE tasks, labels, task_labels, task_dependencies, workflows, workflow_statuses, projects, project_members, task_assignees, task_events, api_keys RESTART IDENTITY").Error; err != nil {
+		t.Fatalf("truncate: %v", err)
+	}
+}
+
+func migrateUp(t *testing.T, db *gorm.DB, driver string) {
+	t.Helper()
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("database handle: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	files, err := migrations.For(driver)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	migrator, err := migrate.New(sqlDB, driver, files)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+}
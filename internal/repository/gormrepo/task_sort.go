@@ -0,0 +1,141 @@
+package gormrepo
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/akhilbidhuri/taskkr/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// sortKey is one term of the ORDER BY of a task list
+type sortKey struct {
+	expr string
+	// placeholder stands for a value compared with expr
+	placeholder string
+	// value is expr evaluated for the cursor task
+	value interface{}
+	desc  bool
+	// nullable columns sort their NULLs last in both directions
+	nullable bool
+	isNull   bool
+}
+
+// sortKeys returns the terms ordering tasks by fields, ending with the id
+// unless it's sorted on already. The values are taken from key when not nil.
+func sortKeys(fields []model.TaskSort, key *model.Task, postgres bool) []sortKey {
+	if key == nil {
+		key = &model.Task{}
+	}
+	var keys []sortKey
+	for _, sort := range fields {
+		k := sortKey{expr: string(sort.Field), placeholder: "?", desc: sort.Desc}
+		switch sort.Field {
+		case model.SortID:
+			// the id is unique, the fields after it never matter
+			k.value = key.ID
+			return append(keys, k)
+		case model.SortTitle:
+			k.expr, k.placeholder, k.value = "LOWER(title)", "LOWER(?)", key.Title
+			if postgres {
+				// byte order like SQLite, the default collation ignores spaces and punctuation
+				k.expr += ` COLLATE "C"`
+			}
+		case model.SortStatus:
+			k.value = key.Status
+		case model.SortPriority:
+			// ascending means the most urgent first
+			k.expr, k.value, k.desc = priorityRank, key.Priority.Rank(), !sort.Desc
+		case model.SortStartAt, model.SortDueAt:
+			at := key.StartAt
+			if sort.Field == model.SortDueAt {
+				at = key.DueAt
+			}
+			k.nullable, k.isNull = true, at == nil
+			if at != nil {
+				k.value = at.UTC()
+			}
+		case model.SortCreatedAt:
+			k.value = key.CreatedAt.UTC()
+		case model.SortUpdatedAt:
+			k.value = key.UpdatedAt.UTC()
+		}
+		keys = append(keys, k)
+	}
+	return append(keys, sortKey{expr: "id", placeholder: "?", value: key.ID})
+}
+
+// orderBy sorts the query by keys, the same way on every database
+func orderBy(query *gorm.DB, keys []sortKey, reverse bool) *gorm.DB {
+	for _, k := range keys {
+		if k.nullable {
+			nulls := k.expr + " IS NULL"
+			if reverse {
+				nulls += " DESC"
+			}
+			query = query.Order(nulls)
+		}
+		column := k.expr
+		if k.desc != reverse {
+			column += " DESC"
+		}
+		query = query.Order(column)
+	}
+	return query
+}
+
+// after restricts the query to the tasks sorted after the cursor the keys were built from,
+// or before it when reverse is set. It's the row comparison (a, b) > (x, y) spelled out
+// as a > x OR (a = x AND b > y) since the directions and NULL handling differ per key.
+func after(query *gorm.DB, keys []sortKey, reverse bool) *gorm.DB {
+	var terms, equal []string
+	var args, equalArgs []interface{}
+	for _, k := range keys {
+		if cond, condArgs, ok := k.beyond(reverse); ok {
+			terms = append(terms, strings.Join(append(slices.Clone(equal), cond), " AND "))
+			args = append(append(args, equalArgs...), condArgs...)
+		}
+		if k.isNull {
+			equal = append(equal, k.expr+" IS NULL")
+		} else {
+			equal = append(equal, k.expr+" = "+k.placeholder)
+			equalArgs = append(equalArgs, k.value)
+		}
+	}
+	return query.Where("("+strings.Join(terms, " OR ")+")", args...)
+}
+
+// beyond returns the condition of the rows sorted strictly after the cursor value,
+// or before it when reverse is set. ok is false when no row can be.
+func (k sortKey) beyond(reverse bool) (cond string, args []interface{}, ok bool) {
+	op := ">"
+	if k.desc != reverse {
+		op = "<"
+	}
+	switch {
+	case !k.nullable:
+		return fmt.Sprintf("%s %s %s", k.expr, op, k.placeholder), []interface{}{k.value}, true
+	case k.isNull && reverse:
+		return k.expr + " IS NOT NULL", nil, true
+	case k.isNull:
+		// NULLs come last, nothing sorts after them
+		return "", nil, false
+	case reverse:
+		return fmt.Sprintf("%s %s %s", k.expr, op, k.placeholder), []interface{}{k.value}, true
+	default:
+		return fmt.Sprintf("(%s %s %s OR %s IS NULL)", k.expr, op, k.placeholder, k.expr), []interface{}{k.value}, true
+	}
+}
+
+// priorityRank is model.TaskPriority.Rank as an SQL expression
+var priorityRank = func() string {
+	var b strings.Builder
+	b.WriteString("CASE priority")
+	for _, priority := range model.Priorities {
+		fmt.Fprintf(&b, " WHEN '%s' THEN %d", priority, priority.Rank())
+	}
+	b.WriteString(" ELSE 0 END")
+	return b.String()
+}()
@@ -0,0 +1,170 @@
+package gormrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// subtreeIDs selects the id of a task and of all its descendants that aren't deleted
+const subtreeIDs = `WITH RECURSIVE subtree (id) AS (
+	SELECT id FROM tasks WHERE id = ? AND deleted_at IS NULL
+	UNION ALL
+	SELECT tasks.id FROM tasks JOIN subtree ON tasks.parent_id = subtree.id WHERE tasks.deleted_at IS NULL
+) SELECT id FROM subtree`
+
+// ancestorIDs selects the id of a task and of all its ancestors
+const ancestorIDs = `WITH RECURSIVE ancestors (id, parent_id) AS (
+	SELECT id, parent_id FROM tasks WHERE id = ?
+	UNION ALL
+	SELECT tasks.id, tasks.parent_id FROM tasks JOIN ancestors ON tasks.id = ancestors.parent_id
+) SELECT id FROM ancestors`
+
+func (r *taskRepository) Subtree(ctx context.Context, userID uint, id string) ([]*model.Task, error) {
+	root, err := r.GetByID(ctx, userID, id)
+	if err != nil || root == nil {
+		return nil, err
+	}
+
+	var ids []uint
+	if err := r.db.WithContext(ctx).Raw(subtreeIDs, root.ID).Scan(&ids).Error; err != nil {
+		return nil, err
+	}
+	var tasks []*model.Task
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Order("id").Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	// the root first, whatever its id compared to the subtasks moved under it
+	tasks = slices.DeleteFunc(tasks, func(task *model.Task) bool { return task.ID == root.ID })
+	tasks = append([]*model.Task{root}, tasks...)
+	if err := loadDetails(r.db.WithContext(ctx), tasks[1:]...); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (r *taskRepository) Move(ctx context.Context, userID uint, id string, parentID *uint) (*model.Task, error) {
+	taskID, ok := parseID(id)
+	if !ok {
+		return nil, utils.NoEntryError
+	}
+	var task model.Task
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ownedBy(tx, userID).First(&task, "id = ?", taskID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return utils.NoEntryError
+			}
+			return err
+		}
+		if parentID != nil {
+			projectID, err := checkParent(tx, task.UserID, *parentID)
+			if err != nil {
+				return err
+			}
+			if !sameID(task.ProjectID, projectID) {
+				return fmt.Errorf("%w: a task can't be moved under a task of another project", utils.InvalidInputError)
+			}
+			var ancestors []uint
+			if err := tx.Raw(ancestorIDs, *parentID).Scan(&ancestors).Error; err != nil {
+				return err
+			}
+			if slices.Contains(ancestors, task.ID) {
+				return fmt.Errorf("%w: a task can't be moved under itself or one of its subtasks", utils.InvalidInputError)
+			}
+		}
+		if err := tx.Model(&task).Update("parent_id", parentID).Error; err != nil {
+			return err
+		}
+		return loadDetails(tx, &task)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (r *taskRepository) SetProject(ctx context.Context, userID uint, id string, projectID *uint) (*model.Task, error) {
+	taskID, ok := parseID(id)
+	if !ok {
+		return nil, utils.NoEntryError
+	}
+	var task model.Task
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ownedBy(tx, userID).First(&task, "id = ?", taskID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return utils.NoEntryError
+			}
+			return err
+		}
+		if task.ParentID != nil {
+			return fmt.Errorf("%w: a subtask is in the project of its parent, move the top level task instead", utils.InvalidInputError)
+		}
+		var ids []uint
+		if err := tx.Raw(subtreeIDs, task.ID).Scan(&ids).Error; err != nil {
+			return err
+		}
+		if err := checkAssigneesIn(tx, ids, projectID); err != nil {
+			return err
+		}
+		if err := tx.Model(&model.Task{}).Where("id IN ?", ids).Update("project_id", projectID).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&task, "id = ?", task.ID).Error; err != nil {
+			return err
+		}
+		return loadDetails(tx, &task)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (r *taskRepository) CountChildren(ctx context.Context, taskID uint) (map[model.StatusCategory]int, error) {
+	var rows []struct {
+		Category model.StatusCategory
+		Count    int
+	}
+	err := r.db.WithContext(ctx).
+		Model(&model.Task{}).
+		Select("category, COUNT(*) AS count").
+		Where("parent_id = ?", taskID).
+		Group("category").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[model.StatusCategory]int, len(rows))
+	for _, row := range rows {
+		counts[row.Category] = row.Count
+	}
+	return counts, nil
+}
+
+// checkParent fails unless parentID is a task of userID, it returns the project of the parent
+func checkParent(tx *gorm.DB, userID, parentID uint) (*uint, error) {
+	var parent model.Task
+	err := tx.Select("id", "project_id").Where("id = ? AND user_id = ?", parentID, userID).Take(&parent).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: parent task %d not found", utils.InvalidInputError, parentID)
+		}
+		return nil, err
+	}
+	return parent.ProjectID, nil
+}
+
+// sameID tells whether both ids are nil or equal
+func sameID(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,232 @@
+package gormrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/repository"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+type workflowRepository struct {
+	db *gorm.DB
+}
+
+func NewWorkflowRepository(db *gorm.DB) repository.WorkflowRepository {
+	return &workflowRepository{db: db}
+}
+
+func (r *workflowRepository) Create(ctx context.Context, workflow *model.Workflow) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := workflowNameAvailable(tx, workflow.UserID, workflow.Name, 0); err != nil {
+			return err
+		}
+		if err := tx.Create(workflow).Error; err != nil {
+			return err
+		}
+		return setStatuses(tx, workflow, workflow.Statuses)
+	})
+}
+
+func (r *workflowRepository) GetByID(ctx context.Context, userID uint, id string) (*model.Workflow, error) {
+	workflowID, ok := parseID(id)
+	if !ok {
+		return nil, nil
+	}
+	db := r.db.WithContext(ctx)
+	var workflow model.Workflow
+	if err := ownedBy(db, userID).First(&workflow, "id = ?", workflowID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := loadStatuses(db, &workflow); err != nil {
+		return nil, err
+	}
+	return &workflow, nil
+}
+
+func (r *workflowRepository) List(ctx context.Context, userID uint) ([]*model.Workflow, error) {
+	db := r.db.WithContext(ctx)
+	var workflows []*model.Workflow
+	if err := ownedBy(db, userID).Order("name").Order("id").Find(&workflows).Error; err != nil {
+		return nil, err
+	}
+	if err := loadStatuses(db, workflows...); err != nil {
+		return nil, err
+	}
+	return workflows, nil
+}
+
+func (r *workflowRepository) Update(ctx context.Context, userID uint, id string, update *model.UpdateWorkflow) (*model.Workflow, error) {
+	workflowID, ok := parseID(id)
+	if !ok {
+		return nil, utils.NoEntryError
+	}
+	if update.Name == "" && update.Statuses == nil {
+		return nil, utils.NoEntryError
+	}
+	var workflow model.Workflow
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ownedBy(tx, userID).First(&workflow, "id = ?", workflowID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return utils.NoEntryError
+			}
+			return err
+		}
+		if update.Name != "" && update.Name != workflow.Name {
+			if err := workflowNameAvailable(tx, workflow.UserID, update.Name, workflow.ID); err != nil {
+				return err
+			}
+		}
+		if update.Statuses != nil {
+			if err := replaceStatuses(tx, &workflow, *update.Statuses); err != nil {
+				return err
+			}
+		}
+		// the statuses live in their own table, the workflow still counts as updated
+		columns := map[string]any{"updated_at": tx.NowFunc()}
+		if update.Name != "" {
+			columns["name"] = update.Name
+		}
+		if err := tx.Model(&workflow).Updates(columns).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&workflow, "id = ?", workflow.ID).Error; err != nil {
+			return err
+		}
+		return loadStatuses(tx, &workflow)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &workflow, nil
+}
+
+func (r *workflowRepository) Delete(ctx context.Context, userID uint, id string) error {
+	workflowID, ok := parseID(id)
+	if !ok {
+		return utils.NoEntryError
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var workflow model.Workflow
+		if err := ownedBy(tx, userID).First(&workflow, "id = ?", workflowID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return utils.NoEntryError
+			}
+			return err
+		}
+		var task model.Task
+		err := tx.Select("id").Where("workflow_id = ?", workflow.ID).Take(&task).Error
+		if err == nil {
+			return fmt.Errorf("%w: workflow is used by task %d", utils.ConflictError, task.ID)
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		// the statuses go with the workflow, ON DELETE CASCADE, and deleted
+		// tasks lose it, ON DELETE SET NULL
+		return tx.Delete(&workflow).Error
+	})
+}
+
+// replaceStatuses swaps the statuses of the workflow and moves its tasks to the new
+// categories, it fails while a task has a status that is dropped
+func replaceStatuses(tx *gorm.DB, workflow *model.Workflow, statuses []model.WorkflowStatus) error {
+	names := make([]model.TaskStatus, len(statuses))
+	for i, status := range statuses {
+		names[i] = status.Name
+	}
+	var task model.Task
+	err := tx.Select("id", "status").
+		Where("workflow_id = ? AND status NOT IN ?", workflow.ID, names).
+		Take(&task).Error
+	if err == nil {
+		return fmt.Errorf("%w: status %q is still used by task %d", utils.ConflictError, task.Status, task.ID)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	if err := tx.Where("workflow_id = ?", workflow.ID).Delete(&model.WorkflowStatus{}).Error; err != nil {
+		return err
+	}
+	if err := setStatuses(tx, workflow, statuses); err != nil {
+		return err
+	}
+	now := tx.NowFunc()
+	for _, status := range workflow.Statuses {
+		// the timestamps follow the new category like model.Task.StampStatus
+		columns := map[string]any{"category": status.Category, "completed_at": nil}
+		switch status.Category {
+		case model.CategoryDoing:
+			columns["started_at"] = gorm.Expr("COALESCE(started_at, ?)", now)
+		case model.CategoryDone:
+			columns["completed_at"] = now
+		}
+		err := tx.Model(&model.Task{}).
+			Where("workflow_id = ? AND status = ? AND category <> ?", workflow.ID, status.Name, status.Category).
+			Updates(columns).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setStatuses stores the statuses of the workflow in order
+func setStatuses(tx *gorm.DB, workflow *model.Workflow, statuses []model.WorkflowStatus) error {
+	workflow.Statuses = make([]model.WorkflowStatus, len(statuses))
+	for i, status := range statuses {
+		status.WorkflowID, status.Position = workflow.ID, i
+		workflow.Statuses[i] = status
+	}
+	if len(workflow.Statuses) == 0 {
+		return nil
+	}
+	return tx.Create(&workflow.Statuses).Error
+}
+
+// loadStatuses fills in the statuses of the workflows
+func loadStatuses(db *gorm.DB, workflows ...*model.Workflow) error {
+	if len(workflows) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(workflows))
+	for i, workflow := range workflows {
+		ids[i] = workflow.ID
+	}
+	var statuses []model.WorkflowStatus
+	if err := db.Where("workflow_id IN ?", ids).Order("position").Find(&statuses).Error; err != nil {
+		return err
+	}
+	for _, workflow := range workflows {
+		workflow.Statuses = []model.WorkflowStatus{}
+		for _, status := range statuses {
+			if status.WorkflowID == workflow.ID {
+				workflow.Statuses = append(workflow.Statuses, status)
+			}
+		}
+	}
+	return nil
+}
+
+// workflowNameAvailable fails when the user has another workflow with the name
+func workflowNameAvailable(tx *gorm.DB, userID uint, name string, exceptID uint) error {
+	var count int64
+	err := tx.Model(&model.Workflow{}).
+		Where("user_id = ? AND name = ? AND id <> ?", userID, name, exceptID).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: workflow %q already exists", utils.ConflictError, name)
+	}
+	return nil
+}
@@ -2,14 +2,105 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/akhilbidhuri/taskkr/internal/model"
 )
 
+// AllUsers can be passed as userID to operate on the tasks of every user
+const AllUsers uint = 0
+
+// TaskRepository persists tasks, every write is restricted to the tasks owned by userID and reads
+// to those plus the tasks of the projects userID is a member of.
+// A userID of AllUsers disables the owner restriction and must only be used for privileged callers.
+// Task labels are given by name, unknown names fail with an error wrapping utils.InvalidInputError.
+// The parent of a task must be a task of the same owner, or it fails the same way. Subtasks are in
+// the project of their parent, a task created without project takes it.
 type TaskRepository interface {
 	Create(ctx context.Context, task *model.Task) error
-	GetByID(ctx context.Context, id string) (*model.Task, error)
-	Update(ctx context.Context, id string, task *model.UpdateTask) (*model.Task, error)
-	Delete(ctx context.Context, id string) error
+	GetByID(ctx context.Context, userID uint, id string) (*model.Task, error)
+	Update(ctx context.Context, userID uint, id string, task *model.UpdateTask) (*model.Task, error)
+	// Delete moves the subtasks of the task up to its parent and drops its dependencies
+	Delete(ctx context.Context, userID uint, id string) error
 	List(ctx context.Context, filter *model.TaskFilter) ([]*model.Task, int, error)
+	// Subtree returns the task followed by all its descendants, nil if the task doesn't exist
+	Subtree(ctx context.Context, userID uint, id string) ([]*model.Task, error)
+	// Move sets the parent of the task, a nil parentID makes it a top level task.
+	// Moving a task under itself or one of its descendants fails wrapping utils.InvalidInputError.
+	Move(ctx context.Context, userID uint, id string, parentID *uint) (*model.Task, error)
+	// SetProject moves the task and all its subtasks to the project, a nil projectID takes them out
+	// of their project. Only a top level task can move, subtasks fail wrapping utils.InvalidInputError,
+	// and so does a move that would hide a task from one of its assignees.
+	SetProject(ctx context.Context, userID uint, id string, projectID *uint) (*model.Task, error)
+	// Assign adds assigneeID to the assignees of the task and records it in its history as done by actorID.
+	// The assignee must see the task, its owner or a member of its project, or it fails wrapping
+	// utils.InvalidInputError. Assigning a user twice fails wrapping utils.ConflictError.
+	Assign(ctx context.Context, userID uint, id string, assigneeID, actorID uint) error
+	// Unassign removes assigneeID from the assignees of the task and records it in its history
+	Unassign(ctx context.Context, userID uint, id string, assigneeID, actorID uint) error
+	// History returns the events of the task oldest first, nil if the task doesn't exist
+	History(ctx context.Context, userID uint, id string) ([]*model.TaskEvent, error)
+	// CountChildren counts the direct subtasks of the task per status category
+	CountChildren(ctx context.Context, taskID uint) (map[model.StatusCategory]int, error)
+	// AddDependency blocks the task by blockedByID, a task of the same owner. An edge closing
+	// a cycle fails wrapping utils.InvalidInputError and an existing one utils.ConflictError.
+	AddDependency(ctx context.Context, userID uint, id string, blockedByID uint) error
+	RemoveDependency(ctx context.Context, userID uint, id string, blockedByID uint) error
+	// Graph returns the task with the tasks upstream and downstream of it, nil if the task doesn't exist
+	Graph(ctx context.Context, userID uint, id string) (*model.TaskGraph, error)
+	// CountByStatus counts the tasks of all users per status
+	CountByStatus(ctx context.Context) (map[model.TaskStatus]int, error)
+	// CountByCategory counts the tasks of all users per status category
+	CountByCategory(ctx context.Context) (map[model.StatusCategory]int, error)
+}
+
+// LabelRepository persists labels, like tasks they are restricted to the ones owned by userID.
+// Tasks refer to labels by name within their owner's labels, the TaskRepository resolves them.
+type LabelRepository interface {
+	Create(ctx context.Context, label *model.Label) error
+	GetByID(ctx context.Context, userID uint, id string) (*model.Label, error)
+	List(ctx context.Context, userID uint) ([]*model.Label, error)
+	Update(ctx context.Context, userID uint, id string, label *model.UpdateLabel) (*model.Label, error)
+	// Delete removes the label from every task too
+	Delete(ctx context.Context, userID uint, id string) error
+}
+
+// WorkflowRepository persists workflows, like labels they are restricted to the ones owned by userID.
+// The statuses of a workflow are stored and returned with it, in order.
+type WorkflowRepository interface {
+	Create(ctx context.Context, workflow *model.Workflow) error
+	GetByID(ctx context.Context, userID uint, id string) (*model.Workflow, error)
+	List(ctx context.Context, userID uint) ([]*model.Workflow, error)
+	// Update replaces the statuses when they are set and moves the tasks of the workflow to the
+	// new categories. Dropping a status some task still has fails wrapping utils.ConflictError.
+	Update(ctx context.Context, userID uint, id string, workflow *model.UpdateWorkflow) (*model.Workflow, error)
+	// Delete fails wrapping utils.ConflictError while tasks use the workflow
+	Delete(ctx context.Context, userID uint, id string) error
+}
+
+// ProjectRepository persists projects and their members. Reads are restricted to the projects userID
+// is a member of and writes to the ones userID owns, AllUsers lifts both restrictions.
+type ProjectRepository interface {
+	// Create stores the project with its owner as first member
+	Create(ctx context.Context, project *model.Project) error
+	GetByID(ctx context.Context, userID uint, id string) (*model.Project, error)
+	List(ctx context.Context, userID uint) ([]*model.Project, error)
+	Update(ctx context.Context, userID uint, id string, project *model.UpdateProject) (*model.Project, error)
+	// Delete fails wrapping utils.ConflictError while the project has tasks
+	Delete(ctx context.Context, userID uint, id string) error
+	// Members returns the members of the project by id, nil if the project doesn't exist
+	Members(ctx context.Context, userID uint, id string) ([]*model.ProjectMember, error)
+	// AddMember fails wrapping utils.ConflictError when the user is a member already
+	AddMember(ctx context.Context, userID uint, id string, member *model.ProjectMember) error
+	// RemoveMember fails wrapping utils.InvalidInputError for the owner, they always stay a member,
+	// and wrapping utils.ConflictError while the member is assigned to a task of the project they don't own
+	RemoveMember(ctx context.Context, userID uint, id string, memberID uint) error
+}
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *model.APIKey) error
+	GetByHash(ctx context.Context, hash string) (*model.APIKey, error)
+	List(ctx context.Context) ([]*model.APIKey, error)
+	Revoke(ctx context.Context, id string, at time.Time) error
+	RecordUsage(ctx context.Context, id uint, at time.Time) error
 }
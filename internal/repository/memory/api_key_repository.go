@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/repository"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+)
+
+type apiKeyRepository struct {
+	*DB
+}
+
+func NewAPIKeyRepository(db *DB) repository.APIKeyRepository {
+	return &apiKeyRepository{DB: db}
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *model.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.apiKeys {
+		if existing.KeyHash == key.KeyHash {
+			return errors.New("duplicate api key hash")
+		}
+	}
+	now := r.now()
+	key.ID = r.nextAPIKeyID
+	r.nextAPIKeyID++
+	key.CreatedAt = now
+	key.UpdatedAt = now
+
+	stored := *key
+	r.apiKeys[stored.ID] = &stored
+	return nil
+}
+
+func (r *apiKeyRepository) GetByHash(ctx context.Context, hash string) (*model.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, key := range r.apiKeys {
+		if key.KeyHash == hash {
+			found := *key
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *apiKeyRepository) List(ctx context.Context) ([]*model.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make([]*model.APIKey, 0, len(r.apiKeys))
+	for _, key := range r.apiKeys {
+		k := *key
+		keys = append(keys, &k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return keys, nil
+}
+
+func (r *apiKeyRepository) Revoke(ctx context.Context, id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	keyID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return utils.NoEntryError
+	}
+	key, ok := r.apiKeys[uint(keyID)]
+	if !ok || key.RevokedAt != nil {
+		return utils.NoEntryError
+	}
+	key.RevokedAt = &at
+	key.UpdatedAt = r.now()
+	return nil
+}
+
+func (r *apiKeyRepository) RecordUsage(ctx context.Context, id uint, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if key, ok := r.apiKeys[id]; ok {
+		key.LastUsedAt = &at
+		key.UsageCount++
+	}
+	return nil
+}
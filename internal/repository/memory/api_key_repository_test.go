@@ -0,0 +1,14 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/akhilbidhuri/taskkr/internal/repository"
+	"github.com/akhilbidhuri/taskkr/internal/repository/repotest"
+)
+
+func TestAPIKeyRepository(t *testing.T) {
+	repotest.RunAPIKeyRepository(t, func(t *testing.T) repository.APIKeyRepository {
+		return NewAPIKeyRepository(NewDB())
+	})
+}
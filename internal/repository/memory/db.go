@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/akhilbidhuri/taskkr/internal/model"
+)
+
+// DB holds the tables shared by the in-memory repositories, like a
+// *gorm.DB it is created once and passed to each repository
+type DB struct {
+	mu             sync.RWMutex
+	tasks          map[uint]*model.Task
+	nextID         uint
+	labels         map[uint]*model.Label
+	nextLabelID    uint
+	workflows      map[uint]*model.Workflow
+	nextWorkflowID uint
+	projects       map[uint]*model.Project
+	nextProjectID  uint
+	// members maps a project id to its members
+	members map[uint][]*model.ProjectMember
+	// assignees maps a task id to the ids of the users assigned to it
+	assignees map[uint][]uint
+	// events holds the history of every task, oldest first
+	events      []*model.TaskEvent
+	nextEventID uint
+	// taskLabels maps a task id to the ids of its labels
+	taskLabels map[uint][]uint
+	// dependencies maps a task id to the ids of the tasks blocking it
+	dependencies map[uint][]uint
+	apiKeys      map[uint]*model.APIKey
+	nextAPIKeyID uint
+	now          func() time.Time
+}
+
+func NewDB() *DB {
+	return &DB{
+		tasks:          map[uint]*model.Task{},
+		nextID:         1,
+		labels:         map[uint]*model.Label{},
+		nextLabelID:    1,
+		workflows:      map[uint]*model.Workflow{},
+		nextWorkflowID: 1,
+		projects:       map[uint]*model.Project{},
+		nextProjectID:  1,
+		members:        map[uint][]*model.ProjectMember{},
+		assignees:      map[uint][]uint{},
+		nextEventID:    1,
+		taskLabels:     map[uint][]uint{},
+		dependencies:   map[uint][]uint{},
+		apiKeys:        map[uint]*model.APIKey{},
+		nextAPIKeyID:   1,
+		now:            func() time.Time { return time.Now().UTC() },
+	}
+}
@@ -0,0 +1,132 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/repository"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+)
+
+type labelRepository struct {
+	*DB
+}
+
+func NewLabelRepository(db *DB) repository.LabelRepository {
+	return &labelRepository{DB: db}
+}
+
+func (r *labelRepository) Create(ctx context.Context, label *model.Label) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.labelNamed(label.UserID, label.Name) != nil {
+		return fmt.Errorf("%w: label %q already exists", utils.ConflictError, label.Name)
+	}
+	now := r.now()
+	label.ID = r.nextLabelID
+	r.nextLabelID++
+	label.CreatedAt = now
+	label.UpdatedAt = now
+
+	stored := *label
+	r.labels[stored.ID] = &stored
+	return nil
+}
+
+func (r *labelRepository) GetByID(ctx context.Context, userID uint, id string) (*model.Label, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	label := r.findLabel(userID, id)
+	if label == nil {
+		return nil, nil
+	}
+	found := *label
+	return &found, nil
+}
+
+func (r *labelRepository) List(ctx context.Context, userID uint) ([]*model.Label, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	labels := []*model.Label{}
+	for _, label := range r.labels {
+		if userID == repository.AllUsers || label.UserID == userID {
+			l := *label
+			labels = append(labels, &l)
+		}
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if labels[i].Name != labels[j].Name {
+			return labels[i].Name < labels[j].Name
+		}
+		return labels[i].ID < labels[j].ID
+	})
+	return labels, nil
+}
+
+func (r *labelRepository) Update(ctx context.Context, userID uint, id string, update *model.UpdateLabel) (*model.Label, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing := r.findLabel(userID, id)
+	if existing == nil || *update == (model.UpdateLabel{}) {
+		return nil, utils.NoEntryError
+	}
+	if update.Name != "" && update.Name != existing.Name {
+		if r.labelNamed(existing.UserID, update.Name) != nil {
+			return nil, fmt.Errorf("%w: label %q already exists", utils.ConflictError, update.Name)
+		}
+		existing.Name = update.Name
+	}
+	if update.Color != "" {
+		existing.Color = update.Color
+	}
+	existing.UpdatedAt = r.now()
+
+	updated := *existing
+	return &updated, nil
+}
+
+func (r *labelRepository) Delete(ctx context.Context, userID uint, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	label := r.findLabel(userID, id)
+	if label == nil {
+		return utils.NoEntryError
+	}
+	delete(r.labels, label.ID)
+	for taskID, labelIDs := range r.taskLabels {
+		r.taskLabels[taskID] = slices.DeleteFunc(labelIDs, func(id uint) bool { return id == label.ID })
+	}
+	return nil
+}
+
+// findLabel returns the stored label with the given id, the caller must hold the lock
+func (r *DB) findLabel(userID uint, id string) *model.Label {
+	labelID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil
+	}
+	label, ok := r.labels[uint(labelID)]
+	if !ok || (userID != repository.AllUsers && label.UserID != userID) {
+		return nil
+	}
+	return label
+}
+
+// labelNamed returns the label of userID with the name, the caller must hold the lock
+func (r *DB) labelNamed(userID uint, name string) *model.Label {
+	for _, label := range r.labels {
+		if label.UserID == userID && label.Name == name {
+			return label
+		}
+	}
+	return nil
+}
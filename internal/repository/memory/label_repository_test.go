@@ -0,0 +1,15 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/akhilbidhuri/taskkr/internal/repository"
+	"github.com/akhilbidhuri/taskkr/internal/repository/repotest"
+)
+
+func TestLabelRepository(t *testing.T) {
+	repotest.RunLabelRepository(t, func(t *testing.T) (repository.TaskRepository, repository.LabelRepository) {
+		db := NewDB()
+		return NewTaskRepository(db), NewLabelRepository(db)
+	})
+}
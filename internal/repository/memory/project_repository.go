@@ -0,0 +1,231 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/repository"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+)
+
+type projectRepository struct {
+	*DB
+}
+
+func NewProjectRepository(db *DB) repository.ProjectRepository {
+	return &projectRepository{DB: db}
+}
+
+func (r *projectRepository) Create(ctx context.Context, project *model.Project) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.projectNamed(project.UserID, project.Name) != nil {
+		return fmt.Errorf("%w: project %q already exists", utils.ConflictError, project.Name)
+	}
+	now := r.now()
+	project.ID = r.nextProjectID
+	r.nextProjectID++
+	project.CreatedAt = now
+	project.UpdatedAt = now
+
+	stored := *project
+	r.projects[stored.ID] = &stored
+	r.members[stored.ID] = []*model.ProjectMember{{ProjectID: stored.ID, UserID: stored.UserID, Role: model.RoleOwner, CreatedAt: now}}
+	return nil
+}
+
+func (r *projectRepository) GetByID(ctx context.Context, userID uint, id string) (*model.Project, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	project := r.findProject(id)
+	if project == nil || !r.isMember(project.ID, userID) {
+		return nil, nil
+	}
+	p := *project
+	return &p, nil
+}
+
+func (r *projectRepository) List(ctx context.Context, userID uint) ([]*model.Project, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	projects := []*model.Project{}
+	for _, project := range r.projects {
+		if r.isMember(project.ID, userID) {
+			p := *project
+			projects = append(projects, &p)
+		}
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		if projects[i].Name != projects[j].Name {
+			return projects[i].Name < projects[j].Name
+		}
+		return projects[i].ID < projects[j].ID
+	})
+	return projects, nil
+}
+
+func (r *projectRepository) Update(ctx context.Context, userID uint, id string, update *model.UpdateProject) (*model.Project, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing := r.ownedProject(userID, id)
+	// like GORM's Updates with a struct, zero values are not applied
+	if existing == nil || *update == (model.UpdateProject{}) {
+		return nil, utils.NoEntryError
+	}
+	if update.Name != "" && update.Name != existing.Name {
+		if r.projectNamed(existing.UserID, update.Name) != nil {
+			return nil, fmt.Errorf("%w: project %q already exists", utils.ConflictError, update.Name)
+		}
+		existing.Name = update.Name
+	}
+	if update.Description != "" {
+		existing.Description = update.Description
+	}
+	existing.UpdatedAt = r.now()
+	p := *existing
+	return &p, nil
+}
+
+func (r *projectRepository) Delete(ctx context.Context, userID uint, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	project := r.ownedProject(userID, id)
+	if project == nil {
+		return utils.NoEntryError
+	}
+	tasks := r.projectTasks(project.ID)
+	for _, task := range tasks {
+		if !task.DeletedAt.Valid {
+			return fmt.Errorf("%w: the project still has task %d", utils.ConflictError, task.ID)
+		}
+	}
+	// like ON DELETE SET NULL for the deleted tasks
+	for _, task := range tasks {
+		task.ProjectID = nil
+	}
+	delete(r.projects, project.ID)
+	delete(r.members, project.ID)
+	return nil
+}
+
+func (r *projectRepository) Members(ctx context.Context, userID uint, id string) ([]*model.ProjectMember, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	project := r.findProject(id)
+	if project == nil || !r.isMember(project.ID, userID) {
+		return nil, nil
+	}
+	members := make([]*model.ProjectMember, 0, len(r.members[project.ID]))
+	for _, member := range r.members[project.ID] {
+		m := *member
+		members = append(members, &m)
+	}
+	slices.SortFunc(members, func(a, b *model.ProjectMember) int { return cmp.Compare(a.UserID, b.UserID) })
+	return members, nil
+}
+
+func (r *projectRepository) AddMember(ctx context.Context, userID uint, id string, member *model.ProjectMember) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	project := r.ownedProject(userID, id)
+	if project == nil {
+		return utils.NoEntryError
+	}
+	if r.isMember(project.ID, member.UserID) {
+		return fmt.Errorf("%w: user %d is a member of the project already", utils.ConflictError, member.UserID)
+	}
+	member.ProjectID = project.ID
+	member.CreatedAt = r.now()
+	stored := *member
+	r.members[project.ID] = append(r.members[project.ID], &stored)
+	return nil
+}
+
+func (r *projectRepository) RemoveMember(ctx context.Context, userID uint, id string, memberID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	project := r.ownedProject(userID, id)
+	if project == nil {
+		return utils.NoEntryError
+	}
+	if memberID == project.UserID {
+		return fmt.Errorf("%w: the owner can't be removed from the project", utils.InvalidInputError)
+	}
+	if !r.isMember(project.ID, memberID) {
+		return utils.NoEntryError
+	}
+	// the member would no longer see the tasks of others they work on
+	tasks := r.projectTasks(project.ID)
+	sortByID(tasks)
+	for _, task := range tasks {
+		if !task.DeletedAt.Valid && task.UserID != memberID && slices.Contains(r.assignees[task.ID], memberID) {
+			return fmt.Errorf("%w: user %d is still assigned to task %d of the project", utils.ConflictError, memberID, task.ID)
+		}
+	}
+	r.members[project.ID] = slices.DeleteFunc(r.members[project.ID], func(m *model.ProjectMember) bool { return m.UserID == memberID })
+	return nil
+}
+
+// findProject returns the stored project with the given id, the caller must hold the lock
+func (r *DB) findProject(id string) *model.Project {
+	projectID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil
+	}
+	return r.projects[uint(projectID)]
+}
+
+// ownedProject returns the stored project with the given id if userID owns it, the caller must hold the lock
+func (r *DB) ownedProject(userID uint, id string) *model.Project {
+	project := r.findProject(id)
+	if project == nil || (userID != repository.AllUsers && project.UserID != userID) {
+		return nil
+	}
+	return project
+}
+
+// isMember reports whether userID is a member of the project, everyone is with
+// repository.AllUsers. The caller must hold the lock.
+func (r *DB) isMember(projectID, userID uint) bool {
+	if _, ok := r.projects[projectID]; !ok {
+		return false
+	}
+	return userID == repository.AllUsers ||
+		slices.ContainsFunc(r.members[projectID], func(m *model.ProjectMember) bool { return m.UserID == userID })
+}
+
+// projectNamed returns the project of userID with the name, the caller must hold the lock
+func (r *DB) projectNamed(userID uint, name string) *model.Project {
+	for _, project := range r.projects {
+		if project.UserID == userID && project.Name == name {
+			return project
+		}
+	}
+	return nil
+}
+
+// projectTasks returns the stored tasks of the project by id, deleted ones included,
+// the caller must hold the lock
+func (r *DB) projectTasks(projectID uint) []*model.Task {
+	var tasks []*model.Task
+	for _, task := range r.tasks {
+		if task.ProjectID != nil && *task.ProjectID == projectID {
+			tasks = append(tasks, task)
+		}
+	}
+	sortByID(tasks)
+	return tasks
+}
@@ -0,0 +1,15 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/akhilbidhuri/taskkr/internal/repository"
+	"github.com/akhilbidhuri/taskkr/internal/repository/repotest"
+)
+
+func TestProjectRepository(t *testing.T) {
+	repotest.RunProjectRepository(t, func(t *testing.T) (repository.TaskRepository, repository.ProjectRepository) {
+		db := NewDB()
+		return NewTaskRepository(db), NewProjectRepository(db)
+	})
+}
@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/repository"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+)
+
+func (r *taskRepository) Assign(ctx context.Context, userID uint, id string, assigneeID, actorID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task := r.find(userID, id)
+	if task == nil {
+		return utils.NoEntryError
+	}
+	if assigneeID == repository.AllUsers || !r.visible(task, assigneeID) {
+		return fmt.Errorf("%w: user %d can't see task %d, only its owner and the members of its project can be assigned", utils.InvalidInputError, assigneeID, task.ID)
+	}
+	if slices.Contains(r.assignees[task.ID], assigneeID) {
+		return fmt.Errorf("%w: user %d is already assigned to task %d", utils.ConflictError, assigneeID, task.ID)
+	}
+	r.assignees[task.ID] = append(r.assignees[task.ID], assigneeID)
+	r.record(&model.TaskEvent{TaskID: task.ID, ActorID: actorID, Type: model.EventAssigned, AssigneeID: &assigneeID})
+	return nil
+}
+
+func (r *taskRepository) Unassign(ctx context.Context, userID uint, id string, assigneeID, actorID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task := r.find(userID, id)
+	if task == nil || !slices.Contains(r.assignees[task.ID], assigneeID) {
+		return utils.NoEntryError
+	}
+	r.assignees[task.ID] = slices.DeleteFunc(r.assignees[task.ID], func(id uint) bool { return id == assigneeID })
+	r.record(&model.TaskEvent{TaskID: task.ID, ActorID: actorID, Type: model.EventUnassigned, AssigneeID: &assigneeID})
+	return nil
+}
+
+func (r *taskRepository) History(ctx context.Context, userID uint, id string) ([]*model.TaskEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	task := r.findVisible(userID, id)
+	if task == nil {
+		return nil, nil
+	}
+	events := []*model.TaskEvent{}
+	for _, event := range r.events {
+		if event.TaskID == task.ID {
+			e := *event
+			e.AssigneeID = copyID(event.AssigneeID)
+			events = append(events, &e)
+		}
+	}
+	return events, nil
+}
+
+// record appends the event to the history, the caller must hold the lock
+func (r *taskRepository) record(event *model.TaskEvent) {
+	event.ID = r.nextEventID
+	r.nextEventID++
+	event.CreatedAt = r.now()
+	r.events = append(r.events, event)
+}
+
+// assigneesOf returns the sorted ids of the users assigned to a task, the caller must hold the lock
+func (r *taskRepository) assigneesOf(taskID uint) []uint {
+	if len(r.assignees[taskID]) == 0 {
+		return nil
+	}
+	assignees := slices.Clone(r.assignees[taskID])
+	slices.Sort(assignees)
+	return assignees
+}
@@ -0,0 +1,135 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+)
+
+func (r *taskRepository) AddDependency(ctx context.Context, userID uint, id string, blockedByID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task := r.find(userID, id)
+	if task == nil {
+		return utils.NoEntryError
+	}
+	if blockedByID == task.ID {
+		return fmt.Errorf("%w: a task can't be blocked by itself", utils.InvalidInputError)
+	}
+	blocker, ok := r.tasks[blockedByID]
+	if !ok || blocker.DeletedAt.Valid || blocker.UserID != task.UserID {
+		return fmt.Errorf("%w: blocking task %d not found", utils.InvalidInputError, blockedByID)
+	}
+	if slices.Contains(r.dependencies[task.ID], blockedByID) {
+		return fmt.Errorf("%w: task %d is already blocked by task %d", utils.ConflictError, task.ID, blockedByID)
+	}
+	for _, edge := range r.walk(blockedByID, true) {
+		if edge.BlockedByID == task.ID {
+			return fmt.Errorf("%w: task %d already depends on task %d, the dependency would close a cycle", utils.InvalidInputError, blockedByID, task.ID)
+		}
+	}
+	r.dependencies[task.ID] = append(r.dependencies[task.ID], blockedByID)
+	return nil
+}
+
+func (r *taskRepository) RemoveDependency(ctx context.Context, userID uint, id string, blockedByID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task := r.find(userID, id)
+	if task == nil || !slices.Contains(r.dependencies[task.ID], blockedByID) {
+		return utils.NoEntryError
+	}
+	r.dependencies[task.ID] = slices.DeleteFunc(r.dependencies[task.ID], func(id uint) bool { return id == blockedByID })
+	return nil
+}
+
+func (r *taskRepository) Graph(ctx context.Context, userID uint, id string) (*model.TaskGraph, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	root := r.findVisible(userID, id)
+	if root == nil {
+		return nil, nil
+	}
+	edges := append(r.walk(root.ID, true), r.walk(root.ID, false)...)
+	ids := []uint{root.ID}
+	for _, edge := range edges {
+		ids = append(ids, edge.TaskID, edge.BlockedByID)
+	}
+	slices.Sort(ids)
+
+	graph := &model.TaskGraph{}
+	for _, id := range slices.Compact(ids) {
+		// like the gorm repository, the tasks the user can't see are left out with their edges
+		if task := r.tasks[id]; r.visible(task, userID) {
+			graph.Tasks = append(graph.Tasks, r.copyTask(task))
+		}
+	}
+	edges = slices.DeleteFunc(edges, func(edge *model.TaskDependency) bool {
+		return !r.visible(r.tasks[edge.TaskID], userID) || !r.visible(r.tasks[edge.BlockedByID], userID)
+	})
+	graph.Edges = sortEdges(edges)
+	return graph, nil
+}
+
+// walk returns the dependencies reachable from the task, following the tasks
+// blocking it when upstream is set and the tasks it blocks otherwise.
+// The caller must hold the lock.
+func (r *taskRepository) walk(taskID uint, upstream bool) []*model.TaskDependency {
+	var edges []*model.TaskDependency
+	seen := map[uint]bool{taskID: true}
+	queue := []uint{taskID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for dependent, blockers := range r.dependencies {
+			for _, blocker := range blockers {
+				from, next := dependent, blocker
+				if !upstream {
+					from, next = blocker, dependent
+				}
+				if from != id {
+					continue
+				}
+				edges = append(edges, &model.TaskDependency{TaskID: dependent, BlockedByID: blocker})
+				if !seen[next] {
+					seen[next] = true
+					queue = append(queue, next)
+				}
+			}
+		}
+	}
+	return edges
+}
+
+// blocked reports whether a task blocking the task isn't done, the caller must hold the lock
+func (r *taskRepository) blocked(taskID uint) bool {
+	for _, id := range r.dependencies[taskID] {
+		if blocker := r.tasks[id]; !blocker.DeletedAt.Valid && blocker.Category != model.CategoryDone {
+			return true
+		}
+	}
+	return false
+}
+
+// sortEdges drops duplicate edges and orders them by task then blocking task
+func sortEdges(edges []*model.TaskDependency) []*model.TaskDependency {
+	compare := func(a, b *model.TaskDependency) int {
+		if a.TaskID != b.TaskID {
+			return cmp.Compare(a.TaskID, b.TaskID)
+		}
+		return cmp.Compare(a.BlockedByID, b.BlockedByID)
+	}
+	slices.SortFunc(edges, compare)
+	edges = slices.CompactFunc(edges, func(a, b *model.TaskDependency) bool { return compare(a, b) == 0 })
+	if edges == nil {
+		return []*model.TaskDependency{}
+	}
+	return edges
+}
@@ -0,0 +1,430 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/repository"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// taskRepository keeps tasks in a map, it behaves like the postgres
+// repository including soft deletes and is safe for concurrent use
+type taskRepository struct {
+	*DB
+}
+
+func NewTaskRepository(db *DB) repository.TaskRepository {
+	return &taskRepository{DB: db}
+}
+
+func (r *taskRepository) Create(ctx context.Context, task *model.Task) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	labelIDs, err := r.labelIDs(task.UserID, task.Labels)
+	if err != nil {
+		return err
+	}
+	if task.ParentID != nil {
+		parent, err := r.checkParent(task.UserID, *task.ParentID)
+		if err != nil {
+			return err
+		}
+		if task.ProjectID == nil {
+			task.ProjectID = copyID(parent.ProjectID)
+		} else if !sameID(task.ProjectID, parent.ProjectID) {
+			return fmt.Errorf("%w: a subtask must be in the project of its parent", utils.InvalidInputError)
+		}
+	}
+	now := r.now()
+	task.ID = r.nextID
+	r.nextID++
+	if task.CreatedBy == 0 {
+		task.CreatedBy = task.UserID
+	}
+	if task.CreatedAt.IsZero() {
+		task.CreatedAt = now
+	}
+	if task.UpdatedAt.IsZero() {
+		task.UpdatedAt = now
+	}
+	if task.Status == "" {
+		task.Status = model.StatusPending
+	}
+	if task.Category == "" {
+		task.Category = model.DefaultCategory(task.Status)
+	}
+	task.StampStatus(task.Status, task.Category, now)
+	if task.Priority == "" {
+		task.Priority = model.PriorityMedium
+	}
+	task.DeletedAt = gorm.DeletedAt{}
+
+	stored := *task
+	stored.ParentID = copyID(task.ParentID)
+	stored.ProjectID = copyID(task.ProjectID)
+	stored.WorkflowID = copyID(task.WorkflowID)
+	stored.Labels = nil
+	stored.Assignees = nil
+	r.tasks[stored.ID] = &stored
+	r.taskLabels[stored.ID] = labelIDs
+	task.Labels = r.labelNames(stored.ID)
+	return nil
+}
+
+func (r *taskRepository) GetByID(ctx context.Context, userID uint, id string) (*model.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	task := r.findVisible(userID, id)
+	if task == nil {
+		return nil, nil
+	}
+	return r.copyTask(task), nil
+}
+
+func (r *taskRepository) List(ctx context.Context, filter *model.TaskFilter) ([]*model.Task, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := r.now()
+	var matched []*model.Task
+	for _, task := range r.tasks {
+		if task.DeletedAt.Valid || !r.visible(task, filter.UserID) {
+			continue
+		}
+		if filter.ProjectID != nil && !sameID(task.ProjectID, filter.ProjectID) {
+			continue
+		}
+		if filter.AssigneeID != nil && !slices.Contains(r.assignees[task.ID], *filter.AssigneeID) {
+			continue
+		}
+		if filter.Unassigned && len(r.assignees[task.ID]) > 0 {
+			continue
+		}
+		if filter.CreatedBy != nil && task.CreatedBy != *filter.CreatedBy {
+			continue
+		}
+		if filter.InvolvedID != nil && task.CreatedBy != *filter.InvolvedID && !slices.Contains(r.assignees[task.ID], *filter.InvolvedID) {
+			continue
+		}
+		if filter.Status != "" && task.Status != filter.Status {
+			continue
+		}
+		if filter.Category != "" && task.Category != filter.Category {
+			continue
+		}
+		if filter.Priority != "" && task.Priority != filter.Priority {
+			continue
+		}
+		if filter.Title != "" && !iLike(task.Title, "%"+filter.Title+"%") {
+			continue
+		}
+		if filter.DueBefore != nil && (task.DueAt == nil || !task.DueAt.Before(*filter.DueBefore)) {
+			continue
+		}
+		if filter.DueAfter != nil && (task.DueAt == nil || !task.DueAt.After(*filter.DueAfter)) {
+			continue
+		}
+		if filter.Overdue && (task.DueAt == nil || !task.DueAt.Before(now) || task.Category == model.CategoryDone) {
+			continue
+		}
+		if len(filter.Labels) > 0 && !r.hasLabels(task.ID, filter.Labels, filter.LabelMatch) {
+			continue
+		}
+		matched = append(matched, task)
+	}
+	sort.Slice(matched, func(i, j int) bool { return less(matched[i], matched[j], filter.Sort) })
+
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize <= 0 {
+		filter.PageSize = 10
+	}
+
+	total := len(matched)
+	if filter.SkipTotal {
+		total = 0
+	}
+	var offset int
+	switch {
+	case filter.After != nil:
+		offset = sort.Search(len(matched), func(i int) bool { return less(filter.After, matched[i], filter.Sort) })
+	case filter.Before != nil:
+		// the page ends right before the cursor
+		end := sort.Search(len(matched), func(i int) bool { return !less(matched[i], filter.Before, filter.Sort) })
+		offset = max(end-int(filter.PageSize), 0)
+		matched = matched[:end]
+	default:
+		offset = min(int((filter.Page-1)*filter.PageSize), len(matched))
+	}
+	end := min(offset+int(filter.PageSize), len(matched))
+
+	tasks := make([]*model.Task, 0, end-offset)
+	for _, task := range matched[offset:end] {
+		tasks = append(tasks, r.copyTask(task))
+	}
+	return tasks, total, nil
+}
+
+func (r *taskRepository) CountByStatus(ctx context.Context) (map[model.TaskStatus]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := map[model.TaskStatus]int{}
+	for _, task := range r.tasks {
+		if !task.DeletedAt.Valid {
+			counts[task.Status]++
+		}
+	}
+	return counts, nil
+}
+
+func (r *taskRepository) CountByCategory(ctx context.Context) (map[model.StatusCategory]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := map[model.StatusCategory]int{}
+	for _, task := range r.tasks {
+		if !task.DeletedAt.Valid {
+			counts[task.Category]++
+		}
+	}
+	return counts, nil
+}
+
+func (r *taskRepository) Update(ctx context.Context, userID uint, id string, task *model.UpdateTask) (*model.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing := r.find(userID, id)
+	// like GORM's Updates with a struct, zero values are not applied
+	if existing == nil || *task == (model.UpdateTask{}) {
+		return nil, utils.NoEntryError
+	}
+	if task.FromStatus != "" && existing.Status != task.FromStatus {
+		return nil, fmt.Errorf("%w: the task isn't %s anymore, it was changed meanwhile", utils.ConflictError, task.FromStatus)
+	}
+	if task.Labels != nil {
+		labelIDs, err := r.labelIDs(existing.UserID, *task.Labels)
+		if err != nil {
+			return nil, err
+		}
+		r.taskLabels[existing.ID] = labelIDs
+	}
+	if task.Title != "" {
+		existing.Title = task.Title
+	}
+	if task.Description != "" {
+		existing.Description = task.Description
+	}
+	now := r.now()
+	if task.Status != "" {
+		category := task.Category
+		if category == "" {
+			category = model.DefaultCategory(task.Status)
+		}
+		existing.StampStatus(task.Status, category, now)
+	}
+	if task.Resolution != "" {
+		existing.Resolution = task.Resolution
+	}
+	if task.Priority != "" {
+		existing.Priority = task.Priority
+	}
+	if task.StartAt != nil {
+		startAt := *task.StartAt
+		existing.StartAt = &startAt
+	}
+	if task.DueAt != nil {
+		dueAt := *task.DueAt
+		existing.DueAt = &dueAt
+	}
+	existing.UpdatedAt = now
+
+	return r.copyTask(existing), nil
+}
+
+func (r *taskRepository) Delete(ctx context.Context, userID uint, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task := r.find(userID, id)
+	if task == nil {
+		return utils.NoEntryError
+	}
+	now := r.now()
+	task.DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+	delete(r.dependencies, task.ID)
+	for id, blockers := range r.dependencies {
+		r.dependencies[id] = slices.DeleteFunc(blockers, func(id uint) bool { return id == task.ID })
+	}
+	// the subtasks stay in the tree under the parent of the deleted task
+	for _, child := range r.tasks {
+		if child.ParentID != nil && *child.ParentID == task.ID {
+			child.ParentID = copyID(task.ParentID)
+			child.UpdatedAt = now
+		}
+	}
+	return nil
+}
+
+// find returns the stored, not deleted task with the given id, the caller must hold the lock
+func (r *taskRepository) find(userID uint, id string) *model.Task {
+	taskID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil
+	}
+	task, ok := r.tasks[uint(taskID)]
+	if !ok || task.DeletedAt.Valid || !ownedBy(task, userID) {
+		return nil
+	}
+	return task
+}
+
+// findVisible returns the stored, not deleted task with the given id if userID can
+// see it, the caller must hold the lock
+func (r *taskRepository) findVisible(userID uint, id string) *model.Task {
+	task := r.find(repository.AllUsers, id)
+	if task == nil || !r.visible(task, userID) {
+		return nil
+	}
+	return task
+}
+
+// visible reports whether userID owns the task or is a member of its project,
+// the caller must hold the lock
+func (r *taskRepository) visible(task *model.Task, userID uint) bool {
+	return ownedBy(task, userID) || (task.ProjectID != nil && r.isMember(*task.ProjectID, userID))
+}
+
+// labelIDs resolves label names within the labels of userID, the caller must hold the lock
+func (r *taskRepository) labelIDs(userID uint, names []string) ([]uint, error) {
+	var ids []uint
+	for _, name := range names {
+		label := r.labelNamed(userID, name)
+		if label == nil {
+			return nil, fmt.Errorf("%w: unknown label %q", utils.InvalidInputError, name)
+		}
+		if !slices.Contains(ids, label.ID) {
+			ids = append(ids, label.ID)
+		}
+	}
+	return ids, nil
+}
+
+// labelNames returns the sorted label names of a task, the caller must hold the lock
+func (r *taskRepository) labelNames(taskID uint) []string {
+	var names []string
+	for _, id := range r.taskLabels[taskID] {
+		names = append(names, r.labels[id].Name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// hasLabels reports whether the task has any, or all with model.LabelMatchAll, of the names
+func (r *taskRepository) hasLabels(taskID uint, names []string, match model.LabelMatch) bool {
+	labels := r.labelNames(taskID)
+	for _, name := range names {
+		found := slices.Contains(labels, name)
+		if found && match != model.LabelMatchAll {
+			return true
+		}
+		if !found && match == model.LabelMatchAll {
+			return false
+		}
+	}
+	return match == model.LabelMatchAll
+}
+
+// less orders tasks like the SQL queries of the gorm repository
+func less(a, b *model.Task, fields []model.TaskSort) bool {
+	for _, sort := range fields {
+		var c int
+		switch sort.Field {
+		case model.SortID:
+			c = cmp.Compare(a.ID, b.ID)
+		case model.SortTitle:
+			// folds every letter like LOWER on Postgres, on SQLite it only folds ASCII ones
+			c = strings.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title))
+		case model.SortStatus:
+			c = strings.Compare(string(a.Status), string(b.Status))
+		case model.SortPriority:
+			c = cmp.Compare(b.Priority.Rank(), a.Priority.Rank())
+		case model.SortStartAt, model.SortDueAt:
+			x, y := a.StartAt, b.StartAt
+			if sort.Field == model.SortDueAt {
+				x, y = a.DueAt, b.DueAt
+			}
+			// tasks without the date come last whatever the direction
+			if (x == nil) != (y == nil) {
+				return y == nil
+			}
+			if x != nil {
+				c = x.Compare(*y)
+			}
+		case model.SortCreatedAt:
+			c = a.CreatedAt.Compare(b.CreatedAt)
+		case model.SortUpdatedAt:
+			c = a.UpdatedAt.Compare(b.UpdatedAt)
+		}
+		if sort.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c < 0
+		}
+	}
+	return a.ID < b.ID
+}
+
+func ownedBy(task *model.Task, userID uint) bool {
+	return userID == repository.AllUsers || task.UserID == userID
+}
+
+// iLike matches s against a SQL LIKE pattern ignoring case,
+// % matches any sequence of characters and _ a single one
+func iLike(s, pattern string) bool {
+	return like([]rune(strings.ToLower(s)), []rune(strings.ToLower(pattern)))
+}
+
+func like(s, p []rune) bool {
+	for len(p) > 0 {
+		switch p[0] {
+		case '%':
+			for len(p) > 0 && p[0] == '%' {
+				p = p[1:]
+			}
+			if len(p) == 0 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if like(s[i:], p) {
+					return true
+				}
+			}
+			return false
+		case '_':
+			if len(s) == 0 {
+				return false
+			}
+		default:
+			if len(s) == 0 || s[0] != p[0] {
+				return false
+			}
+		}
+		s, p = s[1:], p[1:]
+	}
+	return len(s) == 0
+}
@@ -0,0 +1,14 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/akhilbidhuri/taskkr/internal/repository"
+	"github.com/akhilbidhuri/taskkr/internal/repository/repotest"
+)
+
+func TestTaskRepository(t *testing.T) {
+	repotest.RunTaskRepository(t, func(t *testing.T) repository.TaskRepository {
+		return NewTaskRepository(NewDB())
+	})
+}
@@ -0,0 +1,169 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+)
+
+func (r *taskRepository) Subtree(ctx context.Context, userID uint, id string) ([]*model.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	root := r.findVisible(userID, id)
+	if root == nil {
+		return nil, nil
+	}
+	tasks := []*model.Task{r.copyTask(root)}
+	// breadth first, then ordered by id like the gorm repository
+	var descendants []*model.Task
+	parents := []uint{root.ID}
+	for len(parents) > 0 {
+		var next []uint
+		for _, task := range r.tasks {
+			if !task.DeletedAt.Valid && task.ParentID != nil && slices.Contains(parents, *task.ParentID) {
+				descendants = append(descendants, r.copyTask(task))
+				next = append(next, task.ID)
+			}
+		}
+		parents = next
+	}
+	sortByID(descendants)
+	return append(tasks, descendants...), nil
+}
+
+func (r *taskRepository) Move(ctx context.Context, userID uint, id string, parentID *uint) (*model.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task := r.find(userID, id)
+	if task == nil {
+		return nil, utils.NoEntryError
+	}
+	if parentID != nil {
+		parent, err := r.checkParent(task.UserID, *parentID)
+		if err != nil {
+			return nil, err
+		}
+		if !sameID(task.ProjectID, parent.ProjectID) {
+			return nil, fmt.Errorf("%w: a task can't be moved under a task of another project", utils.InvalidInputError)
+		}
+		for ancestor := r.tasks[*parentID]; ancestor != nil; ancestor = r.parentOf(ancestor) {
+			if ancestor.ID == task.ID {
+				return nil, fmt.Errorf("%w: a task can't be moved under itself or one of its subtasks", utils.InvalidInputError)
+			}
+		}
+	}
+	task.ParentID = copyID(parentID)
+	task.UpdatedAt = r.now()
+	return r.copyTask(task), nil
+}
+
+func (r *taskRepository) SetProject(ctx context.Context, userID uint, id string, projectID *uint) (*model.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task := r.find(userID, id)
+	if task == nil {
+		return nil, utils.NoEntryError
+	}
+	if task.ParentID != nil {
+		return nil, fmt.Errorf("%w: a subtask is in the project of its parent, move the top level task instead", utils.InvalidInputError)
+	}
+	subtree := []*model.Task{task}
+	for i := 0; i < len(subtree); i++ {
+		subtree = append(subtree, r.children(subtree[i].ID)...)
+	}
+	sortByID(subtree)
+	for _, t := range subtree {
+		for _, assignee := range r.assignees[t.ID] {
+			if assignee != t.UserID && (projectID == nil || !r.isMember(*projectID, assignee)) {
+				return nil, fmt.Errorf("%w: user %d is assigned to task %d and wouldn't see it in the project", utils.InvalidInputError, assignee, t.ID)
+			}
+		}
+	}
+	now := r.now()
+	for _, t := range subtree {
+		t.ProjectID = copyID(projectID)
+		t.UpdatedAt = now
+	}
+	return r.copyTask(task), nil
+}
+
+func (r *taskRepository) CountChildren(ctx context.Context, taskID uint) (map[model.StatusCategory]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := map[model.StatusCategory]int{}
+	for _, task := range r.tasks {
+		if !task.DeletedAt.Valid && task.ParentID != nil && *task.ParentID == taskID {
+			counts[task.Category]++
+		}
+	}
+	return counts, nil
+}
+
+// checkParent returns the parent task and fails unless it is a task of userID,
+// the caller must hold the lock
+func (r *taskRepository) checkParent(userID, parentID uint) (*model.Task, error) {
+	parent, ok := r.tasks[parentID]
+	if !ok || parent.DeletedAt.Valid || parent.UserID != userID {
+		return nil, fmt.Errorf("%w: parent task %d not found", utils.InvalidInputError, parentID)
+	}
+	return parent, nil
+}
+
+// children returns the stored subtasks of the task that aren't deleted, the caller must hold the lock
+func (r *taskRepository) children(taskID uint) []*model.Task {
+	var children []*model.Task
+	for _, task := range r.tasks {
+		if !task.DeletedAt.Valid && task.ParentID != nil && *task.ParentID == taskID {
+			children = append(children, task)
+		}
+	}
+	return children
+}
+
+func (r *taskRepository) parentOf(task *model.Task) *model.Task {
+	if task.ParentID == nil {
+		return nil
+	}
+	return r.tasks[*task.ParentID]
+}
+
+// copyTask returns a copy of the stored task with the fields computed from other tables,
+// the caller must hold the lock
+func (r *taskRepository) copyTask(task *model.Task) *model.Task {
+	t := *task
+	t.ParentID = copyID(task.ParentID)
+	t.ProjectID = copyID(task.ProjectID)
+	t.WorkflowID = copyID(task.WorkflowID)
+	t.Labels = r.labelNames(task.ID)
+	t.Assignees = r.assigneesOf(task.ID)
+	t.Blocked = r.blocked(task.ID)
+	return &t
+}
+
+func copyID(id *uint) *uint {
+	if id == nil {
+		return nil
+	}
+	c := *id
+	return &c
+}
+
+// sameID tells whether both ids are nil or equal
+func sameID(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func sortByID(tasks []*model.Task) {
+	slices.SortFunc(tasks, func(a, b *model.Task) int { return cmp.Compare(a.ID, b.ID) })
+}
@@ -0,0 +1,180 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/repository"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+)
+
+type workflowRepository struct {
+	*DB
+}
+
+func NewWorkflowRepository(db *DB) repository.WorkflowRepository {
+	return &workflowRepository{DB: db}
+}
+
+func (r *workflowRepository) Create(ctx context.Context, workflow *model.Workflow) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.workflowNamed(workflow.UserID, workflow.Name) != nil {
+		return fmt.Errorf("%w: workflow %q already exists", utils.ConflictError, workflow.Name)
+	}
+	now := r.now()
+	workflow.ID = r.nextWorkflowID
+	r.nextWorkflowID++
+	workflow.CreatedAt = now
+	workflow.UpdatedAt = now
+	workflow.Statuses = numberStatuses(workflow.ID, workflow.Statuses)
+
+	r.workflows[workflow.ID] = copyWorkflow(workflow)
+	return nil
+}
+
+func (r *workflowRepository) GetByID(ctx context.Context, userID uint, id string) (*model.Workflow, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	workflow := r.findWorkflow(userID, id)
+	if workflow == nil {
+		return nil, nil
+	}
+	return copyWorkflow(workflow), nil
+}
+
+func (r *workflowRepository) List(ctx context.Context, userID uint) ([]*model.Workflow, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	workflows := []*model.Workflow{}
+	for _, workflow := range r.workflows {
+		if userID == repository.AllUsers || workflow.UserID == userID {
+			workflows = append(workflows, copyWorkflow(workflow))
+		}
+	}
+	sort.Slice(workflows, func(i, j int) bool {
+		if workflows[i].Name != workflows[j].Name {
+			return workflows[i].Name < workflows[j].Name
+		}
+		return workflows[i].ID < workflows[j].ID
+	})
+	return workflows, nil
+}
+
+func (r *workflowRepository) Update(ctx context.Context, userID uint, id string, update *model.UpdateWorkflow) (*model.Workflow, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing := r.findWorkflow(userID, id)
+	if existing == nil || (update.Name == "" && update.Statuses == nil) {
+		return nil, utils.NoEntryError
+	}
+	if update.Name != "" && update.Name != existing.Name {
+		if r.workflowNamed(existing.UserID, update.Name) != nil {
+			return nil, fmt.Errorf("%w: workflow %q already exists", utils.ConflictError, update.Name)
+		}
+	}
+	if update.Statuses != nil {
+		statuses := numberStatuses(existing.ID, *update.Statuses)
+		tasks := slices.DeleteFunc(r.workflowTasks(existing.ID), func(task *model.Task) bool { return task.DeletedAt.Valid })
+		for _, task := range tasks {
+			if !slices.ContainsFunc(statuses, func(s model.WorkflowStatus) bool { return s.Name == task.Status }) {
+				return nil, fmt.Errorf("%w: status %q is still used by task %d", utils.ConflictError, task.Status, task.ID)
+			}
+		}
+		now := r.now()
+		for _, task := range tasks {
+			for _, status := range statuses {
+				if status.Name == task.Status && status.Category != task.Category {
+					task.StampStatus(task.Status, status.Category, now)
+					task.UpdatedAt = now
+				}
+			}
+		}
+		existing.Statuses = statuses
+	}
+	if update.Name != "" {
+		existing.Name = update.Name
+	}
+	existing.UpdatedAt = r.now()
+	return copyWorkflow(existing), nil
+}
+
+func (r *workflowRepository) Delete(ctx context.Context, userID uint, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	workflow := r.findWorkflow(userID, id)
+	if workflow == nil {
+		return utils.NoEntryError
+	}
+	for _, task := range r.workflowTasks(workflow.ID) {
+		if !task.DeletedAt.Valid {
+			return fmt.Errorf("%w: workflow is used by task %d", utils.ConflictError, task.ID)
+		}
+	}
+	// like ON DELETE SET NULL for the deleted tasks
+	for _, task := range r.workflowTasks(workflow.ID) {
+		task.WorkflowID = nil
+	}
+	delete(r.workflows, workflow.ID)
+	return nil
+}
+
+// findWorkflow returns the stored workflow with the given id, the caller must hold the lock
+func (r *DB) findWorkflow(userID uint, id string) *model.Workflow {
+	workflowID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil
+	}
+	workflow, ok := r.workflows[uint(workflowID)]
+	if !ok || (userID != repository.AllUsers && workflow.UserID != userID) {
+		return nil
+	}
+	return workflow
+}
+
+// workflowNamed returns the workflow of userID with the name, the caller must hold the lock
+func (r *DB) workflowNamed(userID uint, name string) *model.Workflow {
+	for _, workflow := range r.workflows {
+		if workflow.UserID == userID && workflow.Name == name {
+			return workflow
+		}
+	}
+	return nil
+}
+
+// workflowTasks returns the stored tasks of the workflow, deleted ones included,
+// the caller must hold the lock
+func (r *DB) workflowTasks(workflowID uint) []*model.Task {
+	var tasks []*model.Task
+	for _, task := range r.tasks {
+		if task.WorkflowID != nil && *task.WorkflowID == workflowID {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
+
+// numberStatuses returns a copy of the statuses belonging to the workflow, positioned in order
+func numberStatuses(workflowID uint, statuses []model.WorkflowStatus) []model.WorkflowStatus {
+	numbered := make([]model.WorkflowStatus, len(statuses))
+	for i, status := range statuses {
+		status.WorkflowID, status.Position = workflowID, i
+		numbered[i] = status
+	}
+	return numbered
+}
+
+func copyWorkflow(workflow *model.Workflow) *model.Workflow {
+	w := *workflow
+	w.Statuses = slices.Clone(workflow.Statuses)
+	return &w
+}
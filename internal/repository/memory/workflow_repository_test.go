@@ -0,0 +1,15 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/akhilbidhuri/taskkr/internal/repository"
+	"github.com/akhilbidhuri/taskkr/internal/repository/repotest"
+)
+
+func TestWorkflowRepository(t *testing.T) {
+	repotest.RunWorkflowRepository(t, func(t *testing.T) (repository.TaskRepository, repository.WorkflowRepository) {
+		db := NewDB()
+		return NewTaskRepository(db), NewWorkflowRepository(db)
+	})
+}
@@ -0,0 +1,30 @@
+package postgres
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/akhilbidhuri/taskkr/internal/config"
+	"github.com/akhilbidhuri/taskkr/internal/repository/gormrepo"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func NewPostgresDB(cfg *config.Config) *gorm.DB {
+	dsn := fmt.Sprintf(
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		cfg.DBHost,
+		cfg.DBPort,
+		cfg.DBUser,
+		cfg.DBPassword,
+		cfg.DBName,
+	)
+	db, err := gormrepo.Open(postgres.Open(dsn), cfg)
+	if err != nil {
+		slog.Error("failed to connect database", "error", err)
+		os.Exit(1)
+	}
+	return db
+}
@@ -0,0 +1,151 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/repository"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+)
+
+// APIKeyRepositoryFactory returns an empty repository, it's called once per test
+type APIKeyRepositoryFactory func(t *testing.T) repository.APIKeyRepository
+
+// RunAPIKeyRepository runs the APIKeyRepository contract against the repositories built by newRepo
+func RunAPIKeyRepository(t *testing.T, newRepo APIKeyRepositoryFactory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, repo repository.APIKeyRepository)
+	}{
+		{"CreateAndGetByHash", testAPIKeyCreateAndGet},
+		{"DuplicateHash", testAPIKeyDuplicateHash},
+		{"List", testAPIKeyList},
+		{"Revoke", testAPIKeyRevoke},
+		{"RecordUsage", testAPIKeyRecordUsage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newRepo(t))
+		})
+	}
+}
+
+func testAPIKeyCreateAndGet(t *testing.T, repo repository.APIKeyRepository) {
+	ctx := context.Background()
+	expires := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	key := createAPIKey(t, repo, "batch", "hash-batch", &expires)
+	if key.ID == 0 || key.CreatedAt.IsZero() {
+		t.Errorf("Create = %+v, want an id and timestamps", key)
+	}
+
+	got, err := repo.GetByHash(ctx, "hash-batch")
+	if err != nil {
+		t.Fatalf("GetByHash: %v", err)
+	}
+	if got == nil || got.ID != key.ID || got.Name != "batch" || got.UserID != alice || got.Role != "member" || got.Prefix != "tk_batch" {
+		t.Fatalf("GetByHash = %+v, want %+v", got, key)
+	}
+	if got.ExpiresAt == nil || !got.ExpiresAt.Equal(expires) || got.RevokedAt != nil || got.LastUsedAt != nil || got.UsageCount != 0 {
+		t.Errorf("GetByHash = %+v, want an unused key expiring at %v", got, expires)
+	}
+
+	if got, err := repo.GetByHash(ctx, "hash-missing"); err != nil || got != nil {
+		t.Errorf("GetByHash(missing) = %+v, %v, want nothing", got, err)
+	}
+}
+
+func testAPIKeyDuplicateHash(t *testing.T, repo repository.APIKeyRepository) {
+	createAPIKey(t, repo, "batch", "hash-batch", nil)
+	key := &model.APIKey{Name: "copy", Prefix: "tk_copy", KeyHash: "hash-batch", UserID: bob, Role: "member"}
+	if err := repo.Create(context.Background(), key); err == nil {
+		t.Errorf("Create with a duplicate hash succeeded, want an error")
+	}
+}
+
+func testAPIKeyList(t *testing.T, repo repository.APIKeyRepository) {
+	ctx := context.Background()
+	if keys, err := repo.List(ctx); err != nil || len(keys) != 0 {
+		t.Fatalf("List of an empty repository = %v, %v", keys, err)
+	}
+	createAPIKey(t, repo, "batch", "hash-batch", nil)
+	createAPIKey(t, repo, "reports", "hash-reports", nil)
+	createAPIKey(t, repo, "sync", "hash-sync", nil)
+
+	keys, err := repo.List(ctx)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	var names []string
+	for _, key := range keys {
+		names = append(names, key.Name)
+	}
+	if len(names) != 3 || names[0] != "batch" || names[1] != "reports" || names[2] != "sync" {
+		t.Errorf("List = %v, want the keys by id", names)
+	}
+}
+
+func testAPIKeyRevoke(t *testing.T, repo repository.APIKeyRepository) {
+	ctx := context.Background()
+	key := createAPIKey(t, repo, "batch", "hash-batch", nil)
+	other := createAPIKey(t, repo, "reports", "hash-reports", nil)
+	at := time.Now().UTC().Truncate(time.Second)
+
+	if err := repo.Revoke(ctx, strconv.FormatUint(uint64(key.ID), 10), at); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	got, err := repo.GetByHash(ctx, "hash-batch")
+	if err != nil || got == nil {
+		t.Fatalf("GetByHash = %v, %v", got, err)
+	}
+	if got.RevokedAt == nil || !got.RevokedAt.Equal(at) {
+		t.Errorf("RevokedAt = %v, want %v", got.RevokedAt, at)
+	}
+	if got, _ := repo.GetByHash(ctx, "hash-reports"); got == nil || got.RevokedAt != nil {
+		t.Errorf("other key = %+v, want it not revoked", got)
+	}
+
+	for _, id := range []string{strconv.FormatUint(uint64(key.ID), 10), "999999", "0", "abc", ""} {
+		if err := repo.Revoke(ctx, id, at); !errors.Is(err, utils.NoEntryError) {
+			t.Errorf("Revoke(%q) = %v, want NoEntryError", id, err)
+		}
+	}
+	if err := repo.Revoke(ctx, strconv.FormatUint(uint64(other.ID), 10), at); err != nil {
+		t.Errorf("Revoke of the other key: %v", err)
+	}
+}
+
+func testAPIKeyRecordUsage(t *testing.T, repo repository.APIKeyRepository) {
+	ctx := context.Background()
+	key := createAPIKey(t, repo, "batch", "hash-batch", nil)
+	first := time.Now().UTC().Truncate(time.Second)
+	last := first.Add(time.Minute)
+
+	for _, at := range []time.Time{first, last} {
+		if err := repo.RecordUsage(ctx, key.ID, at); err != nil {
+			t.Fatalf("RecordUsage: %v", err)
+		}
+	}
+	got, err := repo.GetByHash(ctx, "hash-batch")
+	if err != nil || got == nil {
+		t.Fatalf("GetByHash = %v, %v", got, err)
+	}
+	if got.UsageCount != 2 || got.LastUsedAt == nil || !got.LastUsedAt.Equal(last) {
+		t.Errorf("usage = %d at %v, want 2 at %v", got.UsageCount, got.LastUsedAt, last)
+	}
+	if err := repo.RecordUsage(ctx, 999999, last); err != nil {
+		t.Errorf("RecordUsage of a missing key = %v, want nil", err)
+	}
+}
+
+func createAPIKey(t *testing.T, repo repository.APIKeyRepository, name, hash string, expiresAt *time.Time) *model.APIKey {
+	t.Helper()
+	key := &model.APIKey{Name: name, Prefix: "tk_" + name, KeyHash: hash, UserID: alice, Role: "member", ExpiresAt: expiresAt}
+	if err := repo.Create(context.Background(), key); err != nil {
+		t.Fatalf("Create(%q): %v", name, err)
+	}
+	return key
+}
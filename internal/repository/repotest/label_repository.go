@@ -0,0 +1,249 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/repository"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+)
+
+// LabelRepositoryFactory returns empty repositories sharing one store, labels
+// are attached to the tasks of the returned task repository
+type LabelRepositoryFactory func(t *testing.T) (repository.TaskRepository, repository.LabelRepository)
+
+// RunLabelRepository runs the LabelRepository contract, including how tasks carry labels
+func RunLabelRepository(t *testing.T, newRepos LabelRepositoryFactory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, tasks repository.TaskRepository, labels repository.LabelRepository)
+	}{
+		{"CRUD", testLabelCRUD},
+		{"UniqueNames", testLabelUniqueNames},
+		{"TaskLabels", testTaskLabels},
+		{"UnknownTaskLabels", testUnknownTaskLabels},
+		{"FilterByLabels", testFilterByLabels},
+		{"RenameAndDelete", testLabelRenameAndDelete},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks, labels := newRepos(t)
+			tt.fn(t, tasks, labels)
+		})
+	}
+}
+
+func testLabelCRUD(t *testing.T, _ repository.TaskRepository, labels repository.LabelRepository) {
+	ctx := context.Background()
+	bug := createLabel(t, labels, alice, "bug")
+	createLabel(t, labels, alice, "backend")
+	createLabel(t, labels, bob, "urgent")
+	if bug.ID == 0 || bug.CreatedAt.IsZero() {
+		t.Errorf("Create = %+v, want an id and timestamps", bug)
+	}
+
+	got, err := labels.GetByID(ctx, alice, labelID(bug))
+	if err != nil || got == nil || got.Name != "bug" {
+		t.Fatalf("GetByID = %+v, %v, want bug", got, err)
+	}
+	for _, id := range []string{labelID(bug), "999999", "abc"} {
+		if got, err := labels.GetByID(ctx, bob, id); err != nil || got != nil {
+			t.Errorf("GetByID(%q) as bob = %+v, %v, want nothing", id, got, err)
+		}
+	}
+
+	list, err := labels.List(ctx, alice)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if names := labelNames(list); !slices.Equal(names, []string{"backend", "bug"}) {
+		t.Errorf("List = %v, want alice's labels by name", names)
+	}
+	if all, _ := labels.List(ctx, repository.AllUsers); len(all) != 3 {
+		t.Errorf("List(AllUsers) has %d labels, want 3", len(all))
+	}
+
+	updated, err := labels.Update(ctx, alice, labelID(bug), &model.UpdateLabel{Color: "#d73a4a"})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if updated.Name != "bug" || updated.Color != "#d73a4a" {
+		t.Errorf("Update = %+v, want only the color changed", updated)
+	}
+	if _, err := labels.Update(ctx, bob, labelID(bug), &model.UpdateLabel{Name: "mine"}); !errors.Is(err, utils.NoEntryError) {
+		t.Errorf("Update as bob = %v, want NoEntryError", err)
+	}
+
+	if err := labels.Delete(ctx, bob, labelID(bug)); !errors.Is(err, utils.NoEntryError) {
+		t.Errorf("Delete as bob = %v, want NoEntryError", err)
+	}
+	if err := labels.Delete(ctx, alice, labelID(bug)); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if got, _ := labels.GetByID(ctx, alice, labelID(bug)); got != nil {
+		t.Errorf("GetByID after Delete = %+v", got)
+	}
+	if err := labels.Delete(ctx, alice, labelID(bug)); !errors.Is(err, utils.NoEntryError) {
+		t.Errorf("second Delete = %v, want NoEntryError", err)
+	}
+}
+
+func testLabelUniqueNames(t *testing.T, _ repository.TaskRepository, labels repository.LabelRepository) {
+	ctx := context.Background()
+	createLabel(t, labels, alice, "bug")
+	docs := createLabel(t, labels, alice, "docs")
+	// each user has their own names
+	createLabel(t, labels, bob, "bug")
+
+	if err := labels.Create(ctx, &model.Label{UserID: alice, Name: "bug"}); !errors.Is(err, utils.ConflictError) {
+		t.Errorf("Create duplicate = %v, want ConflictError", err)
+	}
+	if _, err := labels.Update(ctx, alice, labelID(docs), &model.UpdateLabel{Name: "bug"}); !errors.Is(err, utils.ConflictError) {
+		t.Errorf("rename to a taken name = %v, want ConflictError", err)
+	}
+	if _, err := labels.Update(ctx, alice, labelID(docs), &model.UpdateLabel{Name: "docs", Color: "#0075ca"}); err != nil {
+		t.Errorf("Update keeping the name: %v", err)
+	}
+}
+
+func testTaskLabels(t *testing.T, tasks repository.TaskRepository, labels repository.LabelRepository) {
+	ctx := context.Background()
+	createLabel(t, labels, alice, "bug")
+	createLabel(t, labels, alice, "backend")
+	createLabel(t, labels, alice, "docs")
+
+	task := createTaskWith(t, tasks, &model.Task{Title: "Fix login", Labels: []string{"bug", "backend"}})
+	if !slices.Equal(task.Labels, []string{"backend", "bug"}) {
+		t.Errorf("Create labels = %v, want [backend bug]", task.Labels)
+	}
+	if got := mustGet(t, tasks, alice, task.ID); !slices.Equal(got.Labels, []string{"backend", "bug"}) {
+		t.Errorf("GetByID labels = %v, want [backend bug]", got.Labels)
+	}
+
+	// other fields leave the labels alone
+	updated, err := tasks.Update(ctx, alice, idOf(task), &model.UpdateTask{Title: "Fix login form"})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if !slices.Equal(updated.Labels, []string{"backend", "bug"}) {
+		t.Errorf("labels = %v after a title update, want [backend bug]", updated.Labels)
+	}
+
+	updated, err = tasks.Update(ctx, alice, idOf(task), &model.UpdateTask{Labels: &[]string{"docs", "bug"}})
+	if err != nil {
+		t.Fatalf("Update labels: %v", err)
+	}
+	if !slices.Equal(updated.Labels, []string{"bug", "docs"}) || updated.Title != "Fix login form" {
+		t.Errorf("Update = %+v, want the labels replaced", updated)
+	}
+
+	if _, err := tasks.Update(ctx, alice, idOf(task), &model.UpdateTask{Labels: &[]string{}}); err != nil {
+		t.Fatalf("Update labels: %v", err)
+	}
+	if got := mustGet(t, tasks, alice, task.ID); len(got.Labels) != 0 {
+		t.Errorf("labels = %v, want them all removed", got.Labels)
+	}
+}
+
+func testUnknownTaskLabels(t *testing.T, tasks repository.TaskRepository, labels repository.LabelRepository) {
+	ctx := context.Background()
+	createLabel(t, labels, alice, "bug")
+	createLabel(t, labels, bob, "secret")
+
+	for _, names := range [][]string{{"bug", "missing"}, {"secret"}} {
+		err := tasks.Create(ctx, &model.Task{UserID: alice, Title: "Labelled", Labels: names})
+		if !errors.Is(err, utils.InvalidInputError) {
+			t.Errorf("Create with labels %v = %v, want InvalidInputError", names, err)
+		}
+	}
+	if _, total := list(t, tasks, &model.TaskFilter{UserID: alice}); total != 0 {
+		t.Errorf("%d tasks stored by failed creates", total)
+	}
+
+	task := createTaskWith(t, tasks, &model.Task{Title: "Labelled", Labels: []string{"bug"}})
+	_, err := tasks.Update(ctx, alice, idOf(task), &model.UpdateTask{Title: "Renamed", Labels: &[]string{"secret"}})
+	if !errors.Is(err, utils.InvalidInputError) {
+		t.Errorf("Update with bob's label = %v, want InvalidInputError", err)
+	}
+	if got := mustGet(t, tasks, alice, task.ID); got.Title != "Labelled" || !slices.Equal(got.Labels, []string{"bug"}) {
+		t.Errorf("failed Update changed the task to %+v", got)
+	}
+}
+
+func testFilterByLabels(t *testing.T, tasks repository.TaskRepository, labels repository.LabelRepository) {
+	createLabel(t, labels, alice, "bug")
+	createLabel(t, labels, alice, "backend")
+	createLabel(t, labels, bob, "bug")
+	createTaskWith(t, tasks, &model.Task{Title: "Both", Labels: []string{"bug", "backend"}})
+	createTaskWith(t, tasks, &model.Task{Title: "Bug", Labels: []string{"bug"}})
+	createTaskWith(t, tasks, &model.Task{Title: "Backend", Labels: []string{"backend"}})
+	createTaskWith(t, tasks, &model.Task{Title: "None"})
+	bobs := &model.Task{UserID: bob, Title: "Bob's bug", Labels: []string{"bug"}}
+	if err := tasks.Create(context.Background(), bobs); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	tests := []struct {
+		filter model.TaskFilter
+		want   []string
+	}{
+		{model.TaskFilter{UserID: alice, Labels: []string{"bug"}}, []string{"Both", "Bug"}},
+		{model.TaskFilter{UserID: alice, Labels: []string{"bug", "backend"}}, []string{"Both", "Bug", "Backend"}},
+		{model.TaskFilter{UserID: alice, Labels: []string{"bug", "backend"}, LabelMatch: model.LabelMatchAll}, []string{"Both"}},
+		{model.TaskFilter{UserID: alice, Labels: []string{"bug", "bug"}, LabelMatch: model.LabelMatchAll}, []string{"Both", "Bug"}},
+		{model.TaskFilter{UserID: alice, Labels: []string{"missing"}}, nil},
+		{model.TaskFilter{UserID: repository.AllUsers, Labels: []string{"bug"}}, []string{"Both", "Bug", "Bob's bug"}},
+	}
+	for _, tt := range tests {
+		got, total := list(t, tasks, &tt.filter)
+		if !sameTitles(got, tt.want...) || total != len(tt.want) {
+			t.Errorf("List(labels %v, %q) = %v (total %d), want %v", tt.filter.Labels, tt.filter.LabelMatch, titles(got), total, tt.want)
+		}
+	}
+}
+
+func testLabelRenameAndDelete(t *testing.T, tasks repository.TaskRepository, labels repository.LabelRepository) {
+	ctx := context.Background()
+	bug := createLabel(t, labels, alice, "bug")
+	createLabel(t, labels, alice, "backend")
+	task := createTaskWith(t, tasks, &model.Task{Title: "Fix login", Labels: []string{"bug", "backend"}})
+
+	if _, err := labels.Update(ctx, alice, labelID(bug), &model.UpdateLabel{Name: "defect"}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if got := mustGet(t, tasks, alice, task.ID); !slices.Equal(got.Labels, []string{"backend", "defect"}) {
+		t.Errorf("labels after a rename = %v, want [backend defect]", got.Labels)
+	}
+
+	if err := labels.Delete(ctx, alice, labelID(bug)); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if got := mustGet(t, tasks, alice, task.ID); !slices.Equal(got.Labels, []string{"backend"}) {
+		t.Errorf("labels after a delete = %v, want [backend]", got.Labels)
+	}
+}
+
+func createLabel(t *testing.T, labels repository.LabelRepository, userID uint, name string) *model.Label {
+	t.Helper()
+	label := &model.Label{UserID: userID, Name: name}
+	if err := labels.Create(context.Background(), label); err != nil {
+		t.Fatalf("Create label %q: %v", name, err)
+	}
+	return label
+}
+
+func labelID(label *model.Label) string {
+	return strconv.FormatUint(uint64(label.ID), 10)
+}
+
+func labelNames(labels []*model.Label) []string {
+	out := make([]string, len(labels))
+	for i, label := range labels {
+		out[i] = label.Name
+	}
+	return out
+}
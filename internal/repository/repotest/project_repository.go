@@ -0,0 +1,380 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/repository"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+)
+
+// ProjectRepositoryFactory returns empty repositories sharing one store, the
+// tasks of the returned task repository can be put in the projects
+type ProjectRepositoryFactory func(t *testing.T) (repository.TaskRepository, repository.ProjectRepository)
+
+// carol is a third user, never a member of the projects of alice
+const carol uint = 3
+
+// RunProjectRepository runs the ProjectRepository contract, including who sees the tasks of a project
+func RunProjectRepository(t *testing.T, newRepos ProjectRepositoryFactory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, tasks repository.TaskRepository, projects repository.ProjectRepository)
+	}{
+		{"CRUD", testProjectCRUD},
+		{"UniqueNames", testProjectUniqueNames},
+		{"Members", testProjectMembers},
+		{"DeleteInUse", testProjectDeleteInUse},
+		{"TaskVisibility", testProjectTaskVisibility},
+		{"Subtasks", testProjectSubtasks},
+		{"SetProject", testSetProject},
+		{"Graph", testProjectGraph},
+		{"Assignees", testAssignees},
+		{"AssigneeFilters", testAssigneeFilters},
+		{"AssigneesKeepSeeing", testAssigneesKeepSeeing},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks, projects := newRepos(t)
+			tt.fn(t, tasks, projects)
+		})
+	}
+}
+
+func testProjectCRUD(t *testing.T, _ repository.TaskRepository, projects repository.ProjectRepository) {
+	ctx := context.Background()
+	website := createProject(t, projects, alice, "Website")
+	createProject(t, projects, alice, "Apps")
+	createProject(t, projects, bob, "Billing")
+	if website.ID == 0 || website.CreatedAt.IsZero() {
+		t.Errorf("Create = %+v, want an id and timestamps", website)
+	}
+
+	got, err := projects.GetByID(ctx, alice, projectID(website))
+	if err != nil || got == nil || got.Name != "Website" || got.UserID != alice {
+		t.Fatalf("GetByID = %+v, %v, want alice's Website", got, err)
+	}
+	for _, id := range []string{projectID(website), "999999", "abc"} {
+		if got, err := projects.GetByID(ctx, bob, id); err != nil || got != nil {
+			t.Errorf("GetByID(%q) as bob = %+v, %v, want nothing", id, got, err)
+		}
+	}
+
+	list, err := projects.List(ctx, alice)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if names := projectNames(list); !slices.Equal(names, []string{"Apps", "Website"}) {
+		t.Errorf("List = %v, want alice's projects by name", names)
+	}
+	if all, _ := projects.List(ctx, repository.AllUsers); len(all) != 3 {
+		t.Errorf("List(AllUsers) has %d projects, want 3", len(all))
+	}
+
+	updated, err := projects.Update(ctx, alice, projectID(website), &model.UpdateProject{Description: "The public site"})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if updated.Name != "Website" || updated.Description != "The public site" {
+		t.Errorf("Update = %+v, want only the description changed", updated)
+	}
+	if _, err := projects.Update(ctx, alice, projectID(website), &model.UpdateProject{}); !errors.Is(err, utils.NoEntryError) {
+		t.Errorf("empty Update = %v, want NoEntryError", err)
+	}
+	if _, err := projects.Update(ctx, bob, projectID(website), &model.UpdateProject{Name: "Mine"}); !errors.Is(err, utils.NoEntryError) {
+		t.Errorf("Update as bob = %v, want NoEntryError", err)
+	}
+
+	if err := projects.Delete(ctx, bob, projectID(website)); !errors.Is(err, utils.NoEntryError) {
+		t.Errorf("Delete as bob = %v, want NoEntryError", err)
+	}
+	if err := projects.Delete(ctx, alice, projectID(website)); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if got, err := projects.GetByID(ctx, alice, projectID(website)); err != nil || got != nil {
+		t.Errorf("GetByID after Delete = %+v, %v, want nothing", got, err)
+	}
+	if err := projects.Delete(ctx, alice, projectID(website)); !errors.Is(err, utils.NoEntryError) {
+		t.Errorf("second Delete = %v, want NoEntryError", err)
+	}
+}
+
+func testProjectUniqueNames(t *testing.T, _ repository.TaskRepository, projects repository.ProjectRepository) {
+	ctx := context.Background()
+	createProject(t, projects, alice, "Website")
+	apps := createProject(t, projects, alice, "Apps")
+	// names are unique per owner only
+	createProject(t, projects, bob, "Website")
+
+	if err := projects.Create(ctx, &model.Project{UserID: alice, Name: "Website"}); !errors.Is(err, utils.ConflictError) {
+		t.Errorf("Create of a duplicate = %v, want ConflictError", err)
+	}
+	if _, err := projects.Update(ctx, alice, projectID(apps), &model.UpdateProject{Name: "Website"}); !errors.Is(err, utils.ConflictError) {
+		t.Errorf("rename to a taken name = %v, want ConflictError", err)
+	}
+	if _, err := projects.Update(ctx, alice, projectID(apps), &model.UpdateProject{Name: "Apps"}); err != nil {
+		t.Errorf("rename to its own name = %v", err)
+	}
+}
+
+func testProjectMembers(t *testing.T, _ repository.TaskRepository, projects repository.ProjectRepository) {
+	ctx := context.Background()
+	website := createProject(t, projects, alice, "Website")
+
+	members, err := projects.Members(ctx, alice, projectID(website))
+	if err != nil {
+		t.Fatalf("Members: %v", err)
+	}
+	if got := memberRoles(members); !slices.Equal(got, []string{"1:owner"}) {
+		t.Errorf("Members of a new project = %v, want the owner only", got)
+	}
+
+	addMember(t, projects, alice, website, bob)
+	err = projects.AddMember(ctx, alice, projectID(website), &model.ProjectMember{UserID: bob, Role: model.RoleMember})
+	if !errors.Is(err, utils.ConflictError) {
+		t.Errorf("AddMember of a member = %v, want ConflictError", err)
+	}
+	// members see the project but only the owner manages it
+	err = projects.AddMember(ctx, bob, projectID(website), &model.ProjectMember{UserID: carol, Role: model.RoleMember})
+	if !errors.Is(err, utils.NoEntryError) {
+		t.Errorf("AddMember as a member = %v, want NoEntryError", err)
+	}
+	if _, err := projects.Update(ctx, bob, projectID(website), &model.UpdateProject{Name: "Site"}); !errors.Is(err, utils.NoEntryError) {
+		t.Errorf("Update as a member = %v, want NoEntryError", err)
+	}
+	if got, err := projects.GetByID(ctx, bob, projectID(website)); err != nil || got == nil {
+		t.Errorf("GetByID as a member = %+v, %v, want the project", got, err)
+	}
+	if list, _ := projects.List(ctx, bob); !slices.Equal(projectNames(list), []string{"Website"}) {
+		t.Errorf("List as a member = %v, want [Website]", projectNames(list))
+	}
+
+	members, err = projects.Members(ctx, bob, projectID(website))
+	if err != nil {
+		t.Fatalf("Members: %v", err)
+	}
+	if got := memberRoles(members); !slices.Equal(got, []string{"1:owner", "2:member"}) {
+		t.Errorf("Members = %v, want the owner and bob", got)
+	}
+	if members, err := projects.Members(ctx, carol, projectID(website)); err != nil || members != nil {
+		t.Errorf("Members as carol = %v, %v, want nothing", members, err)
+	}
+
+	if err := projects.RemoveMember(ctx, alice, projectID(website), alice); !errors.Is(err, utils.InvalidInputError) {
+		t.Errorf("RemoveMember of the owner = %v, want InvalidInputError", err)
+	}
+	if err := projects.RemoveMember(ctx, alice, projectID(website), carol); !errors.Is(err, utils.NoEntryError) {
+		t.Errorf("RemoveMember of a stranger = %v, want NoEntryError", err)
+	}
+	if err := projects.RemoveMember(ctx, alice, projectID(website), bob); err != nil {
+		t.Fatalf("RemoveMember: %v", err)
+	}
+	if got, err := projects.GetByID(ctx, bob, projectID(website)); err != nil || got != nil {
+		t.Errorf("GetByID after leaving = %+v, %v, want nothing", got, err)
+	}
+}
+
+func testProjectDeleteInUse(t *testing.T, tasks repository.TaskRepository, projects repository.ProjectRepository) {
+	ctx := context.Background()
+	website := createProject(t, projects, alice, "Website")
+	task := createTaskWith(t, tasks, &model.Task{Title: "Landing page", ProjectID: &website.ID})
+
+	if err := projects.Delete(ctx, alice, projectID(website)); !errors.Is(err, utils.ConflictError) {
+		t.Errorf("Delete with a task = %v, want ConflictError", err)
+	}
+	// deleted tasks don't hold on to their project
+	if err := tasks.Delete(ctx, alice, idOf(task)); err != nil {
+		t.Fatalf("Delete task: %v", err)
+	}
+	if err := projects.Delete(ctx, alice, projectID(website)); err != nil {
+		t.Errorf("Delete without tasks = %v", err)
+	}
+}
+
+func testProjectTaskVisibility(t *testing.T, tasks repository.TaskRepository, projects repository.ProjectRepository) {
+	ctx := context.Background()
+	website := createProject(t, projects, alice, "Website")
+	apps := createProject(t, projects, alice, "Apps")
+	addMember(t, projects, alice, website, bob)
+	landing := createTaskWith(t, tasks, &model.Task{Title: "Landing page", ProjectID: &website.ID})
+	createTaskWith(t, tasks, &model.Task{Title: "iOS release", ProjectID: &apps.ID})
+	createTask(t, tasks, alice, "Dentist", "")
+	own := createTask(t, tasks, bob, "Review copy", "")
+	if _, err := tasks.SetProject(ctx, bob, idOf(own), &website.ID); err != nil {
+		t.Fatalf("SetProject: %v", err)
+	}
+
+	if got := mustGet(t, tasks, bob, landing.ID); got.ProjectID == nil || *got.ProjectID != website.ID {
+		t.Errorf("landing page is in project %v, want %d", got.ProjectID, website.ID)
+	}
+	got, _ := list(t, tasks, &model.TaskFilter{UserID: bob})
+	if !sameTitles(got, "Landing page", "Review copy") {
+		t.Errorf("List as bob = %v, want his tasks and the ones of Website", titles(got))
+	}
+	got, _ = list(t, tasks, &model.TaskFilter{UserID: alice, ProjectID: &website.ID})
+	if !sameTitles(got, "Landing page", "Review copy") {
+		t.Errorf("List of Website as alice = %v, want the tasks of every member", titles(got))
+	}
+	got, _ = list(t, tasks, &model.TaskFilter{UserID: bob, ProjectID: &apps.ID})
+	if len(got) != 0 {
+		t.Errorf("List of Apps as bob = %v, want nothing", titles(got))
+	}
+	if task, err := tasks.GetByID(ctx, carol, idOf(landing)); err != nil || task != nil {
+		t.Errorf("GetByID as carol = %+v, %v, want nothing", task, err)
+	}
+
+	// members see the tasks, only their owner changes them
+	if _, err := tasks.Update(ctx, bob, idOf(landing), &model.UpdateTask{Title: "Mine"}); !errors.Is(err, utils.NoEntryError) {
+		t.Errorf("Update as a member = %v, want NoEntryError", err)
+	}
+	if err := tasks.Delete(ctx, bob, idOf(landing)); !errors.Is(err, utils.NoEntryError) {
+		t.Errorf("Delete as a member = %v, want NoEntryError", err)
+	}
+
+	if err := projects.RemoveMember(ctx, alice, projectID(website), bob); err != nil {
+		t.Fatalf("RemoveMember: %v", err)
+	}
+	if task, err := tasks.GetByID(ctx, bob, idOf(landing)); err != nil || task != nil {
+		t.Errorf("GetByID after leaving = %+v, %v, want nothing", task, err)
+	}
+	// bob keeps his own task, even in a project he left
+	got, _ = list(t, tasks, &model.TaskFilter{UserID: bob})
+	if !sameTitles(got, "Review copy") {
+		t.Errorf("List after leaving = %v, want his own task", titles(got))
+	}
+}
+
+func testProjectSubtasks(t *testing.T, tasks repository.TaskRepository, projects repository.ProjectRepository) {
+	ctx := context.Background()
+	website := createProject(t, projects, alice, "Website")
+	apps := createProject(t, projects, alice, "Apps")
+	landing := createTaskWith(t, tasks, &model.Task{Title: "Landing page", ProjectID: &website.ID})
+	release := createTaskWith(t, tasks, &model.Task{Title: "iOS release", ProjectID: &apps.ID})
+
+	copywriting := createTaskWith(t, tasks, &model.Task{Title: "Copywriting", ParentID: &landing.ID})
+	if copywriting.ProjectID == nil || *copywriting.ProjectID != website.ID {
+		t.Errorf("subtask is in project %v, want the one of its parent", copywriting.ProjectID)
+	}
+	err := tasks.Create(ctx, &model.Task{UserID: alice, Title: "Screenshots", ParentID: &landing.ID, ProjectID: &apps.ID})
+	if !errors.Is(err, utils.InvalidInputError) {
+		t.Errorf("Create of a subtask in another project = %v, want InvalidInputError", err)
+	}
+	if _, err := tasks.Move(ctx, alice, idOf(copywriting), &release.ID); !errors.Is(err, utils.InvalidInputError) {
+		t.Errorf("Move under a task of another project = %v, want InvalidInputError", err)
+	}
+	// a subtask moved to the top level stays in its project
+	moved, err := tasks.Move(ctx, alice, idOf(copywriting), nil)
+	if err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	if moved.ProjectID == nil || *moved.ProjectID != website.ID {
+		t.Errorf("moved task is in project %v, want %d", moved.ProjectID, website.ID)
+	}
+}
+
+func testSetProject(t *testing.T, tasks repository.TaskRepository, projects repository.ProjectRepository) {
+	ctx := context.Background()
+	website := createProject(t, projects, alice, "Website")
+	landing := createTaskWith(t, tasks, &model.Task{Title: "Landing page"})
+	copywriting := createTaskWith(t, tasks, &model.Task{Title: "Copywriting", ParentID: &landing.ID})
+	proofreading := createTaskWith(t, tasks, &model.Task{Title: "Proofreading", ParentID: &copywriting.ID})
+
+	if _, err := tasks.SetProject(ctx, alice, idOf(copywriting), &website.ID); !errors.Is(err, utils.InvalidInputError) {
+		t.Errorf("SetProject of a subtask = %v, want InvalidInputError", err)
+	}
+	if _, err := tasks.SetProject(ctx, bob, idOf(landing), &website.ID); !errors.Is(err, utils.NoEntryError) {
+		t.Errorf("SetProject as bob = %v, want NoEntryError", err)
+	}
+	moved, err := tasks.SetProject(ctx, alice, idOf(landing), &website.ID)
+	if err != nil {
+		t.Fatalf("SetProject: %v", err)
+	}
+	if moved.ProjectID == nil || *moved.ProjectID != website.ID {
+		t.Errorf("SetProject = %+v, want the task in Website", moved)
+	}
+	got, _ := list(t, tasks, &model.TaskFilter{UserID: alice, ProjectID: &website.ID})
+	if !sameTitles(got, "Landing page", "Copywriting", "Proofreading") {
+		t.Errorf("tasks of Website = %v, want the task with its subtasks", titles(got))
+	}
+
+	if _, err := tasks.SetProject(ctx, alice, idOf(landing), nil); err != nil {
+		t.Fatalf("SetProject(nil): %v", err)
+	}
+	if got := mustGet(t, tasks, alice, proofreading.ID); got.ProjectID != nil {
+		t.Errorf("subtask is still in project %d", *got.ProjectID)
+	}
+}
+
+func testProjectGraph(t *testing.T, tasks repository.TaskRepository, projects repository.ProjectRepository) {
+	ctx := context.Background()
+	website := createProject(t, projects, alice, "Website")
+	addMember(t, projects, alice, website, bob)
+	// design <- build <- release, design isn't in the project
+	design := createTask(t, tasks, alice, "Design", "")
+	build := createTaskWith(t, tasks, &model.Task{Title: "Build", ProjectID: &website.ID})
+	release := createTaskWith(t, tasks, &model.Task{Title: "Release", ProjectID: &website.ID})
+	addDependency(t, tasks, build, design)
+	addDependency(t, tasks, release, build)
+
+	graph, err := tasks.Graph(ctx, bob, idOf(build))
+	if err != nil || graph == nil {
+		t.Fatalf("Graph = %+v, %v", graph, err)
+	}
+	if !sameTitles(graph.Tasks, "Build", "Release") {
+		t.Errorf("Graph tasks as a member = %v, want the ones of the project", titles(graph.Tasks))
+	}
+	want := []string{fmt.Sprintf("%d<-%d", release.ID, build.ID)}
+	if got := edges(graph); !slices.Equal(got, want) {
+		t.Errorf("Graph edges as a member = %v, want %v", got, want)
+	}
+	// the owner sees the whole graph, blocked is the same for everyone
+	if graph, _ := tasks.Graph(ctx, alice, idOf(build)); len(graph.Tasks) != 3 || len(graph.Edges) != 2 {
+		t.Errorf("Graph as the owner = %v with %d edges, want 3 tasks and 2 edges", titles(graph.Tasks), len(graph.Edges))
+	}
+	if got := mustGet(t, tasks, bob, build.ID); !got.Blocked {
+		t.Error("build isn't blocked for a member")
+	}
+}
+
+func createProject(t *testing.T, projects repository.ProjectRepository, userID uint, name string) *model.Project {
+	t.Helper()
+	project := &model.Project{UserID: userID, Name: name}
+	if err := projects.Create(context.Background(), project); err != nil {
+		t.Fatalf("Create %q: %v", name, err)
+	}
+	return project
+}
+
+func addMember(t *testing.T, projects repository.ProjectRepository, owner uint, project *model.Project, userID uint) {
+	t.Helper()
+	member := &model.ProjectMember{UserID: userID, Role: model.RoleMember}
+	if err := projects.AddMember(context.Background(), owner, projectID(project), member); err != nil {
+		t.Fatalf("AddMember(%d): %v", userID, err)
+	}
+}
+
+func projectID(project *model.Project) string {
+	return strconv.FormatUint(uint64(project.ID), 10)
+}
+
+func projectNames(projects []*model.Project) []string {
+	names := make([]string, len(projects))
+	for i, project := range projects {
+		names[i] = project.Name
+	}
+	return names
+}
+
+// memberRoles returns the members as user:role
+func memberRoles(members []*model.ProjectMember) []string {
+	roles := make([]string, len(members))
+	for i, member := range members {
+		roles[i] = fmt.Sprintf("%d:%s", member.UserID, member.Role)
+	}
+	return roles
+}
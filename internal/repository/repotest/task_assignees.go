@@ -0,0 +1,175 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/repository"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+)
+
+func testAssignees(t *testing.T, tasks repository.TaskRepository, projects repository.ProjectRepository) {
+	ctx := context.Background()
+	website := createProject(t, projects, alice, "Website")
+	addMember(t, projects, alice, website, bob)
+	landing := createTaskWith(t, tasks, &model.Task{Title: "Landing page", ProjectID: &website.ID})
+	dentist := createTask(t, tasks, alice, "Dentist", "")
+
+	assign(t, tasks, landing, bob)
+	assign(t, tasks, landing, alice)
+	if got := mustGet(t, tasks, bob, landing.ID); !slices.Equal(got.Assignees, []uint{alice, bob}) {
+		t.Errorf("assignees = %v, want [%d %d]", got.Assignees, alice, bob)
+	}
+	assign(t, tasks, dentist, alice)
+
+	tests := []struct {
+		name     string
+		userID   uint
+		task     *model.Task
+		assignee uint
+		want     error
+	}{
+		{"twice", alice, landing, bob, utils.ConflictError},
+		{"not a member", alice, landing, carol, utils.InvalidInputError},
+		{"task without project", alice, dentist, bob, utils.InvalidInputError},
+		{"as a member", bob, landing, carol, utils.NoEntryError},
+		{"missing task", alice, &model.Task{ID: 999999}, alice, utils.NoEntryError},
+	}
+	for _, tt := range tests {
+		if err := tasks.Assign(ctx, tt.userID, idOf(tt.task), tt.assignee, tt.userID); !errors.Is(err, tt.want) {
+			t.Errorf("Assign %s = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	if err := tasks.Unassign(ctx, bob, idOf(landing), alice, bob); !errors.Is(err, utils.NoEntryError) {
+		t.Errorf("Unassign as a member = %v, want NoEntryError", err)
+	}
+	if err := tasks.Unassign(ctx, alice, idOf(landing), bob, alice); err != nil {
+		t.Fatalf("Unassign: %v", err)
+	}
+	if err := tasks.Unassign(ctx, alice, idOf(landing), bob, alice); !errors.Is(err, utils.NoEntryError) {
+		t.Errorf("second Unassign = %v, want NoEntryError", err)
+	}
+	got, _ := list(t, tasks, &model.TaskFilter{UserID: alice, Sort: []model.TaskSort{{Field: model.SortID}}})
+	if len(got) != 2 || !slices.Equal(got[0].Assignees, []uint{alice}) || !slices.Equal(got[1].Assignees, []uint{alice}) {
+		t.Errorf("List = %v, want both tasks assigned to alice", got)
+	}
+
+	// members read the history, the events of other tasks aren't part of it
+	history, err := tasks.History(ctx, bob, idOf(landing))
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	want := []string{"1 assigned 2", "1 assigned 1", "1 unassigned 2"}
+	if got := events(history); !slices.Equal(got, want) {
+		t.Errorf("History = %v, want %v", got, want)
+	}
+	if history[0].TaskID != landing.ID || history[0].CreatedAt.IsZero() {
+		t.Errorf("History[0] = %+v, want an event of the task with its time", history[0])
+	}
+	for _, id := range []string{idOf(dentist), "999999", "abc"} {
+		if history, err := tasks.History(ctx, bob, id); err != nil || history != nil {
+			t.Errorf("History(%q) as bob = %v, %v, want nothing", id, history, err)
+		}
+	}
+	if history, err := tasks.History(ctx, alice, idOf(dentist)); err != nil || len(history) != 1 {
+		t.Errorf("History of dentist = %v, %v, want one event", events(history), err)
+	}
+}
+
+func testAssigneeFilters(t *testing.T, tasks repository.TaskRepository, projects repository.ProjectRepository) {
+	ctx := context.Background()
+	website := createProject(t, projects, alice, "Website")
+	addMember(t, projects, alice, website, bob)
+	landing := createTaskWith(t, tasks, &model.Task{Title: "Landing page", ProjectID: &website.ID})
+	createTaskWith(t, tasks, &model.Task{Title: "Footer", ProjectID: &website.ID})
+	review := createTask(t, tasks, bob, "Review copy", "")
+	if _, err := tasks.SetProject(ctx, bob, idOf(review), &website.ID); err != nil {
+		t.Fatalf("SetProject: %v", err)
+	}
+	dentist := createTask(t, tasks, alice, "Dentist", "")
+	assign(t, tasks, landing, bob)
+	assign(t, tasks, dentist, alice)
+
+	byID := []model.TaskSort{{Field: model.SortID}}
+	tests := []struct {
+		name   string
+		filter *model.TaskFilter
+		want   []string
+	}{
+		{"assigned to bob", &model.TaskFilter{UserID: bob, AssigneeID: ptr(bob)}, []string{"Landing page"}},
+		{"assigned to alice", &model.TaskFilter{UserID: repository.AllUsers, AssigneeID: ptr(alice)}, []string{"Dentist"}},
+		{"unassigned", &model.TaskFilter{UserID: alice, Unassigned: true}, []string{"Footer", "Review copy"}},
+		{"created by bob", &model.TaskFilter{UserID: alice, CreatedBy: ptr(bob)}, []string{"Review copy"}},
+		{"work of bob", &model.TaskFilter{UserID: bob, InvolvedID: ptr(bob)}, []string{"Landing page", "Review copy"}},
+		{"work of alice", &model.TaskFilter{UserID: alice, InvolvedID: ptr(alice)}, []string{"Landing page", "Footer", "Dentist"}},
+		{"work of bob in the project", &model.TaskFilter{UserID: bob, InvolvedID: ptr(bob), ProjectID: &website.ID, Status: model.StatusPending}, []string{"Landing page", "Review copy"}},
+	}
+	for _, tt := range tests {
+		tt.filter.Sort = byID
+		got, total := list(t, tasks, tt.filter)
+		if !sameTitles(got, tt.want...) || total != len(tt.want) {
+			t.Errorf("List %s = %v (total %d), want %v", tt.name, titles(got), total, tt.want)
+		}
+	}
+}
+
+func testAssigneesKeepSeeing(t *testing.T, tasks repository.TaskRepository, projects repository.ProjectRepository) {
+	ctx := context.Background()
+	website := createProject(t, projects, alice, "Website")
+	apps := createProject(t, projects, alice, "Apps")
+	addMember(t, projects, alice, website, bob)
+	landing := createTaskWith(t, tasks, &model.Task{Title: "Landing page", ProjectID: &website.ID})
+	copywriting := createTaskWith(t, tasks, &model.Task{Title: "Copywriting", ParentID: &landing.ID})
+	assign(t, tasks, landing, alice)
+	assign(t, tasks, copywriting, bob)
+
+	// bob works on a subtask and would lose it in a project without bob
+	for _, projectID := range []*uint{&apps.ID, nil} {
+		if _, err := tasks.SetProject(ctx, alice, idOf(landing), projectID); !errors.Is(err, utils.InvalidInputError) {
+			t.Errorf("SetProject(%v) = %v, want InvalidInputError", projectID, err)
+		}
+	}
+	if err := projects.RemoveMember(ctx, alice, projectID(website), bob); !errors.Is(err, utils.ConflictError) {
+		t.Errorf("RemoveMember of an assignee = %v, want ConflictError", err)
+	}
+
+	addMember(t, projects, alice, apps, bob)
+	if _, err := tasks.SetProject(ctx, alice, idOf(landing), &apps.ID); err != nil {
+		t.Fatalf("SetProject: %v", err)
+	}
+	if err := projects.RemoveMember(ctx, alice, projectID(website), bob); err != nil {
+		t.Errorf("RemoveMember once the tasks moved: %v", err)
+	}
+	if err := tasks.Unassign(ctx, alice, idOf(copywriting), bob, alice); err != nil {
+		t.Fatalf("Unassign: %v", err)
+	}
+	// the owner always sees the task
+	if _, err := tasks.SetProject(ctx, alice, idOf(landing), nil); err != nil {
+		t.Errorf("SetProject(nil) assigned to the owner: %v", err)
+	}
+}
+
+func assign(t *testing.T, tasks repository.TaskRepository, task *model.Task, userID uint) {
+	t.Helper()
+	if err := tasks.Assign(context.Background(), task.UserID, idOf(task), userID, task.UserID); err != nil {
+		t.Fatalf("Assign(%d) to %q: %v", userID, task.Title, err)
+	}
+}
+
+// events returns the events as "actor type assignee"
+func events(history []*model.TaskEvent) []string {
+	out := make([]string, len(history))
+	for i, event := range history {
+		out[i] = fmt.Sprintf("%d %s %d", event.ActorID, event.Type, *event.AssigneeID)
+	}
+	return out
+}
+
+func ptr(id uint) *uint {
+	return &id
+}
@@ -0,0 +1,249 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/repository"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+)
+
+func testDependencies(t *testing.T, repo repository.TaskRepository) {
+	ctx := context.Background()
+	design := createTask(t, repo, alice, "Design", "")
+	build := createTask(t, repo, alice, "Build", "")
+	review := createTask(t, repo, alice, "Review", model.StatusCompleted)
+
+	addDependency(t, repo, build, design)
+	addDependency(t, repo, build, review)
+	if got := mustGet(t, repo, alice, build.ID); !got.Blocked {
+		t.Error("Build isn't blocked by the pending Design")
+	}
+	if got := mustGet(t, repo, alice, design.ID); got.Blocked {
+		t.Error("Design is blocked, only its dependents should be")
+	}
+	tasks, _ := list(t, repo, &model.TaskFilter{UserID: alice, Sort: []model.TaskSort{{Field: model.SortID}}})
+	if blocked := blockedTitles(tasks); !slices.Equal(blocked, []string{"Build"}) {
+		t.Errorf("List blocked tasks = %v, want [Build]", blocked)
+	}
+
+	updated, err := repo.Update(ctx, alice, idOf(design), &model.UpdateTask{Status: model.StatusCompleted})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if updated.Blocked {
+		t.Error("Update returned Design as blocked")
+	}
+	if got := mustGet(t, repo, alice, build.ID); got.Blocked {
+		t.Error("Build is still blocked with every blocker completed")
+	}
+
+	if _, err := repo.Update(ctx, alice, idOf(review), &model.UpdateTask{Status: model.StatusPending}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if err := repo.RemoveDependency(ctx, alice, idOf(build), review.ID); err != nil {
+		t.Fatalf("RemoveDependency: %v", err)
+	}
+	if got := mustGet(t, repo, alice, build.ID); got.Blocked {
+		t.Error("Build is blocked by a removed dependency")
+	}
+	if err := repo.RemoveDependency(ctx, alice, idOf(build), review.ID); !errors.Is(err, utils.NoEntryError) {
+		t.Errorf("second RemoveDependency = %v, want NoEntryError", err)
+	}
+	if err := repo.RemoveDependency(ctx, bob, idOf(build), design.ID); !errors.Is(err, utils.NoEntryError) {
+		t.Errorf("RemoveDependency as bob = %v, want NoEntryError", err)
+	}
+}
+
+func testDependencyErrors(t *testing.T, repo repository.TaskRepository) {
+	ctx := context.Background()
+	a := createTask(t, repo, alice, "A", "")
+	b := createTask(t, repo, alice, "B", "")
+	c := createTask(t, repo, alice, "C", "")
+	bobs := createTask(t, repo, bob, "Bob's", "")
+	addDependency(t, repo, b, a)
+	addDependency(t, repo, c, b)
+
+	tests := []struct {
+		task      *model.Task
+		blockedBy uint
+		want      error
+	}{
+		{a, a.ID, utils.InvalidInputError},
+		{a, b.ID, utils.InvalidInputError},
+		{a, c.ID, utils.InvalidInputError},
+		{a, bobs.ID, utils.InvalidInputError},
+		{a, 999999, utils.InvalidInputError},
+		{b, a.ID, utils.ConflictError},
+	}
+	for _, tt := range tests {
+		err := repo.AddDependency(ctx, alice, idOf(tt.task), tt.blockedBy)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("AddDependency(%s blocked by %d) = %v, want %v", tt.task.Title, tt.blockedBy, err, tt.want)
+		}
+	}
+	if err := repo.AddDependency(ctx, bob, idOf(a), bobs.ID); !errors.Is(err, utils.NoEntryError) {
+		t.Errorf("AddDependency as bob = %v, want NoEntryError", err)
+	}
+	// a shortcut across the chain isn't a cycle
+	addDependency(t, repo, c, a)
+}
+
+func testDependencyGraph(t *testing.T, repo repository.TaskRepository) {
+	ctx := context.Background()
+	// design <- build <- test <- release, with docs <- release and lint <- test
+	design := createTask(t, repo, alice, "Design", model.StatusCompleted)
+	build := createTask(t, repo, alice, "Build", "")
+	test := createTask(t, repo, alice, "Test", "")
+	release := createTask(t, repo, alice, "Release", "")
+	docs := createTask(t, repo, alice, "Docs", "")
+	lint := createTask(t, repo, alice, "Lint", "")
+	createTask(t, repo, alice, "Unrelated", "")
+	addDependency(t, repo, build, design)
+	addDependency(t, repo, test, build)
+	addDependency(t, repo, release, test)
+	addDependency(t, repo, release, docs)
+	addDependency(t, repo, test, lint)
+	addDependency(t, repo, release, build)
+
+	graph, err := repo.Graph(ctx, alice, idOf(build))
+	if err != nil {
+		t.Fatalf("Graph: %v", err)
+	}
+	if graph == nil {
+		t.Fatal("Graph found nothing")
+	}
+	// lint and docs only meet build's chain downstream, they aren't part of its graph
+	if !sameTitles(graph.Tasks, "Design", "Build", "Test", "Release") {
+		t.Errorf("Graph tasks = %v, want Design, Build, Test and Release", titles(graph.Tasks))
+	}
+	want := []string{
+		fmt.Sprintf("%d<-%d", build.ID, design.ID),
+		fmt.Sprintf("%d<-%d", test.ID, build.ID),
+		fmt.Sprintf("%d<-%d", release.ID, build.ID),
+		fmt.Sprintf("%d<-%d", release.ID, test.ID),
+	}
+	if got := edges(graph); !slices.Equal(got, want) {
+		t.Errorf("Graph edges = %v, want %v", got, want)
+	}
+	blocked := blockedTitles(graph.Tasks)
+	if !slices.Equal(blocked, []string{"Test", "Release"}) {
+		t.Errorf("blocked tasks in the graph = %v, want [Test Release]", blocked)
+	}
+
+	graph, err = repo.Graph(ctx, alice, idOf(docs))
+	if err != nil {
+		t.Fatalf("Graph: %v", err)
+	}
+	if !sameTitles(graph.Tasks, "Release", "Docs") || len(graph.Edges) != 1 {
+		t.Errorf("Graph(Docs) = %v with %d edges", titles(graph.Tasks), len(graph.Edges))
+	}
+
+	for _, id := range []string{"999999", "abc"} {
+		if graph, err := repo.Graph(ctx, alice, id); err != nil || graph != nil {
+			t.Errorf("Graph(%q) = %+v, %v, want nothing", id, graph, err)
+		}
+	}
+	if graph, err := repo.Graph(ctx, bob, idOf(build)); err != nil || graph != nil {
+		t.Errorf("Graph as bob = %+v, %v, want nothing", graph, err)
+	}
+}
+
+func testDeleteBlocker(t *testing.T, repo repository.TaskRepository) {
+	ctx := context.Background()
+	blocker := createTask(t, repo, alice, "Blocker", "")
+	task := createTask(t, repo, alice, "Task", "")
+	addDependency(t, repo, task, blocker)
+
+	if err := repo.Delete(ctx, alice, idOf(blocker)); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if got := mustGet(t, repo, alice, task.ID); got.Blocked {
+		t.Error("task is still blocked by a deleted task")
+	}
+	graph, err := repo.Graph(ctx, alice, idOf(task))
+	if err != nil {
+		t.Fatalf("Graph: %v", err)
+	}
+	if !sameTitles(graph.Tasks, "Task") || len(graph.Edges) != 0 {
+		t.Errorf("Graph = %v with %d edges, want the task alone", titles(graph.Tasks), len(graph.Edges))
+	}
+}
+
+// testConcurrentDependencies races dependencies that would close a cycle
+// together, each along with duplicates of itself
+func testConcurrentDependencies(t *testing.T, repo repository.TaskRepository) {
+	ctx := context.Background()
+	// a <- b and c <- d would close a <- b <- c <- d <- a
+	a := createTask(t, repo, alice, "A", "")
+	b := createTask(t, repo, alice, "B", "")
+	c := createTask(t, repo, alice, "C", "")
+	d := createTask(t, repo, alice, "D", "")
+	addDependency(t, repo, b, c)
+	addDependency(t, repo, d, a)
+	pairs := [][2]*model.Task{{a, b}, {c, d}}
+
+	const n = 20
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var added []string
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(pair [2]*model.Task) {
+			defer wg.Done()
+			err := repo.AddDependency(ctx, alice, idOf(pair[0]), pair[1].ID)
+			if err != nil {
+				if !errors.Is(err, utils.ConflictError) && !errors.Is(err, utils.InvalidInputError) {
+					t.Errorf("AddDependency(%s blocked by %s): %v", pair[0].Title, pair[1].Title, err)
+				}
+				return
+			}
+			mu.Lock()
+			added = append(added, pair[0].Title+"<-"+pair[1].Title)
+			mu.Unlock()
+		}(pairs[i%len(pairs)])
+	}
+	wg.Wait()
+
+	if len(added) != 1 {
+		t.Errorf("%d dependencies added, want a single one without duplicates or cycle: %v", len(added), added)
+	}
+	graph, err := repo.Graph(ctx, alice, idOf(a))
+	if err != nil {
+		t.Fatalf("Graph: %v", err)
+	}
+	if len(graph.Edges) != 3 {
+		t.Errorf("Graph edges = %v, want the 2 first dependencies and one of the raced ones", edges(graph))
+	}
+}
+
+func addDependency(t *testing.T, repo repository.TaskRepository, task, blockedBy *model.Task) {
+	t.Helper()
+	if err := repo.AddDependency(context.Background(), task.UserID, idOf(task), blockedBy.ID); err != nil {
+		t.Fatalf("AddDependency(%s blocked by %s): %v", task.Title, blockedBy.Title, err)
+	}
+}
+
+func blockedTitles(tasks []*model.Task) []string {
+	var out []string
+	for _, task := range tasks {
+		if task.Blocked {
+			out = append(out, task.Title)
+		}
+	}
+	return out
+}
+
+// edges renders the graph edges as task<-blocked_by
+func edges(graph *model.TaskGraph) []string {
+	out := make([]string, len(graph.Edges))
+	for i, edge := range graph.Edges {
+		out[i] = fmt.Sprintf("%d<-%d", edge.TaskID, edge.BlockedByID)
+	}
+	return out
+}
@@ -0,0 +1,865 @@
+// Package repotest holds the conformance suites every repository
+// implementation has to pass, so the storage backends stay interchangeable
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/repository"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+)
+
+// TaskRepositoryFactory returns an empty repository, it's called once per test
+type TaskRepositoryFactory func(t *testing.T) repository.TaskRepository
+
+const (
+	alice uint = 1
+	bob   uint = 2
+)
+
+// RunTaskRepository runs the TaskRepository contract against the repositories built by newRepo
+func RunTaskRepository(t *testing.T, newRepo TaskRepositoryFactory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, repo repository.TaskRepository)
+	}{
+		{"CreateAndGet", testCreateAndGet},
+		{"GetMissing", testGetMissing},
+		{"OwnerScope", testOwnerScope},
+		{"Update", testUpdate},
+		{"UpdateFromStatus", testUpdateFromStatus},
+		{"UpdateDates", testUpdateDates},
+		{"UpdateMissing", testUpdateMissing},
+		{"StatusTimestamps", testStatusTimestamps},
+		{"Delete", testDelete},
+		{"SoftDeleteVisibility", testSoftDeleteVisibility},
+		{"FilterByStatus", testFilterByStatus},
+		{"FilterByTitle", testFilterByTitle},
+		{"FilterByDueDate", testFilterByDueDate},
+		{"FilterByPriority", testFilterByPriority},
+		{"Sort", testSort},
+		{"SortNonASCII", testSortNonASCII},
+		{"Pagination", testPagination},
+		{"Cursor", testCursor},
+		{"CursorStableWhileInserting", testCursorStableWhileInserting},
+		{"CountByStatus", testCountByStatus},
+		{"CreateSubtask", testCreateSubtask},
+		{"Subtree", testSubtree},
+		{"Move", testMove},
+		{"CountChildren", testCountChildren},
+		{"DeleteParent", testDeleteParent},
+		{"Dependencies", testDependencies},
+		{"DependencyErrors", testDependencyErrors},
+		{"DependencyGraph", testDependencyGraph},
+		{"DeleteBlocker", testDeleteBlocker},
+		{"ConcurrentCreates", testConcurrentCreates},
+		{"ConcurrentUpdates", testConcurrentUpdates},
+		{"ConcurrentStatusMoves", testConcurrentStatusMoves},
+		{"ConcurrentDependencies", testConcurrentDependencies},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newRepo(t))
+		})
+	}
+}
+
+func testCreateAndGet(t *testing.T, repo repository.TaskRepository) {
+	ctx := context.Background()
+	task := &model.Task{UserID: alice, Title: "Write docs", Description: "for the API"}
+	if err := repo.Create(ctx, task); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if task.ID == 0 {
+		t.Fatal("Create didn't assign an id")
+	}
+	if task.CreatedAt.IsZero() || task.UpdatedAt.IsZero() {
+		t.Error("Create didn't set the timestamps")
+	}
+
+	got := mustGet(t, repo, alice, task.ID)
+	if got.Title != task.Title || got.Description != task.Description || got.UserID != alice {
+		t.Errorf("GetByID = %+v, want %+v", got, task)
+	}
+	if task.CreatedBy != alice || got.CreatedBy != alice {
+		t.Errorf("created by %d and stored %d, want the owner %d by default", task.CreatedBy, got.CreatedBy, alice)
+	}
+	if got.Status != model.StatusPending {
+		t.Errorf("status = %q, want the default %q", got.Status, model.StatusPending)
+	}
+	if task.Priority != model.PriorityMedium || got.Priority != model.PriorityMedium {
+		t.Errorf("priority = %q and stored %q, want the default %q", task.Priority, got.Priority, model.PriorityMedium)
+	}
+
+	other := createTask(t, repo, alice, "Second", model.StatusCompleted)
+	if other.ID == task.ID {
+		t.Errorf("both tasks got id %d", task.ID)
+	}
+	if got := mustGet(t, repo, alice, other.ID); got.Status != model.StatusCompleted {
+		t.Errorf("status = %q, want %q", got.Status, model.StatusCompleted)
+	}
+}
+
+func testGetMissing(t *testing.T, repo repository.TaskRepository) {
+	task := createTask(t, repo, alice, "Exists", "")
+	for _, id := range []string{"999999", "0", "-1", "abc", ""} {
+		got, err := repo.GetByID(context.Background(), repository.AllUsers, id)
+		if err != nil || got != nil {
+			t.Errorf("GetByID(%q) = %+v, %v, want nil, nil", id, got, err)
+		}
+	}
+	mustGet(t, repo, alice, task.ID)
+}
+
+func testOwnerScope(t *testing.T, repo repository.TaskRepository) {
+	ctx := context.Background()
+	task := createTask(t, repo, alice, "Alice's", "")
+	createTask(t, repo, bob, "Bob's", "")
+	id := idOf(task)
+
+	if got, err := repo.GetByID(ctx, bob, id); err != nil || got != nil {
+		t.Errorf("GetByID by another user = %+v, %v, want nil, nil", got, err)
+	}
+	mustGet(t, repo, repository.AllUsers, task.ID)
+
+	if _, err := repo.Update(ctx, bob, id, &model.UpdateTask{Title: "Stolen"}); !errors.Is(err, utils.NoEntryError) {
+		t.Errorf("Update by another user error = %v, want NoEntryError", err)
+	}
+	if err := repo.Delete(ctx, bob, id); !errors.Is(err, utils.NoEntryError) {
+		t.Errorf("Delete by another user error = %v, want NoEntryError", err)
+	}
+	if got := mustGet(t, repo, alice, task.ID); got.Title != "Alice's" {
+		t.Errorf("title = %q after another user's update", got.Title)
+	}
+
+	if _, total := list(t, repo, &model.TaskFilter{UserID: alice}); total != 1 {
+		t.Errorf("List for alice total = %d, want 1", total)
+	}
+	if _, total := list(t, repo, &model.TaskFilter{UserID: repository.AllUsers}); total != 2 {
+		t.Errorf("List for all users total = %d, want 2", total)
+	}
+
+	if _, err := repo.Update(ctx, repository.AllUsers, id, &model.UpdateTask{Title: "By admin"}); err != nil {
+		t.Errorf("Update for all users: %v", err)
+	}
+	if err := repo.Delete(ctx, repository.AllUsers, id); err != nil {
+		t.Errorf("Delete for all users: %v", err)
+	}
+}
+
+func testUpdate(t *testing.T, repo repository.TaskRepository) {
+	ctx := context.Background()
+	task := createTask(t, repo, alice, "Draft", "")
+
+	updated, err := repo.Update(ctx, alice, idOf(task), &model.UpdateTask{Status: model.StatusInProcess})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if updated.Status != model.StatusInProcess || updated.Title != "Draft" {
+		t.Errorf("Update = %+v, want only the status changed", updated)
+	}
+	if updated.UpdatedAt.Before(task.UpdatedAt) {
+		t.Errorf("updated_at went back from %v to %v", task.UpdatedAt, updated.UpdatedAt)
+	}
+
+	updated, err = repo.Update(ctx, alice, idOf(task), &model.UpdateTask{Title: "Final", Description: "done", Priority: model.PriorityUrgent})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if updated.Title != "Final" || updated.Description != "done" || updated.Priority != model.PriorityUrgent || updated.Status != model.StatusInProcess {
+		t.Errorf("Update = %+v, want the title, description and priority changed", updated)
+	}
+
+	got := mustGet(t, repo, alice, task.ID)
+	if got.Title != "Final" || got.Description != "done" || got.Priority != model.PriorityUrgent || got.Status != model.StatusInProcess {
+		t.Errorf("GetByID after Update = %+v", got)
+	}
+	if !got.CreatedAt.Equal(task.CreatedAt) {
+		t.Errorf("created_at changed from %v to %v", task.CreatedAt, got.CreatedAt)
+	}
+}
+
+func testUpdateFromStatus(t *testing.T, repo repository.TaskRepository) {
+	ctx := context.Background()
+	task := createTask(t, repo, alice, "Draft", model.StatusInProcess)
+
+	_, err := repo.Update(ctx, alice, idOf(task), &model.UpdateTask{Status: model.StatusCompleted, Title: "Done", FromStatus: model.StatusPending})
+	if !errors.Is(err, utils.ConflictError) {
+		t.Fatalf("Update from a stale status = %v, want ConflictError", err)
+	}
+	if got := mustGet(t, repo, alice, task.ID); got.Status != model.StatusInProcess || got.Title != "Draft" || got.CompletedAt != nil {
+		t.Errorf("task after a refused update = %+v, want it unchanged", got)
+	}
+
+	updated, err := repo.Update(ctx, alice, idOf(task), &model.UpdateTask{Status: model.StatusCompleted, Title: "Done", FromStatus: model.StatusInProcess})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if updated.Status != model.StatusCompleted || updated.Title != "Done" || updated.CompletedAt == nil {
+		t.Errorf("Update = %+v, want it completed", updated)
+	}
+}
+
+func testUpdateDates(t *testing.T, repo repository.TaskRepository) {
+	ctx := context.Background()
+	task := createTask(t, repo, alice, "Scheduled", "")
+	if task.StartAt != nil || task.DueAt != nil {
+		t.Errorf("new task has dates %v, %v", task.StartAt, task.DueAt)
+	}
+
+	startAt := date(2030, 1, 1)
+	dueAt := date(2030, 2, 1)
+	if _, err := repo.Update(ctx, alice, idOf(task), &model.UpdateTask{StartAt: &startAt, DueAt: &dueAt}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	got := mustGet(t, repo, alice, task.ID)
+	if got.StartAt == nil || !got.StartAt.Equal(startAt) || got.DueAt == nil || !got.DueAt.Equal(dueAt) {
+		t.Errorf("dates = %v, %v, want %v, %v", got.StartAt, got.DueAt, startAt, dueAt)
+	}
+
+	// other fields leave the dates alone
+	if _, err := repo.Update(ctx, alice, idOf(task), &model.UpdateTask{Title: "Rescheduled"}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if got := mustGet(t, repo, alice, task.ID); got.DueAt == nil || !got.DueAt.Equal(dueAt) {
+		t.Errorf("due_at = %v after a title update, want %v", got.DueAt, dueAt)
+	}
+}
+
+func testStatusTimestamps(t *testing.T, repo repository.TaskRepository) {
+	ctx := context.Background()
+	done := createTask(t, repo, alice, "Done already", model.StatusCompleted)
+	if got := mustGet(t, repo, alice, done.ID); got.CompletedAt == nil || got.StartedAt != nil {
+		t.Errorf("created completed task has started_at %v, completed_at %v", got.StartedAt, got.CompletedAt)
+	}
+
+	task := createTask(t, repo, alice, "Lifecycle", "")
+	if task.StartedAt != nil || task.CompletedAt != nil {
+		t.Errorf("new task has started_at %v, completed_at %v", task.StartedAt, task.CompletedAt)
+	}
+
+	if _, err := repo.Update(ctx, alice, idOf(task), &model.UpdateTask{Status: model.StatusInProcess}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	started := mustGet(t, repo, alice, task.ID).StartedAt
+	if started == nil {
+		t.Fatal("started_at not set when the task went in_process")
+	}
+
+	updated, err := repo.Update(ctx, alice, idOf(task), &model.UpdateTask{Status: model.StatusCompleted, Resolution: "shipped"})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if updated.CompletedAt == nil || updated.Resolution != "shipped" {
+		t.Errorf("completed task has completed_at %v, resolution %q", updated.CompletedAt, updated.Resolution)
+	}
+	completed := *updated.CompletedAt
+
+	// completing again keeps the original completion time
+	if _, err := repo.Update(ctx, alice, idOf(task), &model.UpdateTask{Status: model.StatusCompleted, Title: "Lifecycle done"}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if got := mustGet(t, repo, alice, task.ID); got.CompletedAt == nil || !got.CompletedAt.Equal(completed) {
+		t.Errorf("completed_at = %v after completing again, want %v", got.CompletedAt, completed)
+	}
+
+	// reopening clears completed_at but keeps when the task was first started
+	if _, err := repo.Update(ctx, alice, idOf(task), &model.UpdateTask{Status: model.StatusInProcess}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	got := mustGet(t, repo, alice, task.ID)
+	if got.CompletedAt != nil {
+		t.Errorf("completed_at = %v after reopening, want nil", got.CompletedAt)
+	}
+	if got.StartedAt == nil || !got.StartedAt.Equal(*started) {
+		t.Errorf("started_at = %v after reopening, want %v", got.StartedAt, *started)
+	}
+}
+
+func testUpdateMissing(t *testing.T, repo repository.TaskRepository) {
+	createTask(t, repo, alice, "Exists", "")
+	for _, id := range []string{"999999", "abc"} {
+		got, err := repo.Update(context.Background(), alice, id, &model.UpdateTask{Title: "New"})
+		if !errors.Is(err, utils.NoEntryError) || got != nil {
+			t.Errorf("Update(%q) = %+v, %v, want NoEntryError", id, got, err)
+		}
+	}
+}
+
+func testDelete(t *testing.T, repo repository.TaskRepository) {
+	ctx := context.Background()
+	task := createTask(t, repo, alice, "Temporary", "")
+	kept := createTask(t, repo, alice, "Kept", "")
+
+	if err := repo.Delete(ctx, alice, idOf(task)); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := repo.Delete(ctx, alice, idOf(task)); !errors.Is(err, utils.NoEntryError) {
+		t.Errorf("second Delete error = %v, want NoEntryError", err)
+	}
+	for _, id := range []string{"999999", "abc"} {
+		if err := repo.Delete(ctx, alice, id); !errors.Is(err, utils.NoEntryError) {
+			t.Errorf("Delete(%q) error = %v, want NoEntryError", id, err)
+		}
+	}
+	mustGet(t, repo, alice, kept.ID)
+}
+
+func testSoftDeleteVisibility(t *testing.T, repo repository.TaskRepository) {
+	ctx := context.Background()
+	task := createTask(t, repo, alice, "Deleted", model.StatusCompleted)
+	createTask(t, repo, alice, "Alive", model.StatusCompleted)
+	if err := repo.Delete(ctx, alice, idOf(task)); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	if got, err := repo.GetByID(ctx, repository.AllUsers, idOf(task)); err != nil || got != nil {
+		t.Errorf("GetByID of a deleted task = %+v, %v, want nil, nil", got, err)
+	}
+	if _, err := repo.Update(ctx, alice, idOf(task), &model.UpdateTask{Title: "Back"}); !errors.Is(err, utils.NoEntryError) {
+		t.Errorf("Update of a deleted task error = %v, want NoEntryError", err)
+	}
+	tasks, total := list(t, repo, &model.TaskFilter{UserID: repository.AllUsers})
+	if total != 1 || len(tasks) != 1 || tasks[0].Title != "Alive" {
+		t.Errorf("List = %d tasks of %d, want only the task that wasn't deleted", len(tasks), total)
+	}
+	counts, err := repo.CountByStatus(ctx)
+	if err != nil {
+		t.Fatalf("CountByStatus: %v", err)
+	}
+	if counts[model.StatusCompleted] != 1 {
+		t.Errorf("CountByStatus = %v, want 1 completed", counts)
+	}
+}
+
+func testFilterByStatus(t *testing.T, repo repository.TaskRepository) {
+	createTask(t, repo, alice, "One", model.StatusPending)
+	createTask(t, repo, alice, "Two", model.StatusCompleted)
+	createTask(t, repo, alice, "Three", model.StatusCompleted)
+	createTask(t, repo, bob, "Four", model.StatusCompleted)
+
+	tasks, total := list(t, repo, &model.TaskFilter{UserID: alice, Status: model.StatusCompleted})
+	if total != 2 || !sameTitles(tasks, "Two", "Three") {
+		t.Errorf("List completed = %v (total %d), want Two, Three", titles(tasks), total)
+	}
+	if _, total := list(t, repo, &model.TaskFilter{UserID: alice, Status: model.StatusInProcess}); total != 0 {
+		t.Errorf("List in_process total = %d, want 0", total)
+	}
+}
+
+func testFilterByTitle(t *testing.T, repo repository.TaskRepository) {
+	createTask(t, repo, alice, "Write docs", model.StatusPending)
+	createTask(t, repo, alice, "Review DOCS", model.StatusCompleted)
+	createTask(t, repo, alice, "Fix bug", model.StatusPending)
+
+	tests := []struct {
+		title string
+		want  []string
+	}{
+		{"docs", []string{"Write docs", "Review DOCS"}},
+		{"Docs", []string{"Write docs", "Review DOCS"}},
+		{"write", []string{"Write docs"}},
+		{"bug", []string{"Fix bug"}},
+		{"missing", nil},
+	}
+	for _, tt := range tests {
+		tasks, total := list(t, repo, &model.TaskFilter{UserID: alice, Title: tt.title})
+		if total != len(tt.want) || !sameTitles(tasks, tt.want...) {
+			t.Errorf("List title %q = %v (total %d), want %v", tt.title, titles(tasks), total, tt.want)
+		}
+	}
+
+	tasks, total := list(t, repo, &model.TaskFilter{UserID: alice, Title: "docs", Status: model.StatusPending})
+	if total != 1 || !sameTitles(tasks, "Write docs") {
+		t.Errorf("List title and status = %v (total %d), want Write docs", titles(tasks), total)
+	}
+}
+
+func testFilterByDueDate(t *testing.T, repo repository.TaskRepository) {
+	past := date(2020, 1, 1)
+	soon := date(2030, 1, 1)
+	later := date(2031, 1, 1)
+	createTaskDue(t, repo, "Missed", model.StatusPending, &past)
+	createTaskDue(t, repo, "Done late", model.StatusCompleted, &past)
+	createTaskDue(t, repo, "Soon", model.StatusInProcess, &soon)
+	createTaskDue(t, repo, "Later", model.StatusPending, &later)
+	createTaskDue(t, repo, "Whenever", model.StatusPending, nil)
+
+	beforeSoon := date(2029, 12, 31)
+	afterPast := date(2020, 1, 1)
+	// the bounds are absolute instants, the offset doesn't matter
+	afterSoon := time.Date(2030, 1, 1, 5, 30, 0, 0, time.FixedZone("IST", 5*60*60+30*60))
+
+	tests := []struct {
+		name   string
+		filter model.TaskFilter
+		want   []string
+	}{
+		{"due before", model.TaskFilter{DueBefore: &beforeSoon}, []string{"Missed", "Done late"}},
+		{"due after", model.TaskFilter{DueAfter: &afterPast}, []string{"Soon", "Later"}},
+		{"due after with offset", model.TaskFilter{DueAfter: &afterSoon}, []string{"Later"}},
+		{"due between", model.TaskFilter{DueAfter: &afterPast, DueBefore: &later}, []string{"Soon"}},
+		{"overdue", model.TaskFilter{Overdue: true}, []string{"Missed"}},
+		{"overdue with status", model.TaskFilter{Overdue: true, Status: model.StatusCompleted}, nil},
+	}
+	for _, tt := range tests {
+		filter := tt.filter
+		filter.UserID = alice
+		tasks, total := list(t, repo, &filter)
+		if total != len(tt.want) || !sameTitles(tasks, tt.want...) {
+			t.Errorf("List %s = %v (total %d), want %v", tt.name, titles(tasks), total, tt.want)
+		}
+	}
+}
+
+func testFilterByPriority(t *testing.T, repo repository.TaskRepository) {
+	createTaskWith(t, repo, &model.Task{Title: "Urgent", Priority: model.PriorityUrgent})
+	createTaskWith(t, repo, &model.Task{Title: "Default"})
+	createTaskWith(t, repo, &model.Task{Title: "Also urgent", Priority: model.PriorityUrgent, Status: model.StatusCompleted})
+
+	tasks, total := list(t, repo, &model.TaskFilter{UserID: alice, Priority: model.PriorityUrgent})
+	if total != 2 || !sameTitles(tasks, "Urgent", "Also urgent") {
+		t.Errorf("List urgent = %v (total %d), want Urgent, Also urgent", titles(tasks), total)
+	}
+	tasks, total = list(t, repo, &model.TaskFilter{UserID: alice, Priority: model.PriorityMedium})
+	if total != 1 || !sameTitles(tasks, "Default") {
+		t.Errorf("List medium = %v (total %d), want Default", titles(tasks), total)
+	}
+	tasks, total = list(t, repo, &model.TaskFilter{UserID: alice, Priority: model.PriorityUrgent, Status: model.StatusPending})
+	if total != 1 || !sameTitles(tasks, "Urgent") {
+		t.Errorf("List urgent and pending = %v (total %d), want Urgent", titles(tasks), total)
+	}
+}
+
+func testSort(t *testing.T, repo repository.TaskRepository) {
+	soon := date(2030, 1, 1)
+	later := date(2031, 1, 1)
+	createTaskWith(t, repo, &model.Task{Title: "low", Priority: model.PriorityLow, DueAt: &soon, Status: model.StatusCompleted})
+	createTaskWith(t, repo, &model.Task{Title: "High undated", Priority: model.PriorityHigh})
+	createTaskWith(t, repo, &model.Task{Title: "High later", Priority: model.PriorityHigh, DueAt: &later})
+	createTaskWith(t, repo, &model.Task{Title: "Urgent", Priority: model.PriorityUrgent, Status: model.StatusInProcess})
+	createTaskWith(t, repo, &model.Task{Title: "high soon", Priority: model.PriorityHigh, DueAt: &soon})
+	createTaskWith(t, repo, &model.Task{Title: "High undated", Priority: model.PriorityHigh})
+
+	asc := func(field model.TaskSortField) model.TaskSort { return model.TaskSort{Field: field} }
+	desc := func(field model.TaskSortField) model.TaskSort { return model.TaskSort{Field: field, Desc: true} }
+	tests := []struct {
+		name string
+		sort []model.TaskSort
+		want []string
+	}{
+		{"default", nil, []string{"low", "High undated", "High later", "Urgent", "high soon", "High undated"}},
+		{"id descending", []model.TaskSort{desc(model.SortID)}, []string{"High undated", "high soon", "Urgent", "High later", "High undated", "low"}},
+		// titles ignore case, ties are broken by id
+		{"title", []model.TaskSort{asc(model.SortTitle)}, []string{"High later", "high soon", "High undated", "High undated", "low", "Urgent"}},
+		{"title descending", []model.TaskSort{desc(model.SortTitle)}, []string{"Urgent", "low", "High undated", "High undated", "high soon", "High later"}},
+		{"priority then due date", []model.TaskSort{asc(model.SortPriority), asc(model.SortDueAt)}, []string{"Urgent", "high soon", "High later", "High undated", "High undated", "low"}},
+		{"least urgent first", []model.TaskSort{desc(model.SortPriority)}, []string{"low", "High undated", "High later", "high soon", "High undated", "Urgent"}},
+		// tasks without a due date come last in both directions
+		{"due date descending", []model.TaskSort{desc(model.SortDueAt)}, []string{"High later", "low", "high soon", "High undated", "Urgent", "High undated"}},
+		{"status then title", []model.TaskSort{asc(model.SortStatus), desc(model.SortTitle)}, []string{"low", "Urgent", "High undated", "High undated", "high soon", "High later"}},
+		{"newest first", []model.TaskSort{desc(model.SortCreatedAt), desc(model.SortID)}, []string{"High undated", "high soon", "Urgent", "High later", "High undated", "low"}},
+	}
+	for _, tt := range tests {
+		tasks, _ := list(t, repo, &model.TaskFilter{UserID: alice, Sort: tt.sort})
+		if !sameTitles(tasks, tt.want...) {
+			t.Errorf("List sorted by %s = %v, want %v", tt.name, titles(tasks), tt.want)
+			continue
+		}
+
+		// the order holds across pages
+		var paged []*model.Task
+		for page := uint(1); page <= 3; page++ {
+			tasks, _ := list(t, repo, &model.TaskFilter{UserID: alice, Sort: tt.sort, Page: page, PageSize: 2})
+			paged = append(paged, tasks...)
+		}
+		if !sameTitles(paged, tt.want...) {
+			t.Errorf("paging sorted by %s = %v, want %v", tt.name, titles(paged), tt.want)
+		}
+	}
+}
+
+// testSortNonASCII checks titles are sorted by code point after folding the
+// case, not by the rules of a locale. SQLite only folds ASCII letters so
+// uppercase letters beyond ASCII sort apart there, they're left out.
+func testSortNonASCII(t *testing.T, repo repository.TaskRepository) {
+	for _, title := range []string{"zèbre", "été", "Zoo", "ábaco", "apple", "über"} {
+		createTaskWith(t, repo, &model.Task{Title: title})
+	}
+
+	want := []string{"apple", "Zoo", "zèbre", "ábaco", "été", "über"}
+	sort := []model.TaskSort{{Field: model.SortTitle}}
+	tasks, _ := list(t, repo, &model.TaskFilter{UserID: alice, Sort: sort})
+	if !sameTitles(tasks, want...) {
+		t.Errorf("List sorted by title = %v, want %v", titles(tasks), want)
+	}
+
+	// cursors compare titles the same way
+	var paged []*model.Task
+	var after *model.Task
+	for i := 0; i < len(tasks); i++ {
+		page, _ := list(t, repo, &model.TaskFilter{UserID: alice, Sort: sort, After: after, PageSize: 2, SkipTotal: true})
+		if len(page) == 0 {
+			break
+		}
+		paged = append(paged, page...)
+		after = page[len(page)-1]
+	}
+	if !slices.Equal(ids(paged), ids(tasks)) {
+		t.Errorf("paging sorted by title = %v, want %v", titles(paged), titles(tasks))
+	}
+}
+
+func testPagination(t *testing.T, repo repository.TaskRepository) {
+	for i := 1; i <= 12; i++ {
+		createTask(t, repo, alice, fmt.Sprintf("Task %02d", i), "")
+	}
+
+	tests := []struct {
+		page, pageSize uint
+		first          string
+		count          int
+	}{
+		{0, 0, "Task 01", 10}, // defaults to the first page of 10
+		{1, 5, "Task 01", 5},
+		{2, 5, "Task 06", 5},
+		{3, 5, "Task 11", 2},
+		{4, 5, "", 0},
+		{1, 12, "Task 01", 12},
+		{1, 100, "Task 01", 12},
+		{2, 10, "Task 11", 2},
+	}
+	for _, tt := range tests {
+		tasks, total := list(t, repo, &model.TaskFilter{UserID: alice, Page: tt.page, PageSize: tt.pageSize})
+		if total != 12 {
+			t.Errorf("page %d of %d: total = %d, want 12", tt.page, tt.pageSize, total)
+		}
+		if len(tasks) != tt.count {
+			t.Errorf("page %d of %d: got %d tasks, want %d", tt.page, tt.pageSize, len(tasks), tt.count)
+			continue
+		}
+		if tt.count > 0 && tasks[0].Title != tt.first {
+			t.Errorf("page %d of %d: first task = %q, want %q", tt.page, tt.pageSize, tasks[0].Title, tt.first)
+		}
+	}
+
+	// pages don't overlap and are ordered by creation
+	seen := map[uint]bool{}
+	var last uint
+	for page := uint(1); page <= 3; page++ {
+		tasks, _ := list(t, repo, &model.TaskFilter{UserID: alice, Page: page, PageSize: 5})
+		for _, task := range tasks {
+			if seen[task.ID] {
+				t.Errorf("task %d returned on more than one page", task.ID)
+			}
+			if task.ID < last {
+				t.Errorf("task %d listed after task %d", task.ID, last)
+			}
+			seen[task.ID] = true
+			last = task.ID
+		}
+	}
+	if len(seen) != 12 {
+		t.Errorf("paging returned %d distinct tasks, want 12", len(seen))
+	}
+}
+
+func testCursor(t *testing.T, repo repository.TaskRepository) {
+	soon := date(2030, 1, 1)
+	later := date(2031, 1, 1)
+	createTaskWith(t, repo, &model.Task{Title: "b", Priority: model.PriorityLow, DueAt: &soon})
+	createTaskWith(t, repo, &model.Task{Title: "A", Priority: model.PriorityHigh})
+	createTaskWith(t, repo, &model.Task{Title: "c", Priority: model.PriorityHigh, DueAt: &later})
+	createTaskWith(t, repo, &model.Task{Title: "a", Priority: model.PriorityUrgent, Status: model.StatusCompleted})
+	createTaskWith(t, repo, &model.Task{Title: "B", Priority: model.PriorityHigh, DueAt: &soon})
+	createTaskWith(t, repo, &model.Task{Title: "d", Priority: model.PriorityHigh})
+	createTaskWith(t, repo, &model.Task{Title: "b", Priority: model.PriorityLow, DueAt: &soon, Status: model.StatusCompleted})
+
+	asc := func(field model.TaskSortField) model.TaskSort { return model.TaskSort{Field: field} }
+	desc := func(field model.TaskSortField) model.TaskSort { return model.TaskSort{Field: field, Desc: true} }
+	sorts := [][]model.TaskSort{
+		nil,
+		{desc(model.SortID)},
+		{asc(model.SortTitle)},
+		{desc(model.SortTitle), asc(model.SortStatus)},
+		{asc(model.SortPriority), asc(model.SortDueAt)},
+		{desc(model.SortDueAt), desc(model.SortPriority)},
+		{asc(model.SortDueAt), desc(model.SortStatus)},
+		{desc(model.SortCreatedAt)},
+		{asc(model.SortUpdatedAt), asc(model.SortStartAt)},
+	}
+	for _, sort := range sorts {
+		all, _ := list(t, repo, &model.TaskFilter{UserID: alice, Sort: sort, PageSize: 100})
+		want := ids(all)
+
+		// forward from the start, a nil cursor is the first page
+		var forward []uint
+		var after *model.Task
+		for i := 0; i < len(all); i++ {
+			filter := &model.TaskFilter{UserID: alice, Sort: sort, After: after, PageSize: 2, SkipTotal: true}
+			tasks, _ := list(t, repo, filter)
+			if len(tasks) == 0 {
+				break
+			}
+			forward = append(forward, ids(tasks)...)
+			after = tasks[len(tasks)-1]
+		}
+		if !slices.Equal(forward, want) {
+			t.Errorf("sorted by %v: paging forward = %v, want %v", sort, forward, want)
+		}
+
+		// backward from the last task
+		backward := []uint{all[len(all)-1].ID}
+		before := all[len(all)-1]
+		for i := 0; i < len(all); i++ {
+			tasks, _ := list(t, repo, &model.TaskFilter{UserID: alice, Sort: sort, Before: before, PageSize: 2, SkipTotal: true})
+			if len(tasks) == 0 {
+				break
+			}
+			backward = append(ids(tasks), backward...)
+			before = tasks[0]
+		}
+		if !slices.Equal(backward, want) {
+			t.Errorf("sorted by %v: paging backward = %v, want %v", sort, backward, want)
+		}
+	}
+
+	// the total is still counted on request
+	_, total := list(t, repo, &model.TaskFilter{UserID: alice, After: &model.Task{ID: 2}, PageSize: 2})
+	if total != 7 {
+		t.Errorf("total with a cursor = %d, want 7", total)
+	}
+}
+
+func testCursorStableWhileInserting(t *testing.T, repo repository.TaskRepository) {
+	for i := 1; i <= 4; i++ {
+		createTask(t, repo, alice, fmt.Sprintf("Task %d", i), "")
+	}
+	sort := []model.TaskSort{{Field: model.SortTitle}}
+	first, _ := list(t, repo, &model.TaskFilter{UserID: alice, Sort: sort, PageSize: 2})
+
+	// a task sorted on the first page would shift an offset based second page
+	createTask(t, repo, alice, "Task 0", "")
+	second, _ := list(t, repo, &model.TaskFilter{UserID: alice, Sort: sort, After: first[len(first)-1], PageSize: 2})
+	if !sameTitles(second, "Task 3", "Task 4") {
+		t.Errorf("page after %q = %v, want Task 3, Task 4", first[len(first)-1].Title, titles(second))
+	}
+}
+
+func testCountByStatus(t *testing.T, repo repository.TaskRepository) {
+	counts, err := repo.CountByStatus(context.Background())
+	if err != nil {
+		t.Fatalf("CountByStatus: %v", err)
+	}
+	if len(counts) != 0 {
+		t.Errorf("CountByStatus of an empty repository = %v", counts)
+	}
+
+	createTask(t, repo, alice, "One", model.StatusPending)
+	createTask(t, repo, alice, "Two", model.StatusInProcess)
+	createTask(t, repo, bob, "Three", model.StatusInProcess)
+
+	counts, err = repo.CountByStatus(context.Background())
+	if err != nil {
+		t.Fatalf("CountByStatus: %v", err)
+	}
+	want := map[model.TaskStatus]int{model.StatusPending: 1, model.StatusInProcess: 2}
+	for status, n := range want {
+		if counts[status] != n {
+			t.Errorf("CountByStatus[%s] = %d, want %d", status, counts[status], n)
+		}
+	}
+	if counts[model.StatusCompleted] != 0 {
+		t.Errorf("CountByStatus[completed] = %d, want 0", counts[model.StatusCompleted])
+	}
+}
+
+func testConcurrentCreates(t *testing.T, repo repository.TaskRepository) {
+	const n = 20
+	ids := make(chan uint, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			task := &model.Task{UserID: alice, Title: fmt.Sprintf("Task %d", i)}
+			if err := repo.Create(context.Background(), task); err != nil {
+				t.Errorf("Create: %v", err)
+				return
+			}
+			ids <- task.ID
+		}(i)
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := map[uint]bool{}
+	for id := range ids {
+		if seen[id] {
+			t.Errorf("id %d assigned twice", id)
+		}
+		seen[id] = true
+	}
+	if _, total := list(t, repo, &model.TaskFilter{UserID: alice}); total != n {
+		t.Errorf("List total = %d, want %d", total, n)
+	}
+}
+
+func testConcurrentUpdates(t *testing.T, repo repository.TaskRepository) {
+	task := createTask(t, repo, alice, "Contended", "")
+	statuses := []model.TaskStatus{model.StatusPending, model.StatusInProcess, model.StatusCompleted}
+
+	const n = 30
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			update := &model.UpdateTask{Title: fmt.Sprintf("Title %d", i), Status: statuses[i%len(statuses)]}
+			if _, err := repo.Update(context.Background(), alice, idOf(task), update); err != nil {
+				t.Errorf("Update: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	got := mustGet(t, repo, alice, task.ID)
+	var i int
+	if _, err := fmt.Sscanf(got.Title, "Title %d", &i); err != nil || i < 0 || i >= n {
+		t.Fatalf("title = %q, want one of the concurrent updates", got.Title)
+	}
+	if got.Status != statuses[i%len(statuses)] {
+		t.Errorf("title %q with status %q, the fields of different updates got mixed", got.Title, got.Status)
+	}
+	if _, total := list(t, repo, &model.TaskFilter{UserID: alice}); total != 1 {
+		t.Errorf("List total = %d, want 1", total)
+	}
+}
+
+// testConcurrentStatusMoves races moves out of the same status, like two users
+// starting and completing a pending task at once, only the first may pass
+func testConcurrentStatusMoves(t *testing.T, repo repository.TaskRepository) {
+	task := createTask(t, repo, alice, "Contended", model.StatusPending)
+	statuses := []model.TaskStatus{model.StatusInProcess, model.StatusCompleted}
+
+	const n = 20
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var moved []model.TaskStatus
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			update := &model.UpdateTask{Status: statuses[i%len(statuses)], Resolution: "done", FromStatus: model.StatusPending}
+			_, err := repo.Update(context.Background(), alice, idOf(task), update)
+			if err != nil {
+				if !errors.Is(err, utils.ConflictError) {
+					t.Errorf("Update: %v", err)
+				}
+				return
+			}
+			mu.Lock()
+			moved = append(moved, update.Status)
+			mu.Unlock()
+		}(i)
+	}
+	wg.Wait()
+
+	if len(moved) != 1 {
+		t.Fatalf("%d moves out of pending passed, want 1: %v", len(moved), moved)
+	}
+	if got := mustGet(t, repo, alice, task.ID); got.Status != moved[0] {
+		t.Errorf("status = %q, want %q set by the move that passed", got.Status, moved[0])
+	}
+}
+
+func createTask(t *testing.T, repo repository.TaskRepository, userID uint, title string, status model.TaskStatus) *model.Task {
+	t.Helper()
+	task := &model.Task{UserID: userID, Title: title, Status: status}
+	if err := repo.Create(context.Background(), task); err != nil {
+		t.Fatalf("Create %q: %v", title, err)
+	}
+	return task
+}
+
+func createTaskDue(t *testing.T, repo repository.TaskRepository, title string, status model.TaskStatus, dueAt *time.Time) *model.Task {
+	t.Helper()
+	return createTaskWith(t, repo, &model.Task{Title: title, Status: status, DueAt: dueAt})
+}
+
+// createTaskWith stores task for alice
+func createTaskWith(t *testing.T, repo repository.TaskRepository, task *model.Task) *model.Task {
+	t.Helper()
+	task.UserID = alice
+	if err := repo.Create(context.Background(), task); err != nil {
+		t.Fatalf("Create %q: %v", task.Title, err)
+	}
+	return task
+}
+
+// date returns midnight UTC of the given day, services store every date in UTC
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func mustGet(t *testing.T, repo repository.TaskRepository, userID, id uint) *model.Task {
+	t.Helper()
+	task, err := repo.GetByID(context.Background(), userID, strconv.FormatUint(uint64(id), 10))
+	if err != nil {
+		t.Fatalf("GetByID(%d): %v", id, err)
+	}
+	if task == nil {
+		t.Fatalf("GetByID(%d) found nothing", id)
+	}
+	return task
+}
+
+func list(t *testing.T, repo repository.TaskRepository, filter *model.TaskFilter) ([]*model.Task, int) {
+	t.Helper()
+	tasks, total, err := repo.List(context.Background(), filter)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	return tasks, total
+}
+
+func ids(tasks []*model.Task) []uint {
+	out := make([]uint, len(tasks))
+	for i, task := range tasks {
+		out[i] = task.ID
+	}
+	return out
+}
+
+func idOf(task *model.Task) string {
+	return strconv.FormatUint(uint64(task.ID), 10)
+}
+
+func titles(tasks []*model.Task) []string {
+	out := make([]string, len(tasks))
+	for i, task := range tasks {
+		out[i] = task.Title
+	}
+	return out
+}
+
+// sameTitles reports whether tasks have exactly the given titles in order
+func sameTitles(tasks []*model.Task, want ...string) bool {
+	if len(tasks) != len(want) {
+		return false
+	}
+	for i, task := range tasks {
+		if task.Title != want[i] {
+			return false
+		}
+	}
+	return true
+}
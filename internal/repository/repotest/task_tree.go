@@ -0,0 +1,183 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/repository"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+)
+
+func testCreateSubtask(t *testing.T, repo repository.TaskRepository) {
+	ctx := context.Background()
+	parent := createTask(t, repo, alice, "Release", "")
+	child := createTaskWith(t, repo, &model.Task{Title: "Tag", ParentID: &parent.ID})
+
+	got := mustGet(t, repo, alice, child.ID)
+	if got.ParentID == nil || *got.ParentID != parent.ID {
+		t.Errorf("parent_id = %v, want %d", got.ParentID, parent.ID)
+	}
+	if got := mustGet(t, repo, alice, parent.ID); got.ParentID != nil {
+		t.Errorf("top level task has parent_id %d", *got.ParentID)
+	}
+
+	bobs := createTask(t, repo, bob, "Bob's", "")
+	deleted := createTask(t, repo, alice, "Deleted", "")
+	if err := repo.Delete(ctx, alice, idOf(deleted)); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	for _, parentID := range []uint{bobs.ID, deleted.ID, 999999} {
+		err := repo.Create(ctx, &model.Task{UserID: alice, Title: "Orphan", ParentID: &parentID})
+		if !errors.Is(err, utils.InvalidInputError) {
+			t.Errorf("Create under task %d = %v, want InvalidInputError", parentID, err)
+		}
+	}
+}
+
+func testSubtree(t *testing.T, repo repository.TaskRepository) {
+	ctx := context.Background()
+	root := createTask(t, repo, alice, "Root", "")
+	other := createTask(t, repo, alice, "Other", "")
+	a := createTaskWith(t, repo, &model.Task{Title: "A", ParentID: &root.ID})
+	b := createTaskWith(t, repo, &model.Task{Title: "B", ParentID: &root.ID})
+	createTaskWith(t, repo, &model.Task{Title: "Unrelated", ParentID: &other.ID})
+	createTaskWith(t, repo, &model.Task{Title: "A1", ParentID: &a.ID})
+	deleted := createTaskWith(t, repo, &model.Task{Title: "Deleted", ParentID: &b.ID})
+	if err := repo.Delete(ctx, alice, idOf(deleted)); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	// the root doesn't have to have the lowest id
+	if _, err := repo.Move(ctx, alice, idOf(other), &b.ID); err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+
+	tasks, err := repo.Subtree(ctx, alice, idOf(root))
+	if err != nil {
+		t.Fatalf("Subtree: %v", err)
+	}
+	if !sameTitles(tasks, "Root", "Other", "A", "B", "Unrelated", "A1") {
+		t.Errorf("Subtree = %v, want the root then its descendants by id", titles(tasks))
+	}
+
+	tasks, err = repo.Subtree(ctx, alice, idOf(b))
+	if err != nil {
+		t.Fatalf("Subtree: %v", err)
+	}
+	if !sameTitles(tasks, "B", "Other", "Unrelated") {
+		t.Errorf("Subtree(B) = %v", titles(tasks))
+	}
+
+	for _, id := range []string{idOf(deleted), "999999", "abc"} {
+		if tasks, err := repo.Subtree(ctx, alice, id); err != nil || tasks != nil {
+			t.Errorf("Subtree(%q) = %v, %v, want nothing", id, titles(tasks), err)
+		}
+	}
+	if tasks, err := repo.Subtree(ctx, bob, idOf(root)); err != nil || tasks != nil {
+		t.Errorf("Subtree as bob = %v, %v, want nothing", titles(tasks), err)
+	}
+}
+
+func testMove(t *testing.T, repo repository.TaskRepository) {
+	ctx := context.Background()
+	a := createTask(t, repo, alice, "A", "")
+	b := createTaskWith(t, repo, &model.Task{Title: "B", ParentID: &a.ID})
+	c := createTaskWith(t, repo, &model.Task{Title: "C", ParentID: &b.ID})
+	d := createTask(t, repo, alice, "D", "")
+	bobs := createTask(t, repo, bob, "Bob's", "")
+
+	moved, err := repo.Move(ctx, alice, idOf(c), &d.ID)
+	if err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	if moved.ParentID == nil || *moved.ParentID != d.ID || moved.Title != "C" {
+		t.Errorf("Move = %+v, want C under D", moved)
+	}
+	if got := mustGet(t, repo, alice, c.ID); got.ParentID == nil || *got.ParentID != d.ID {
+		t.Errorf("parent_id = %v after Move, want %d", got.ParentID, d.ID)
+	}
+
+	moved, err = repo.Move(ctx, alice, idOf(b), nil)
+	if err != nil {
+		t.Fatalf("Move to the top level: %v", err)
+	}
+	if moved.ParentID != nil {
+		t.Errorf("parent_id = %d, want none", *moved.ParentID)
+	}
+
+	if _, err := repo.Move(ctx, alice, idOf(d), &b.ID); err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	// D is now under B, with C under D
+	for _, parent := range []*model.Task{b, c, d} {
+		if _, err := repo.Move(ctx, alice, idOf(b), &parent.ID); !errors.Is(err, utils.InvalidInputError) {
+			t.Errorf("Move B under %s = %v, want InvalidInputError", parent.Title, err)
+		}
+	}
+	if _, err := repo.Move(ctx, alice, idOf(a), &bobs.ID); !errors.Is(err, utils.InvalidInputError) {
+		t.Errorf("Move under bob's task = %v, want InvalidInputError", err)
+	}
+	if _, err := repo.Move(ctx, bob, idOf(a), &bobs.ID); !errors.Is(err, utils.NoEntryError) {
+		t.Errorf("Move as bob = %v, want NoEntryError", err)
+	}
+	if _, err := repo.Move(ctx, alice, "999999", nil); !errors.Is(err, utils.NoEntryError) {
+		t.Errorf("Move of a missing task = %v, want NoEntryError", err)
+	}
+}
+
+func testCountChildren(t *testing.T, repo repository.TaskRepository) {
+	ctx := context.Background()
+	parent := createTask(t, repo, alice, "Parent", "")
+	for _, status := range []model.TaskStatus{model.StatusCompleted, model.StatusCompleted, model.StatusPending} {
+		createTaskWith(t, repo, &model.Task{Title: "Child", Status: status, ParentID: &parent.ID})
+	}
+	// the count goes by category, whatever the workflow calls the status
+	createTaskWith(t, repo, &model.Task{Title: "Child", Status: "shipped", Category: model.CategoryDone, ParentID: &parent.ID})
+	deleted := createTaskWith(t, repo, &model.Task{Title: "Deleted", ParentID: &parent.ID})
+	if err := repo.Delete(ctx, alice, idOf(deleted)); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	counts, err := repo.CountChildren(ctx, parent.ID)
+	if err != nil {
+		t.Fatalf("CountChildren: %v", err)
+	}
+	want := map[model.StatusCategory]int{model.CategoryDone: 3, model.CategoryTodo: 1}
+	if !maps.Equal(counts, want) {
+		t.Errorf("CountChildren = %v, want %v", counts, want)
+	}
+	if counts, err := repo.CountChildren(ctx, deleted.ID); err != nil || len(counts) != 0 {
+		t.Errorf("CountChildren of a leaf = %v, %v", counts, err)
+	}
+}
+
+func testDeleteParent(t *testing.T, repo repository.TaskRepository) {
+	ctx := context.Background()
+	root := createTask(t, repo, alice, "Root", "")
+	middle := createTaskWith(t, repo, &model.Task{Title: "Middle", ParentID: &root.ID})
+	a := createTaskWith(t, repo, &model.Task{Title: "A", ParentID: &middle.ID})
+	b := createTaskWith(t, repo, &model.Task{Title: "B", ParentID: &middle.ID})
+
+	if err := repo.Delete(ctx, alice, idOf(middle)); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	for _, child := range []*model.Task{a, b} {
+		if got := mustGet(t, repo, alice, child.ID); got.ParentID == nil || *got.ParentID != root.ID {
+			t.Errorf("%s has parent_id %v, want it moved up to %d", got.Title, got.ParentID, root.ID)
+		}
+	}
+
+	if err := repo.Delete(ctx, alice, idOf(root)); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if got := mustGet(t, repo, alice, a.ID); got.ParentID != nil {
+		t.Errorf("parent_id = %d, want A at the top level", *got.ParentID)
+	}
+	tasks, _ := list(t, repo, &model.TaskFilter{UserID: alice})
+	if !slices.Equal(titles(tasks), []string{"A", "B"}) {
+		t.Errorf("List = %v, want the subtasks kept", titles(tasks))
+	}
+}
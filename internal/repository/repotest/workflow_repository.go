@@ -0,0 +1,266 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/repository"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+)
+
+// WorkflowRepositoryFactory returns empty repositories sharing one store, the
+// tasks of the returned task repository can use the workflows
+type WorkflowRepositoryFactory func(t *testing.T) (repository.TaskRepository, repository.WorkflowRepository)
+
+// RunWorkflowRepository runs the WorkflowRepository contract, including how tasks follow their workflow
+func RunWorkflowRepository(t *testing.T, newRepos WorkflowRepositoryFactory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, tasks repository.TaskRepository, workflows repository.WorkflowRepository)
+	}{
+		{"CRUD", testWorkflowCRUD},
+		{"UniqueNames", testWorkflowUniqueNames},
+		{"ReplaceStatuses", testWorkflowReplaceStatuses},
+		{"DeleteInUse", testWorkflowDeleteInUse},
+		{"Categories", testTaskCategories},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks, workflows := newRepos(t)
+			tt.fn(t, tasks, workflows)
+		})
+	}
+}
+
+func testWorkflowCRUD(t *testing.T, _ repository.TaskRepository, workflows repository.WorkflowRepository) {
+	ctx := context.Background()
+	review := createWorkflow(t, workflows, alice, "Review")
+	createWorkflow(t, workflows, alice, "Kanban")
+	createWorkflow(t, workflows, bob, "Editorial")
+	if review.ID == 0 || review.CreatedAt.IsZero() {
+		t.Errorf("Create = %+v, want an id and timestamps", review)
+	}
+
+	got, err := workflows.GetByID(ctx, alice, workflowID(review))
+	if err != nil || got == nil || got.Name != "Review" {
+		t.Fatalf("GetByID = %+v, %v, want Review", got, err)
+	}
+	if names := statusNames(got); !slices.Equal(names, []model.TaskStatus{"todo", "review", "shipped"}) {
+		t.Errorf("statuses = %v, want them in the order given", names)
+	}
+	if got.Statuses[1].Category != model.CategoryDoing {
+		t.Errorf("review has category %q, want doing", got.Statuses[1].Category)
+	}
+	for _, id := range []string{workflowID(review), "999999", "abc"} {
+		if got, err := workflows.GetByID(ctx, bob, id); err != nil || got != nil {
+			t.Errorf("GetByID(%q) as bob = %+v, %v, want nothing", id, got, err)
+		}
+	}
+
+	list, err := workflows.List(ctx, alice)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	var names []string
+	for _, workflow := range list {
+		names = append(names, workflow.Name)
+		if len(workflow.Statuses) != 3 {
+			t.Errorf("listed workflow %s has %d statuses, want 3", workflow.Name, len(workflow.Statuses))
+		}
+	}
+	if !slices.Equal(names, []string{"Kanban", "Review"}) {
+		t.Errorf("List = %v, want alice's workflows by name", names)
+	}
+	if all, _ := workflows.List(ctx, repository.AllUsers); len(all) != 3 {
+		t.Errorf("List(AllUsers) has %d workflows, want 3", len(all))
+	}
+
+	updated, err := workflows.Update(ctx, alice, workflowID(review), &model.UpdateWorkflow{Name: "Code review"})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if updated.Name != "Code review" || len(updated.Statuses) != 3 {
+		t.Errorf("Update = %+v, want only the name changed", updated)
+	}
+	if _, err := workflows.Update(ctx, bob, workflowID(review), &model.UpdateWorkflow{Name: "Mine"}); !errors.Is(err, utils.NoEntryError) {
+		t.Errorf("Update as bob = %v, want NoEntryError", err)
+	}
+
+	if err := workflows.Delete(ctx, bob, workflowID(review)); !errors.Is(err, utils.NoEntryError) {
+		t.Errorf("Delete as bob = %v, want NoEntryError", err)
+	}
+	if err := workflows.Delete(ctx, alice, workflowID(review)); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if got, err := workflows.GetByID(ctx, alice, workflowID(review)); err != nil || got != nil {
+		t.Errorf("GetByID after Delete = %+v, %v, want nothing", got, err)
+	}
+	if err := workflows.Delete(ctx, alice, workflowID(review)); !errors.Is(err, utils.NoEntryError) {
+		t.Errorf("second Delete = %v, want NoEntryError", err)
+	}
+}
+
+func testWorkflowUniqueNames(t *testing.T, _ repository.TaskRepository, workflows repository.WorkflowRepository) {
+	ctx := context.Background()
+	createWorkflow(t, workflows, alice, "Review")
+	kanban := createWorkflow(t, workflows, alice, "Kanban")
+	// names are unique per user only
+	createWorkflow(t, workflows, bob, "Review")
+
+	duplicate := &model.Workflow{UserID: alice, Name: "Review", Statuses: reviewStatuses()}
+	if err := workflows.Create(ctx, duplicate); !errors.Is(err, utils.ConflictError) {
+		t.Errorf("Create of a duplicate = %v, want ConflictError", err)
+	}
+	if _, err := workflows.Update(ctx, alice, workflowID(kanban), &model.UpdateWorkflow{Name: "Review"}); !errors.Is(err, utils.ConflictError) {
+		t.Errorf("rename to a taken name = %v, want ConflictError", err)
+	}
+	if _, err := workflows.Update(ctx, alice, workflowID(kanban), &model.UpdateWorkflow{Name: "Kanban"}); err != nil {
+		t.Errorf("rename to its own name = %v", err)
+	}
+}
+
+func testWorkflowReplaceStatuses(t *testing.T, tasks repository.TaskRepository, workflows repository.WorkflowRepository) {
+	ctx := context.Background()
+	review := createWorkflow(t, workflows, alice, "Review")
+	task := createTaskWith(t, tasks, &model.Task{Title: "Spec", WorkflowID: &review.ID, Status: "review", Category: model.CategoryDoing})
+	deleted := createTaskWith(t, tasks, &model.Task{Title: "Old", WorkflowID: &review.ID, Status: "todo", Category: model.CategoryTodo})
+	if err := tasks.Delete(ctx, alice, idOf(deleted)); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	// dropping a status a task has is refused and changes nothing
+	dropped := []model.WorkflowStatus{{Name: "todo", Category: model.CategoryTodo}, {Name: "shipped", Category: model.CategoryDone}}
+	if _, err := workflows.Update(ctx, alice, workflowID(review), &model.UpdateWorkflow{Statuses: &dropped}); !errors.Is(err, utils.ConflictError) {
+		t.Errorf("Update dropping a used status = %v, want ConflictError", err)
+	}
+	if got, _ := workflows.GetByID(ctx, alice, workflowID(review)); got == nil || len(got.Statuses) != 3 {
+		t.Errorf("statuses after a refused update = %+v, want them unchanged", got)
+	}
+
+	// deleted tasks don't hold on to their status
+	replaced := []model.WorkflowStatus{{Name: "open", Category: model.CategoryTodo}, {Name: "review", Category: model.CategoryDone}}
+	updated, err := workflows.Update(ctx, alice, workflowID(review), &model.UpdateWorkflow{Statuses: &replaced})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if names := statusNames(updated); !slices.Equal(names, []model.TaskStatus{"open", "review"}) {
+		t.Errorf("statuses = %v, want open, review", names)
+	}
+	got := mustGet(t, tasks, alice, task.ID)
+	if got.Category != model.CategoryDone || got.Status != "review" || got.CompletedAt == nil {
+		t.Errorf("task is %s (%s) completed at %v, want review moved to done", got.Status, got.Category, got.CompletedAt)
+	}
+
+	// and back out of done
+	replaced[1].Category = model.CategoryDoing
+	replaced = append(replaced, model.WorkflowStatus{Name: "shipped", Category: model.CategoryDone})
+	if _, err := workflows.Update(ctx, alice, workflowID(review), &model.UpdateWorkflow{Statuses: &replaced}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if got := mustGet(t, tasks, alice, task.ID); got.Category != model.CategoryDoing || got.CompletedAt != nil {
+		t.Errorf("task is %s completed at %v, want review moved back to doing", got.Category, got.CompletedAt)
+	}
+}
+
+func testWorkflowDeleteInUse(t *testing.T, tasks repository.TaskRepository, workflows repository.WorkflowRepository) {
+	ctx := context.Background()
+	review := createWorkflow(t, workflows, alice, "Review")
+	task := createTaskWith(t, tasks, &model.Task{Title: "Spec", WorkflowID: &review.ID, Status: "todo", Category: model.CategoryTodo})
+
+	if err := workflows.Delete(ctx, alice, workflowID(review)); !errors.Is(err, utils.ConflictError) {
+		t.Errorf("Delete of a workflow in use = %v, want ConflictError", err)
+	}
+	if err := tasks.Delete(ctx, alice, idOf(task)); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := workflows.Delete(ctx, alice, workflowID(review)); err != nil {
+		t.Errorf("Delete once its tasks are deleted = %v", err)
+	}
+}
+
+func testTaskCategories(t *testing.T, tasks repository.TaskRepository, workflows repository.WorkflowRepository) {
+	ctx := context.Background()
+	review := createWorkflow(t, workflows, alice, "Review")
+	past := time.Now().Add(-time.Hour)
+	pending := createTaskDue(t, tasks, "Pending", model.StatusPending, &past)
+	createTaskDue(t, tasks, "Completed", model.StatusCompleted, &past)
+	shipped := createTaskWith(t, tasks, &model.Task{Title: "Shipped", WorkflowID: &review.ID, Status: "shipped", Category: model.CategoryDone, DueAt: &past})
+	inReview := createTaskWith(t, tasks, &model.Task{Title: "In review", WorkflowID: &review.ID, Status: "review", Category: model.CategoryDoing})
+
+	got := mustGet(t, tasks, alice, shipped.ID)
+	if got.WorkflowID == nil || *got.WorkflowID != review.ID || got.CompletedAt == nil {
+		t.Errorf("shipped task = %+v, want the workflow and completed_at set", got)
+	}
+	if got := mustGet(t, tasks, alice, pending.ID); got.Category != model.CategoryTodo {
+		t.Errorf("built-in pending has category %q, want todo", got.Category)
+	}
+
+	done, _ := list(t, tasks, &model.TaskFilter{UserID: alice, Category: model.CategoryDone})
+	if !sameTitles(done, "Completed", "Shipped") {
+		t.Errorf("done tasks = %v, want Completed and Shipped", titles(done))
+	}
+	overdue, _ := list(t, tasks, &model.TaskFilter{UserID: alice, Overdue: true})
+	if !sameTitles(overdue, "Pending") {
+		t.Errorf("overdue tasks = %v, want only Pending", titles(overdue))
+	}
+	byStatus, _ := list(t, tasks, &model.TaskFilter{UserID: alice, Status: "review"})
+	if !sameTitles(byStatus, "In review") {
+		t.Errorf("tasks in review = %v, want In review", titles(byStatus))
+	}
+
+	// a blocker in a done status of its workflow doesn't block
+	if err := tasks.AddDependency(ctx, alice, idOf(pending), shipped.ID); err != nil {
+		t.Fatalf("AddDependency: %v", err)
+	}
+	if got := mustGet(t, tasks, alice, pending.ID); got.Blocked {
+		t.Error("task waiting for a shipped task is blocked")
+	}
+	if err := tasks.AddDependency(ctx, alice, idOf(pending), inReview.ID); err != nil {
+		t.Fatalf("AddDependency: %v", err)
+	}
+	if got := mustGet(t, tasks, alice, pending.ID); !got.Blocked {
+		t.Error("task waiting for a task in review isn't blocked")
+	}
+
+	counts, err := tasks.CountByCategory(ctx)
+	if err != nil {
+		t.Fatalf("CountByCategory: %v", err)
+	}
+	if counts[model.CategoryDone] != 2 || counts[model.CategoryDoing] != 1 || counts[model.CategoryTodo] != 1 {
+		t.Errorf("CountByCategory = %v, want 2 done, 1 doing and 1 todo", counts)
+	}
+}
+
+func createWorkflow(t *testing.T, workflows repository.WorkflowRepository, userID uint, name string) *model.Workflow {
+	t.Helper()
+	workflow := &model.Workflow{UserID: userID, Name: name, Statuses: reviewStatuses()}
+	if err := workflows.Create(context.Background(), workflow); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return workflow
+}
+
+func reviewStatuses() []model.WorkflowStatus {
+	return []model.WorkflowStatus{
+		{Name: "todo", Category: model.CategoryTodo},
+		{Name: "review", Category: model.CategoryDoing},
+		{Name: "shipped", Category: model.CategoryDone},
+	}
+}
+
+func workflowID(workflow *model.Workflow) string {
+	return strconv.FormatUint(uint64(workflow.ID), 10)
+}
+
+func statusNames(workflow *model.Workflow) []model.TaskStatus {
+	var names []model.TaskStatus
+	for _, status := range workflow.Statuses {
+		names = append(names, status.Name)
+	}
+	return names
+}
@@ -0,0 +1,34 @@
+package sqlite
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/akhilbidhuri/taskkr/internal/config"
+	"github.com/akhilbidhuri/taskkr/internal/repository/gormrepo"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// pragmas applied to every connection, foreign keys are off by default in SQLite
+const pragmas = "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+
+// NewSQLiteDB opens the SQLite database file at cfg.SQLitePath with a pure Go driver
+func NewSQLiteDB(cfg *config.Config) *gorm.DB {
+	db, err := gormrepo.Open(sqlite.Open(cfg.SQLitePath+pragmas), cfg)
+	if err != nil {
+		slog.Error("failed to connect database", "error", err)
+		os.Exit(1)
+	}
+
+	// SQLite allows a single writer, one connection avoids "database is locked" errors
+	sqlDB, err := db.DB()
+	if err != nil {
+		slog.Error("failed to get database handle", "error", err)
+		os.Exit(1)
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	return db
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/akhilbidhuri/taskkr/internal/auth"
+	"github.com/akhilbidhuri/taskkr/internal/logger"
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/repository"
+	"github.com/akhilbidhuri/taskkr/internal/tracing"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+)
+
+// apiKeyPrefixLen is how much of the key is kept in clear to identify it
+const apiKeyPrefixLen = 11
+
+type APIKeyService struct {
+	repo repository.APIKeyRepository
+	now  func() time.Time
+}
+
+func NewAPIKeyService(repo repository.APIKeyRepository) *APIKeyService {
+	return &APIKeyService{repo: repo, now: time.Now}
+}
+
+// Issue creates a new key, the plain key is only part of the returned value
+func (s *APIKeyService) Issue(ctx context.Context, req *model.CreateAPIKey) (*model.IssuedAPIKey, error) {
+	ctx, span := tracing.Start(ctx, "APIKeyService.Issue")
+	defer span.End()
+
+	if _, err := authorize(ctx, auth.PermAPIKeyManage); err != nil {
+		return nil, err
+	}
+	if req.Name == "" {
+		return nil, fmt.Errorf("%w: name cannot be empty", utils.InvalidInputError)
+	}
+	if req.UserID == 0 {
+		return nil, fmt.Errorf("%w: user_id cannot be empty", utils.InvalidInputError)
+	}
+	if req.Role == "" {
+		req.Role = string(auth.RoleMember)
+	}
+	if !auth.Role(req.Role).Valid() {
+		return nil, fmt.Errorf("%w: invalid role %q", utils.InvalidInputError, req.Role)
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(s.now()) {
+		return nil, fmt.Errorf("%w: expires_at must be in the future", utils.InvalidInputError)
+	}
+
+	raw, err := auth.GenerateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	key := model.APIKey{
+		Name:      req.Name,
+		Prefix:    raw[:apiKeyPrefixLen],
+		KeyHash:   auth.HashAPIKey(raw),
+		UserID:    req.UserID,
+		Role:      req.Role,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := s.repo.Create(ctx, &key); err != nil {
+		return nil, err
+	}
+	logger.FromContext(ctx).Info("api key issued", "api_key_id", key.ID, "prefix", key.Prefix, "for_user_id", key.UserID, "role", key.Role)
+	return &model.IssuedAPIKey{APIKey: key, Key: raw}, nil
+}
+
+func (s *APIKeyService) List(ctx context.Context) ([]*model.APIKey, error) {
+	ctx, span := tracing.Start(ctx, "APIKeyService.List")
+	defer span.End()
+
+	if _, err := authorize(ctx, auth.PermAPIKeyManage); err != nil {
+		return nil, err
+	}
+	return s.repo.List(ctx)
+}
+
+func (s *APIKeyService) Revoke(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "APIKeyService.Revoke")
+	defer span.End()
+
+	if _, err := authorize(ctx, auth.PermAPIKeyManage); err != nil {
+		return err
+	}
+	if err := s.repo.Revoke(ctx, id, s.now()); err != nil {
+		return err
+	}
+	logger.FromContext(ctx).Info("api key revoked", "api_key_id", id)
+	return nil
+}
+
+// Authenticate implements auth.KeyAuthenticator, every successful call is recorded as a usage of the key
+func (s *APIKeyService) Authenticate(ctx context.Context, raw string) (*auth.Identity, error) {
+	ctx, span := tracing.Start(ctx, "APIKeyService.Authenticate")
+	defer span.End()
+
+	key, err := s.repo.GetByHash(ctx, auth.HashAPIKey(raw))
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	if key == nil {
+		return nil, auth.ErrInvalidAPIKey
+	}
+	if key.RevokedAt != nil {
+		logger.FromContext(ctx).Warn("revoked api key used", "api_key_id", key.ID)
+		return nil, auth.ErrInvalidAPIKey
+	}
+	if key.ExpiresAt != nil && !key.ExpiresAt.After(now) {
+		logger.FromContext(ctx).Warn("expired api key used", "api_key_id", key.ID)
+		return nil, fmt.Errorf("%w: key expired", auth.ErrInvalidAPIKey)
+	}
+	if err := s.repo.RecordUsage(ctx, key.ID, now); err != nil {
+		return nil, err
+	}
+	return &auth.Identity{
+		UserID:  key.UserID,
+		Subject: "api_key:" + strconv.FormatUint(uint64(key.ID), 10),
+		Role:    auth.Role(key.Role),
+	}, nil
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/akhilbidhuri/taskkr/internal/auth"
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/repository"
+	"github.com/akhilbidhuri/taskkr/internal/repository/memory"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+)
+
+var adminCtx = auth.NewContext(context.Background(), &auth.Identity{UserID: 1, Subject: "1", Role: auth.RoleAdmin})
+
+// newAPIKeyService returns a service whose clock is moved with the returned function
+func newAPIKeyService(repo repository.APIKeyRepository) (*APIKeyService, func(time.Duration)) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	s := NewAPIKeyService(repo)
+	s.now = func() time.Time { return now }
+	return s, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestAPIKeyServiceIssue(t *testing.T) {
+	s, _ := newAPIKeyService(memory.NewAPIKeyRepository(memory.NewDB()))
+	member := auth.NewContext(context.Background(), &auth.Identity{UserID: 2, Subject: "2", Role: auth.RoleMember})
+	past := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		req  model.CreateAPIKey
+		err  error
+	}{
+		{name: "as a member", ctx: member, req: model.CreateAPIKey{Name: "batch", UserID: 2}, err: utils.ForbiddenError},
+		{name: "anonymous", ctx: context.Background(), req: model.CreateAPIKey{Name: "batch", UserID: 2}, err: utils.UnauthenticatedError},
+		{name: "missing name", ctx: adminCtx, req: model.CreateAPIKey{UserID: 2}, err: utils.InvalidInputError},
+		{name: "missing user", ctx: adminCtx, req: model.CreateAPIKey{Name: "batch"}, err: utils.InvalidInputError},
+		{name: "unknown role", ctx: adminCtx, req: model.CreateAPIKey{Name: "batch", UserID: 2, Role: "root"}, err: utils.InvalidInputError},
+		{name: "expired", ctx: adminCtx, req: model.CreateAPIKey{Name: "batch", UserID: 2, ExpiresAt: &past}, err: utils.InvalidInputError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.Issue(tt.ctx, &tt.req); !errors.Is(err, tt.err) {
+				t.Errorf("Issue() = %v, want %v", err, tt.err)
+			}
+		})
+	}
+
+	issued, err := s.Issue(adminCtx, &model.CreateAPIKey{Name: "batch", UserID: 2})
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if !auth.IsAPIKey(issued.Key) || issued.Prefix != issued.Key[:apiKeyPrefixLen] || issued.Role != string(auth.RoleMember) {
+		t.Errorf("Issue = %+v, want a member key starting with its prefix", issued)
+	}
+	if issued.KeyHash != auth.HashAPIKey(issued.Key) {
+		t.Errorf("KeyHash = %q, want the hash of the key", issued.KeyHash)
+	}
+}
+
+func TestAPIKeyServiceAuthenticate(t *testing.T) {
+	repo := memory.NewAPIKeyRepository(memory.NewDB())
+	s, advance := newAPIKeyService(repo)
+	expiresAt := time.Date(2026, 1, 2, 4, 0, 0, 0, time.UTC)
+	issued, err := s.Issue(adminCtx, &model.CreateAPIKey{Name: "reports", UserID: 7, Role: string(auth.RoleReadOnly), ExpiresAt: &expiresAt})
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		identity, err := s.Authenticate(context.Background(), issued.Key)
+		if err != nil {
+			t.Fatalf("Authenticate: %v", err)
+		}
+		want := auth.Identity{UserID: 7, Subject: "api_key:" + strconv.FormatUint(uint64(issued.ID), 10), Role: auth.RoleReadOnly}
+		if *identity != want {
+			t.Errorf("Authenticate = %+v, want %+v", identity, want)
+		}
+	}
+	key, _ := repo.GetByHash(context.Background(), issued.KeyHash)
+	if key.UsageCount != 2 || key.LastUsedAt == nil {
+		t.Errorf("usage = %d at %v, want 2 recorded uses", key.UsageCount, key.LastUsedAt)
+	}
+
+	if _, err := s.Authenticate(context.Background(), "tk_unknown"); !errors.Is(err, auth.ErrInvalidAPIKey) {
+		t.Errorf("Authenticate(unknown) = %v, want ErrInvalidAPIKey", err)
+	}
+
+	advance(time.Hour)
+	if _, err := s.Authenticate(context.Background(), issued.Key); !errors.Is(err, auth.ErrInvalidAPIKey) {
+		t.Errorf("Authenticate(expired) = %v, want ErrInvalidAPIKey", err)
+	}
+	if key, _ := repo.GetByHash(context.Background(), issued.KeyHash); key.UsageCount != 2 {
+		t.Errorf("usage = %d, want the expired use not recorded", key.UsageCount)
+	}
+}
+
+func TestAPIKeyServiceRevoke(t *testing.T) {
+	s, _ := newAPIKeyService(memory.NewAPIKeyRepository(memory.NewDB()))
+	issued, err := s.Issue(adminCtx, &model.CreateAPIKey{Name: "batch", UserID: 2})
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	id := strconv.FormatUint(uint64(issued.ID), 10)
+
+	if err := s.Revoke(adminCtx, id); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if _, err := s.Authenticate(context.Background(), issued.Key); !errors.Is(err, auth.ErrInvalidAPIKey) {
+		t.Errorf("Authenticate(revoked) = %v, want ErrInvalidAPIKey", err)
+	}
+	for _, id := range []string{id, "999", "abc"} {
+		if err := s.Revoke(adminCtx, id); !errors.Is(err, utils.NoEntryError) {
+			t.Errorf("Revoke(%q) = %v, want NoEntryError", id, err)
+		}
+	}
+}
+
+// failingKeyRepository fails every lookup like an unreachable database
+type failingKeyRepository struct {
+	repository.APIKeyRepository
+}
+
+func (failingKeyRepository) GetByHash(context.Context, string) (*model.APIKey, error) {
+	return nil, errors.New("dial tcp 10.0.0.5:5432: connection refused")
+}
+
+func TestAPIKeyServiceAuthenticateFailure(t *testing.T) {
+	s, _ := newAPIKeyService(failingKeyRepository{})
+	_, err := s.Authenticate(context.Background(), "tk_key")
+	if err == nil || errors.Is(err, auth.ErrInvalidAPIKey) {
+		t.Errorf("Authenticate = %v, want the storage error, not an invalid key", err)
+	}
+}
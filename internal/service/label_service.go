@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/akhilbidhuri/taskkr/internal/auth"
+	"github.com/akhilbidhuri/taskkr/internal/logger"
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/repository"
+	"github.com/akhilbidhuri/taskkr/internal/tracing"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+)
+
+// maxLabelName is the size of the labels.name column
+const maxLabelName = 50
+
+var labelColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// LabelService manages the labels of a user, labels are attached to tasks through TaskService
+type LabelService struct {
+	repo repository.LabelRepository
+}
+
+func NewLabelService(repo repository.LabelRepository) *LabelService {
+	return &LabelService{repo: repo}
+}
+
+func (s *LabelService) Create(ctx context.Context, label *model.Label) error {
+	ctx, span := tracing.Start(ctx, "LabelService.Create")
+	defer span.End()
+
+	identity, err := authorize(ctx, auth.PermTaskWrite)
+	if err != nil {
+		return err
+	}
+	name, err := labelName(label.Name)
+	if err != nil {
+		return err
+	}
+	if err := validateColor(label.Color); err != nil {
+		return err
+	}
+	label.ID = 0
+	label.UserID = identity.UserID
+	label.Name = name
+	label.CreatedAt, label.UpdatedAt = time.Time{}, time.Time{}
+	if err := s.repo.Create(ctx, label); err != nil {
+		return err
+	}
+	logger.FromContext(ctx).Info("label created", "label_id", label.ID)
+	return nil
+}
+
+func (s *LabelService) GetByID(ctx context.Context, id string) (*model.Label, error) {
+	ctx, span := tracing.Start(ctx, "LabelService.GetByID")
+	defer span.End()
+
+	userID, err := ownerScope(ctx, auth.PermTaskRead, auth.PermTaskReadAll)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, userID, id)
+}
+
+func (s *LabelService) List(ctx context.Context) ([]*model.Label, error) {
+	ctx, span := tracing.Start(ctx, "LabelService.List")
+	defer span.End()
+
+	userID, err := ownerScope(ctx, auth.PermTaskRead, auth.PermTaskReadAll)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.List(ctx, userID)
+}
+
+func (s *LabelService) Update(ctx context.Context, id string, label *model.UpdateLabel) (*model.Label, error) {
+	ctx, span := tracing.Start(ctx, "LabelService.Update")
+	defer span.End()
+
+	userID, err := ownerScope(ctx, auth.PermTaskWrite, auth.PermTaskWriteAll)
+	if err != nil {
+		return nil, err
+	}
+	if label.Name != "" {
+		if label.Name, err = labelName(label.Name); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateColor(label.Color); err != nil {
+		return nil, err
+	}
+	return s.repo.Update(ctx, userID, id, label)
+}
+
+// Delete removes the label and takes it off every task
+func (s *LabelService) Delete(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "LabelService.Delete")
+	defer span.End()
+
+	userID, err := ownerScope(ctx, auth.PermTaskWrite, auth.PermTaskWriteAll)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, userID, id); err != nil {
+		return err
+	}
+	logger.FromContext(ctx).Info("label deleted", "label_id", id)
+	return nil
+}
+
+// labelName trims the name and checks it fits the column and a comma separated filter
+func labelName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		return "", fmt.Errorf("%w: label name cannot be empty", utils.InvalidInputError)
+	case utf8.RuneCountInString(name) > maxLabelName:
+		return "", fmt.Errorf("%w: label name cannot be longer than %d characters", utils.InvalidInputError, maxLabelName)
+	case strings.Contains(name, ","):
+		return "", fmt.Errorf("%w: label name cannot contain a comma", utils.InvalidInputError)
+	}
+	return name, nil
+}
+
+// labelNames trims the label names given for a task
+func labelNames(names []string) ([]string, error) {
+	trimmed := make([]string, len(names))
+	for i, name := range names {
+		var err error
+		if trimmed[i], err = labelName(name); err != nil {
+			return nil, err
+		}
+	}
+	return trimmed, nil
+}
+
+func validateColor(color string) error {
+	if color != "" && !labelColor.MatchString(color) {
+		return fmt.Errorf("%w: color must be like #d73a4a", utils.InvalidInputError)
+	}
+	return nil
+}
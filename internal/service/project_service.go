@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/akhilbidhuri/taskkr/internal/auth"
+	"github.com/akhilbidhuri/taskkr/internal/logger"
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/repository"
+	"github.com/akhilbidhuri/taskkr/internal/tracing"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+)
+
+// maxProjectName is the size of the projects.name column
+const maxProjectName = 100
+
+// ProjectService manages projects and their members. Members see a project and
+// its tasks, only the owner changes the project and who is a member.
+type ProjectService struct {
+	repo repository.ProjectRepository
+}
+
+func NewProjectService(repo repository.ProjectRepository) *ProjectService {
+	return &ProjectService{repo: repo}
+}
+
+func (s *ProjectService) Create(ctx context.Context, project *model.Project) error {
+	ctx, span := tracing.Start(ctx, "ProjectService.Create")
+	defer span.End()
+
+	identity, err := authorize(ctx, auth.PermTaskWrite)
+	if err != nil {
+		return err
+	}
+	name, err := projectName(project.Name)
+	if err != nil {
+		return err
+	}
+	project.ID = 0
+	project.UserID = identity.UserID
+	project.Name = name
+	project.CreatedAt, project.UpdatedAt = time.Time{}, time.Time{}
+	if err := s.repo.Create(ctx, project); err != nil {
+		return err
+	}
+	logger.FromContext(ctx).Info("project created", "project_id", project.ID)
+	return nil
+}
+
+func (s *ProjectService) GetByID(ctx context.Context, id string) (*model.Project, error) {
+	ctx, span := tracing.Start(ctx, "ProjectService.GetByID")
+	defer span.End()
+
+	userID, err := ownerScope(ctx, auth.PermTaskRead, auth.PermTaskReadAll)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, userID, id)
+}
+
+// List returns the projects the caller is a member of
+func (s *ProjectService) List(ctx context.Context) ([]*model.Project, error) {
+	ctx, span := tracing.Start(ctx, "ProjectService.List")
+	defer span.End()
+
+	userID, err := ownerScope(ctx, auth.PermTaskRead, auth.PermTaskReadAll)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.List(ctx, userID)
+}
+
+func (s *ProjectService) Update(ctx context.Context, id string, project *model.UpdateProject) (*model.Project, error) {
+	ctx, span := tracing.Start(ctx, "ProjectService.Update")
+	defer span.End()
+
+	userID, err := s.manage(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if project.Name != "" {
+		if project.Name, err = projectName(project.Name); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.Update(ctx, userID, id, project)
+}
+
+// Delete removes a project without tasks
+func (s *ProjectService) Delete(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "ProjectService.Delete")
+	defer span.End()
+
+	userID, err := s.manage(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, userID, id); err != nil {
+		return err
+	}
+	logger.FromContext(ctx).Info("project deleted", "project_id", id)
+	return nil
+}
+
+func (s *ProjectService) Members(ctx context.Context, id string) ([]*model.ProjectMember, error) {
+	ctx, span := tracing.Start(ctx, "ProjectService.Members")
+	defer span.End()
+
+	userID, err := ownerScope(ctx, auth.PermTaskRead, auth.PermTaskReadAll)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.repo.Members(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if members == nil {
+		return nil, utils.NoEntryError
+	}
+	return members, nil
+}
+
+// AddMember lets the user see the project and its tasks
+func (s *ProjectService) AddMember(ctx context.Context, id string, memberID uint) (*model.ProjectMember, error) {
+	ctx, span := tracing.Start(ctx, "ProjectService.AddMember")
+	defer span.End()
+
+	userID, err := s.manage(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if memberID == 0 {
+		return nil, fmt.Errorf("%w: user_id is required", utils.InvalidInputError)
+	}
+	member := &model.ProjectMember{UserID: memberID, Role: model.RoleMember}
+	if err := s.repo.AddMember(ctx, userID, id, member); err != nil {
+		return nil, err
+	}
+	logger.FromContext(ctx).Info("project member added", "project_id", id, "member_id", memberID)
+	return member, nil
+}
+
+// RemoveMember takes the user out of the project, the tasks they own in it stay theirs
+func (s *ProjectService) RemoveMember(ctx context.Context, id string, memberID uint) error {
+	ctx, span := tracing.Start(ctx, "ProjectService.RemoveMember")
+	defer span.End()
+
+	userID, err := s.manage(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.RemoveMember(ctx, userID, id, memberID); err != nil {
+		return err
+	}
+	logger.FromContext(ctx).Info("project member removed", "project_id", id, "member_id", memberID)
+	return nil
+}
+
+// manage returns the scope the caller changes the project with, members
+// who don't own it are forbidden instead of not finding it
+func (s *ProjectService) manage(ctx context.Context, id string) (uint, error) {
+	userID, err := ownerScope(ctx, auth.PermTaskWrite, auth.PermTaskWriteAll)
+	if err != nil {
+		return 0, err
+	}
+	project, err := s.repo.GetByID(ctx, userID, id)
+	if err != nil {
+		return 0, err
+	}
+	if project == nil {
+		return 0, utils.NoEntryError
+	}
+	if userID != repository.AllUsers && project.UserID != userID {
+		return 0, fmt.Errorf("%w: only the owner of the project can change it", utils.ForbiddenError)
+	}
+	return userID, nil
+}
+
+// projectName trims the name and checks it fits the column
+func projectName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		return "", fmt.Errorf("%w: project name cannot be empty", utils.InvalidInputError)
+	case utf8.RuneCountInString(name) > maxProjectName:
+		return "", fmt.Errorf("%w: project name cannot be longer than %d characters", utils.InvalidInputError, maxProjectName)
+	}
+	return name, nil
+}
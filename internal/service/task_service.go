@@ -2,40 +2,598 @@ package service
 
 import (
 	"context"
-	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/akhilbidhuri/taskkr/internal/auth"
+	"github.com/akhilbidhuri/taskkr/internal/logger"
 	"github.com/akhilbidhuri/taskkr/internal/repository"
+	"github.com/akhilbidhuri/taskkr/internal/tracing"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
 
 	"github.com/akhilbidhuri/taskkr/internal/model"
 )
 
+// defaultPageSize matches the page size the repositories default to
+const defaultPageSize = 10
+
+// TaskOptions holds the configurable rules of a TaskService
+type TaskOptions struct {
+	// RequireChildrenCompleted refuses to complete a task while one of its subtasks isn't
+	RequireChildrenCompleted bool
+	// Transitions lists the allowed status changes, nil uses DefaultTransitions
+	Transitions []TaskTransition
+}
+
 type TaskService struct {
-	repo repository.TaskRepository
+	repo      repository.TaskRepository
+	workflows repository.WorkflowRepository
+	projects  repository.ProjectRepository
+	opts      TaskOptions
 }
 
-func NewTaskService(repo repository.TaskRepository) *TaskService {
-	return &TaskService{repo: repo}
+func NewTaskService(repo repository.TaskRepository, workflows repository.WorkflowRepository, projects repository.ProjectRepository, opts TaskOptions) *TaskService {
+	return &TaskService{repo: repo, workflows: workflows, projects: projects, opts: opts}
 }
 
 func (s *TaskService) Create(ctx context.Context, task *model.Task) error {
+	ctx, span := tracing.Start(ctx, "TaskService.Create")
+	defer span.End()
+
+	identity, err := authorize(ctx, auth.PermTaskWrite)
+	if err != nil {
+		return err
+	}
 	if task.Title == "" {
-		return errors.New("title cannot be empty")
+		return fmt.Errorf("%w: title cannot be empty", utils.InvalidInputError)
+	}
+	task.StartAt, task.DueAt = utc(task.StartAt), utc(task.DueAt)
+	if err := validateDates(task.StartAt, task.DueAt); err != nil {
+		return err
+	}
+	if task.Labels, err = labelNames(task.Labels); err != nil {
+		return err
+	}
+	if task.ProjectID != nil {
+		if _, err := s.project(ctx, identity.UserID, *task.ProjectID); err != nil {
+			return err
+		}
+	}
+	workflow, err := s.workflow(ctx, identity.UserID, task.WorkflowID)
+	if err != nil {
+		return err
+	}
+	task.Resolution = strings.TrimSpace(task.Resolution)
+	if task.Status == "" {
+		task.Status = workflow.InitialStatus()
+	}
+	if task.Category, err = statusCategory(workflow, task.Status); err != nil {
+		return err
+	}
+	// a task created in another status moves there from the initial one, it has
+	// no subtasks or dependencies yet so only the transition rules apply
+	initial := &model.Task{WorkflowID: task.WorkflowID, Status: workflow.InitialStatus(), Category: model.CategoryTodo}
+	move := &model.UpdateTask{Status: task.Status, Category: task.Category, Resolution: task.Resolution}
+	if err := s.checkTransition(initial, move); err != nil {
+		return err
+	}
+	// the id, owner, creator, assignees and timestamps are never taken from the caller
+	task.ID = 0
+	task.UserID = identity.UserID
+	task.CreatedBy = identity.UserID
+	task.Assignees = nil
+	task.CreatedAt, task.UpdatedAt = time.Time{}, time.Time{}
+	task.StartedAt, task.CompletedAt = nil, nil
+	if err := s.repo.Create(ctx, task); err != nil {
+		return err
+	}
+	logger.FromContext(ctx).Info("task created", "task_id", task.ID)
+	return nil
+}
+
+// CreateInProject creates the task in the project, the caller must be a member of it
+func (s *TaskService) CreateInProject(ctx context.Context, projectID string, task *model.Task) error {
+	ctx, span := tracing.Start(ctx, "TaskService.CreateInProject")
+	defer span.End()
+
+	identity, err := authorize(ctx, auth.PermTaskWrite)
+	if err != nil {
+		return err
+	}
+	project, err := s.projects.GetByID(ctx, identity.UserID, projectID)
+	if err != nil {
+		return err
+	}
+	if project == nil {
+		return utils.NoEntryError
 	}
-	return s.repo.Create(ctx, task)
+	task.ProjectID = &project.ID
+	return s.Create(ctx, task)
 }
 
 func (s *TaskService) GetByID(ctx context.Context, id string) (*model.Task, error) {
-	return s.repo.GetByID(ctx, id)
+	ctx, span := tracing.Start(ctx, "TaskService.GetByID")
+	defer span.End()
+
+	userID, err := ownerScope(ctx, auth.PermTaskRead, auth.PermTaskReadAll)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, userID, id)
 }
 
-func (s *TaskService) List(ctx context.Context, filter *model.TaskFilter) ([]*model.Task, int, error) {
-	return s.repo.List(ctx, filter)
+// List returns a page of the tasks matching filter, with an offset page the total is always counted
+func (s *TaskService) List(ctx context.Context, filter *model.TaskFilter) (*model.TaskPage, error) {
+	ctx, span := tracing.Start(ctx, "TaskService.List")
+	defer span.End()
+
+	userID, err := ownerScope(ctx, auth.PermTaskRead, auth.PermTaskReadAll)
+	if err != nil {
+		return nil, err
+	}
+	filter.UserID = userID
+
+	if filter.After == nil && filter.Before == nil {
+		filter.SkipTotal = false
+		tasks, total, err := s.repo.List(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		return &model.TaskPage{
+			Tasks:   tasks,
+			Total:   &total,
+			HasNext: int((filter.Page-1)*filter.PageSize)+len(tasks) < total,
+			HasPrev: filter.Page > 1,
+		}, nil
+	}
+
+	if filter.PageSize == 0 {
+		filter.PageSize = defaultPageSize
+	}
+	// one extra task tells whether there is a page beyond this one
+	pageSize := int(filter.PageSize)
+	filter.PageSize++
+	tasks, total, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	page := &model.TaskPage{Tasks: tasks}
+	if !filter.SkipTotal {
+		page.Total = &total
+	}
+	if filter.After != nil {
+		page.HasPrev = true
+		if len(tasks) > pageSize {
+			page.Tasks, page.HasNext = tasks[:pageSize], true
+		}
+	} else {
+		page.HasNext = true
+		if len(tasks) > pageSize {
+			page.Tasks, page.HasPrev = tasks[len(tasks)-pageSize:], true
+		}
+	}
+	return page, nil
 }
 
-func (s *TaskService) Update(ctx context.Context, id string, task *model.UpdateTask) (*model.Task, error) {
-	return s.repo.Update(ctx, id, task)
+// ListInProject returns a page of the tasks of the project matching filter, whoever owns them
+func (s *TaskService) ListInProject(ctx context.Context, projectID string, filter *model.TaskFilter) (*model.TaskPage, error) {
+	ctx, span := tracing.Start(ctx, "TaskService.ListInProject")
+	defer span.End()
+
+	userID, err := ownerScope(ctx, auth.PermTaskRead, auth.PermTaskReadAll)
+	if err != nil {
+		return nil, err
+	}
+	project, err := s.projects.GetByID(ctx, userID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if project == nil {
+		return nil, utils.NoEntryError
+	}
+	filter.ProjectID = &project.ID
+	return s.List(ctx, filter)
+}
+
+// ListMyWork returns a page of the tasks the caller created or is assigned to, among the ones they can see
+func (s *TaskService) ListMyWork(ctx context.Context, filter *model.TaskFilter) (*model.TaskPage, error) {
+	ctx, span := tracing.Start(ctx, "TaskService.ListMyWork")
+	defer span.End()
+
+	identity, err := authorize(ctx, auth.PermTaskRead)
+	if err != nil {
+		return nil, err
+	}
+	filter.InvolvedID = &identity.UserID
+	return s.List(ctx, filter)
+}
+
+// Update changes the given fields of the task. A blocked task can only be started with force.
+func (s *TaskService) Update(ctx context.Context, id string, task *model.UpdateTask, force bool) (*model.Task, error) {
+	ctx, span := tracing.Start(ctx, "TaskService.Update")
+	defer span.End()
+
+	userID, err := ownerScope(ctx, auth.PermTaskWrite, auth.PermTaskWriteAll)
+	if err != nil {
+		return nil, err
+	}
+	if task.Labels != nil {
+		labels, err := labelNames(*task.Labels)
+		if err != nil {
+			return nil, err
+		}
+		task.Labels = &labels
+	}
+	task.Resolution = strings.TrimSpace(task.Resolution)
+	var existing *model.Task
+	if task.StartAt != nil || task.DueAt != nil || task.Status != "" {
+		existing, err = s.repo.GetByID(ctx, userID, id)
+		if err != nil {
+			return nil, err
+		}
+		if existing == nil {
+			return nil, utils.NoEntryError
+		}
+	}
+	if task.Status != "" {
+		workflow, err := s.workflow(ctx, existing.UserID, existing.WorkflowID)
+		if err != nil {
+			return nil, err
+		}
+		if task.Category, err = statusCategory(workflow, task.Status); err != nil {
+			return nil, err
+		}
+		if err := s.checkTransition(existing, task); err != nil {
+			return nil, err
+		}
+		// the repository refuses the update if another one moved the task meanwhile
+		task.FromStatus = existing.Status
+	}
+	if s.checksChildren(task.Category) {
+		counts, err := s.repo.CountChildren(ctx, existing.ID)
+		if err != nil {
+			return nil, err
+		}
+		for category, count := range counts {
+			if category != model.CategoryDone && count > 0 {
+				return nil, fmt.Errorf("%w: the task has subtasks that aren't completed", utils.ConflictError)
+			}
+		}
+	}
+	starts := task.Category == model.CategoryDoing && existing.Category != model.CategoryDoing
+	if starts && !force && existing.Blocked {
+		return nil, fmt.Errorf("%w: the task is blocked by tasks that aren't completed, force to start it anyway", utils.ConflictError)
+	}
+	if task.StartAt != nil || task.DueAt != nil {
+		task.StartAt, task.DueAt = utc(task.StartAt), utc(task.DueAt)
+		startAt, dueAt := existing.StartAt, existing.DueAt
+		if task.StartAt != nil {
+			startAt = task.StartAt
+		}
+		if task.DueAt != nil {
+			dueAt = task.DueAt
+		}
+		if err := validateDates(startAt, dueAt); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.Update(ctx, userID, id, task)
 }
 
 func (s *TaskService) Delete(ctx context.Context, id string) error {
-	return s.repo.Delete(ctx, id)
+	ctx, span := tracing.Start(ctx, "TaskService.Delete")
+	defer span.End()
+
+	userID, err := ownerScope(ctx, auth.PermTaskWrite, auth.PermTaskWriteAll)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, userID, id); err != nil {
+		return err
+	}
+	logger.FromContext(ctx).Info("task deleted", "task_id", id)
+	return nil
+}
+
+// Progress fills in the progress of the task's direct subtasks
+func (s *TaskService) Progress(ctx context.Context, task *model.Task) error {
+	ctx, span := tracing.Start(ctx, "TaskService.Progress")
+	defer span.End()
+
+	if _, err := authorize(ctx, auth.PermTaskRead); err != nil {
+		return err
+	}
+	counts, err := s.repo.CountChildren(ctx, task.ID)
+	if err != nil {
+		return err
+	}
+	progress := &model.TaskProgress{Completed: counts[model.CategoryDone]}
+	for _, count := range counts {
+		progress.Children += count
+	}
+	if progress.Children > 0 {
+		progress.Percent = progress.Completed * 100 / progress.Children
+	}
+	task.Progress = progress
+	return nil
+}
+
+// Subtree returns the task with all its subtasks nested under it
+func (s *TaskService) Subtree(ctx context.Context, id string) (*model.TaskTree, error) {
+	ctx, span := tracing.Start(ctx, "TaskService.Subtree")
+	defer span.End()
+
+	userID, err := ownerScope(ctx, auth.PermTaskRead, auth.PermTaskReadAll)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := s.repo.Subtree(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(tasks) == 0 {
+		return nil, utils.NoEntryError
+	}
+
+	nodes := make(map[uint]*model.TaskTree, len(tasks))
+	for _, task := range tasks {
+		nodes[task.ID] = &model.TaskTree{Task: task, Children: []*model.TaskTree{}}
+	}
+	// the subtasks come ordered by id, so are the children of each node
+	for _, task := range tasks[1:] {
+		parent := nodes[*task.ParentID]
+		parent.Children = append(parent.Children, nodes[task.ID])
+	}
+	return nodes[tasks[0].ID], nil
+}
+
+// Move puts the task under another parent, or at the top level with a nil parentID
+func (s *TaskService) Move(ctx context.Context, id string, parentID *uint) (*model.Task, error) {
+	ctx, span := tracing.Start(ctx, "TaskService.Move")
+	defer span.End()
+
+	userID, err := ownerScope(ctx, auth.PermTaskWrite, auth.PermTaskWriteAll)
+	if err != nil {
+		return nil, err
+	}
+	task, err := s.repo.Move(ctx, userID, id, parentID)
+	if err != nil {
+		return nil, err
+	}
+	log := logger.FromContext(ctx)
+	if parentID != nil {
+		log = log.With("parent_id", *parentID)
+	}
+	log.Info("task moved", "task_id", task.ID)
+	return task, nil
+}
+
+// SetProject moves the task with its subtasks to the project, a nil projectID takes them out of their project
+func (s *TaskService) SetProject(ctx context.Context, id string, projectID *uint) (*model.Task, error) {
+	ctx, span := tracing.Start(ctx, "TaskService.SetProject")
+	defer span.End()
+
+	userID, err := ownerScope(ctx, auth.PermTaskWrite, auth.PermTaskWriteAll)
+	if err != nil {
+		return nil, err
+	}
+	if projectID != nil {
+		if _, err := s.project(ctx, userID, *projectID); err != nil {
+			return nil, err
+		}
+	}
+	task, err := s.repo.SetProject(ctx, userID, id, projectID)
+	if err != nil {
+		return nil, err
+	}
+	log := logger.FromContext(ctx)
+	if projectID != nil {
+		log = log.With("project_id", *projectID)
+	}
+	log.Info("task project changed", "task_id", task.ID)
+	return task, nil
+}
+
+// AddDependency blocks the task until the task blockedByID is completed
+func (s *TaskService) AddDependency(ctx context.Context, id string, blockedByID uint) (*model.Task, error) {
+	ctx, span := tracing.Start(ctx, "TaskService.AddDependency")
+	defer span.End()
+
+	userID, err := ownerScope(ctx, auth.PermTaskWrite, auth.PermTaskWriteAll)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.AddDependency(ctx, userID, id, blockedByID); err != nil {
+		return nil, err
+	}
+	logger.FromContext(ctx).Info("task dependency added", "task_id", id, "blocked_by_id", blockedByID)
+	task, err := s.repo.GetByID(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if task == nil {
+		return nil, utils.NoEntryError
+	}
+	return task, nil
+}
+
+func (s *TaskService) RemoveDependency(ctx context.Context, id string, blockedByID uint) error {
+	ctx, span := tracing.Start(ctx, "TaskService.RemoveDependency")
+	defer span.End()
+
+	userID, err := ownerScope(ctx, auth.PermTaskWrite, auth.PermTaskWriteAll)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.RemoveDependency(ctx, userID, id, blockedByID); err != nil {
+		return err
+	}
+	logger.FromContext(ctx).Info("task dependency removed", "task_id", id, "blocked_by_id", blockedByID)
+	return nil
+}
+
+// Assign adds the user to the assignees of the task, the change is recorded in its history
+func (s *TaskService) Assign(ctx context.Context, id string, assigneeID uint) (*model.Task, error) {
+	ctx, span := tracing.Start(ctx, "TaskService.Assign")
+	defer span.End()
+
+	userID, err := ownerScope(ctx, auth.PermTaskWrite, auth.PermTaskWriteAll)
+	if err != nil {
+		return nil, err
+	}
+	if assigneeID == 0 {
+		return nil, fmt.Errorf("%w: user_id is required", utils.InvalidInputError)
+	}
+	identity, _ := auth.FromContext(ctx)
+	if err := s.repo.Assign(ctx, userID, id, assigneeID, identity.UserID); err != nil {
+		return nil, err
+	}
+	logger.FromContext(ctx).Info("task assigned", "task_id", id, "assignee_id", assigneeID)
+	task, err := s.repo.GetByID(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if task == nil {
+		return nil, utils.NoEntryError
+	}
+	return task, nil
+}
+
+// Unassign removes the user from the assignees of the task, the change is recorded in its history
+func (s *TaskService) Unassign(ctx context.Context, id string, assigneeID uint) error {
+	ctx, span := tracing.Start(ctx, "TaskService.Unassign")
+	defer span.End()
+
+	userID, err := ownerScope(ctx, auth.PermTaskWrite, auth.PermTaskWriteAll)
+	if err != nil {
+		return err
+	}
+	identity, _ := auth.FromContext(ctx)
+	if err := s.repo.Unassign(ctx, userID, id, assigneeID, identity.UserID); err != nil {
+		return err
+	}
+	logger.FromContext(ctx).Info("task unassigned", "task_id", id, "assignee_id", assigneeID)
+	return nil
+}
+
+// History returns the recorded changes of the task oldest first
+func (s *TaskService) History(ctx context.Context, id string) ([]*model.TaskEvent, error) {
+	ctx, span := tracing.Start(ctx, "TaskService.History")
+	defer span.End()
+
+	userID, err := ownerScope(ctx, auth.PermTaskRead, auth.PermTaskReadAll)
+	if err != nil {
+		return nil, err
+	}
+	history, err := s.repo.History(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if history == nil {
+		return nil, utils.NoEntryError
+	}
+	return history, nil
+}
+
+// Graph returns the tasks the task waits for and the tasks waiting for it, directly or not
+func (s *TaskService) Graph(ctx context.Context, id string) (*model.TaskGraph, error) {
+	ctx, span := tracing.Start(ctx, "TaskService.Graph")
+	defer span.End()
+
+	userID, err := ownerScope(ctx, auth.PermTaskRead, auth.PermTaskReadAll)
+	if err != nil {
+		return nil, err
+	}
+	graph, err := s.repo.Graph(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if graph == nil {
+		return nil, utils.NoEntryError
+	}
+	return graph, nil
+}
+
+// checksChildren tells whether the subtasks must be done before moving to a status of the category
+func (s *TaskService) checksChildren(category model.StatusCategory) bool {
+	return s.opts.RequireChildrenCompleted && category == model.CategoryDone
+}
+
+// workflow returns the workflow of userID with the id, the default one for a nil id
+func (s *TaskService) workflow(ctx context.Context, userID uint, id *uint) (*model.Workflow, error) {
+	if id == nil {
+		return model.DefaultWorkflow, nil
+	}
+	workflow, err := s.workflows.GetByID(ctx, userID, strconv.FormatUint(uint64(*id), 10))
+	if err != nil {
+		return nil, err
+	}
+	if workflow == nil {
+		return nil, fmt.Errorf("%w: workflow %d not found", utils.InvalidInputError, *id)
+	}
+	return workflow, nil
+}
+
+// project returns the project with the id userID is a member of
+func (s *TaskService) project(ctx context.Context, userID, id uint) (*model.Project, error) {
+	project, err := s.projects.GetByID(ctx, userID, strconv.FormatUint(uint64(id), 10))
+	if err != nil {
+		return nil, err
+	}
+	if project == nil {
+		return nil, fmt.Errorf("%w: project %d not found", utils.InvalidInputError, id)
+	}
+	return project, nil
+}
+
+// statusCategory returns the category of the status in the workflow
+func statusCategory(workflow *model.Workflow, status model.TaskStatus) (model.StatusCategory, error) {
+	s := workflow.Status(status)
+	if s == nil {
+		return "", fmt.Errorf("%w: status %q isn't part of the %q workflow", utils.InvalidInputError, status, workflow.Name)
+	}
+	return s.Category, nil
+}
+
+// authorize returns the authenticated caller if its role grants perm
+func authorize(ctx context.Context, perm auth.Permission) (*auth.Identity, error) {
+	identity, ok := auth.FromContext(ctx)
+	if !ok || identity.UserID == 0 {
+		return nil, utils.UnauthenticatedError
+	}
+	if !identity.Can(perm) {
+		return nil, utils.ForbiddenError
+	}
+	return identity, nil
+}
+
+// ownerScope returns the user whose tasks the caller may access with perm,
+// callers also holding allPerm get repository.AllUsers
+func ownerScope(ctx context.Context, perm, allPerm auth.Permission) (uint, error) {
+	identity, err := authorize(ctx, perm)
+	if err != nil {
+		return 0, err
+	}
+	if identity.Can(allPerm) {
+		return repository.AllUsers, nil
+	}
+	return identity.UserID, nil
+}
+
+// utc converts t to UTC with the microsecond precision the databases keep
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	converted := t.UTC().Truncate(time.Microsecond)
+	return &converted
+}
+
+func validateDates(startAt, dueAt *time.Time) error {
+	if startAt != nil && dueAt != nil && dueAt.Before(*startAt) {
+		return fmt.Errorf("%w: due_at cannot be before start_at", utils.InvalidInputError)
+	}
+	return nil
 }
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+)
+
+// FieldResolution is the update field a transition can require
+const FieldResolution = "resolution"
+
+// TaskTransition allows moving a task of the default workflow from one status
+// to another, Requires lists the update fields that have to be set for the move.
+// Tasks of custom workflows follow the transitions between the categories of
+// the statuses, each built-in status standing for its category.
+type TaskTransition struct {
+	From     model.TaskStatus
+	To       model.TaskStatus
+	Requires []string
+}
+
+// DefaultTransitions is used when TaskOptions.Transitions is nil, a completed
+// task can only be reopened by starting it again and skipping straight to
+// completed needs a resolution
+var DefaultTransitions = []TaskTransition{
+	{From: model.StatusPending, To: model.StatusInProcess},
+	{From: model.StatusPending, To: model.StatusCompleted, Requires: []string{FieldResolution}},
+	{From: model.StatusInProcess, To: model.StatusPending},
+	{From: model.StatusInProcess, To: model.StatusCompleted},
+	{From: model.StatusCompleted, To: model.StatusInProcess},
+}
+
+// ParseTransitions reads a comma separated list of from>to pairs, each
+// optionally followed by +field for every field the move requires, e.g.
+// "pending>in_process,in_process>completed+resolution"
+func ParseTransitions(s string) ([]TaskTransition, error) {
+	var transitions []TaskTransition
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		fields := strings.Split(entry, "+")
+		from, to, ok := strings.Cut(fields[0], ">")
+		if !ok {
+			return nil, fmt.Errorf("transition %q isn't of the form from>to", entry)
+		}
+		transition := TaskTransition{
+			From: model.TaskStatus(strings.TrimSpace(from)),
+			To:   model.TaskStatus(strings.TrimSpace(to)),
+		}
+		for _, status := range []model.TaskStatus{transition.From, transition.To} {
+			if model.DefaultWorkflow.Status(status) == nil {
+				return nil, fmt.Errorf("transition %q has unknown status %q", entry, status)
+			}
+		}
+		for _, field := range fields[1:] {
+			field = strings.TrimSpace(field)
+			if field != FieldResolution {
+				return nil, fmt.Errorf("transition %q requires unknown field %q", entry, field)
+			}
+			transition.Requires = append(transition.Requires, field)
+		}
+		transitions = append(transitions, transition)
+	}
+	return transitions, nil
+}
+
+func (s *TaskService) transitions() []TaskTransition {
+	if s.opts.Transitions == nil {
+		return DefaultTransitions
+	}
+	return s.opts.Transitions
+}
+
+// checkTransition reports whether the update may move the task to another status,
+// the category of the update must be set. Tasks of custom workflows move freely
+// between statuses of the same category.
+func (s *TaskService) checkTransition(existing *model.Task, task *model.UpdateTask) error {
+	if task.Status == "" || task.Status == existing.Status {
+		return nil
+	}
+	// the states the transitions go between, statuses or categories
+	from, to := string(existing.Status), string(task.Status)
+	state := func(status model.TaskStatus) string { return string(status) }
+	if existing.WorkflowID != nil {
+		if existing.Category == task.Category {
+			return nil
+		}
+		from, to = string(existing.Category), string(task.Category)
+		state = func(status model.TaskStatus) string { return string(model.DefaultCategory(status)) }
+	}
+
+	var allowed []string
+	for _, transition := range s.transitions() {
+		if state(transition.From) != from {
+			continue
+		}
+		if state(transition.To) != to {
+			allowed = append(allowed, state(transition.To))
+			continue
+		}
+		for _, field := range transition.Requires {
+			if field == FieldResolution && task.Resolution == "" {
+				return fmt.Errorf("%w: %s is required to move a task from %s to %s", utils.UnprocessableError, field, from, to)
+			}
+		}
+		return nil
+	}
+	if len(allowed) == 0 {
+		return fmt.Errorf("%w: a task can't be moved out of %s", utils.ConflictError, from)
+	}
+	slices.Sort(allowed)
+	return fmt.Errorf("%w: a task can't be moved from %s to %s, allowed: %s", utils.ConflictError, from, to, strings.Join(allowed, ", "))
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/akhilbidhuri/taskkr/internal/auth"
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/repository"
+	"github.com/akhilbidhuri/taskkr/internal/repository/memory"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+)
+
+var memberCtx = auth.NewContext(context.Background(), &auth.Identity{UserID: 1, Subject: "1", Role: auth.RoleMember})
+
+func newTaskService(opts TaskOptions) (*TaskService, repository.TaskRepository) {
+	db := memory.NewDB()
+	tasks := memory.NewTaskRepository(db)
+	return NewTaskService(tasks, memory.NewWorkflowRepository(db), memory.NewProjectRepository(db), opts), tasks
+}
+
+// createInStatus stores a task of member in status, bypassing the service checks
+func createInStatus(t *testing.T, tasks repository.TaskRepository, status model.TaskStatus) string {
+	t.Helper()
+	task := &model.Task{UserID: 1, Title: "Task", Status: status}
+	if err := tasks.Create(context.Background(), task); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return strconv.FormatUint(uint64(task.ID), 10)
+}
+
+func TestParseTransitions(t *testing.T) {
+	got, err := ParseTransitions(" pending>in_process , in_process>completed+resolution,")
+	if err != nil {
+		t.Fatalf("ParseTransitions: %v", err)
+	}
+	want := []TaskTransition{
+		{From: model.StatusPending, To: model.StatusInProcess},
+		{From: model.StatusInProcess, To: model.StatusCompleted, Requires: []string{FieldResolution}},
+	}
+	if len(got) != len(want) || got[0].From != want[0].From || got[0].To != want[0].To || len(got[0].Requires) != 0 ||
+		got[1].From != want[1].From || got[1].To != want[1].To || len(got[1].Requires) != 1 || got[1].Requires[0] != FieldResolution {
+		t.Errorf("ParseTransitions = %+v, want %+v", got, want)
+	}
+	if got, err := ParseTransitions(""); err != nil || got != nil {
+		t.Errorf("ParseTransitions(\"\") = %v, %v, want nothing", got, err)
+	}
+
+	for _, s := range []string{
+		"pending",
+		"pending-in_process",
+		"pending>archived",
+		"draft>completed",
+		"pending>completed+reason",
+		"pending>in_process,in_process",
+	} {
+		if _, err := ParseTransitions(s); err == nil {
+			t.Errorf("ParseTransitions(%q) succeeded, want an error", s)
+		}
+	}
+}
+
+func TestTaskServiceUpdateTransitions(t *testing.T) {
+	tests := []struct {
+		name       string
+		from       model.TaskStatus
+		to         model.TaskStatus
+		resolution string
+		want       error
+	}{
+		{name: "start", from: model.StatusPending, to: model.StatusInProcess},
+		{name: "complete with resolution", from: model.StatusPending, to: model.StatusCompleted, resolution: "duplicate"},
+		{name: "complete without resolution", from: model.StatusPending, to: model.StatusCompleted, want: utils.UnprocessableError},
+		{name: "complete with blank resolution", from: model.StatusPending, to: model.StatusCompleted, resolution: "  ", want: utils.UnprocessableError},
+		{name: "stop", from: model.StatusInProcess, to: model.StatusPending},
+		{name: "finish", from: model.StatusInProcess, to: model.StatusCompleted},
+		{name: "reopen", from: model.StatusCompleted, to: model.StatusInProcess},
+		{name: "reset completed", from: model.StatusCompleted, to: model.StatusPending, want: utils.ConflictError},
+		{name: "same status", from: model.StatusCompleted, to: model.StatusCompleted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, tasks := newTaskService(TaskOptions{})
+			id := createInStatus(t, tasks, tt.from)
+			got, err := s.Update(memberCtx, id, &model.UpdateTask{Status: tt.to, Resolution: tt.resolution}, false)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Update(%s -> %s) = %v, want %v", tt.from, tt.to, err, tt.want)
+			}
+			if tt.want == nil && got.Status != tt.to {
+				t.Errorf("status = %q, want %q", got.Status, tt.to)
+			}
+		})
+	}
+}
+
+func TestTaskServiceCustomTransitions(t *testing.T) {
+	transitions, err := ParseTransitions("pending>in_process,in_process>completed+resolution")
+	if err != nil {
+		t.Fatalf("ParseTransitions: %v", err)
+	}
+	s, tasks := newTaskService(TaskOptions{Transitions: transitions})
+
+	tests := []struct {
+		from model.TaskStatus
+		to   model.TaskStatus
+		want error
+	}{
+		{from: model.StatusPending, to: model.StatusInProcess},
+		{from: model.StatusPending, to: model.StatusCompleted, want: utils.ConflictError},
+		{from: model.StatusInProcess, to: model.StatusCompleted, want: utils.UnprocessableError},
+		{from: model.StatusCompleted, to: model.StatusInProcess, want: utils.ConflictError},
+	}
+	for _, tt := range tests {
+		id := createInStatus(t, tasks, tt.from)
+		if _, err := s.Update(memberCtx, id, &model.UpdateTask{Status: tt.to}, false); !errors.Is(err, tt.want) {
+			t.Errorf("Update(%s -> %s) = %v, want %v", tt.from, tt.to, err, tt.want)
+		}
+	}
+}
+
+func TestTaskServiceCreateTransitions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts TaskOptions
+		task model.Task
+		want error
+	}{
+		{name: "initial status", task: model.Task{Title: "New"}},
+		{name: "started", task: model.Task{Title: "New", Status: model.StatusInProcess}},
+		{name: "completed with resolution", task: model.Task{Title: "New", Status: model.StatusCompleted, Resolution: "done elsewhere"}},
+		{name: "completed without resolution", task: model.Task{Title: "New", Status: model.StatusCompleted}, want: utils.UnprocessableError},
+		{
+			name: "not reachable from the initial status",
+			opts: TaskOptions{Transitions: []TaskTransition{{From: model.StatusPending, To: model.StatusInProcess}}},
+			task: model.Task{Title: "New", Status: model.StatusCompleted, Resolution: "done"},
+			want: utils.ConflictError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTaskService(tt.opts)
+			task := tt.task
+			if err := s.Create(memberCtx, &task); !errors.Is(err, tt.want) {
+				t.Fatalf("Create(%s) = %v, want %v", tt.task.Status, err, tt.want)
+			}
+			if tt.want == nil && task.ID == 0 {
+				t.Errorf("Create(%s) didn't store the task", tt.task.Status)
+			}
+		})
+	}
+}
+
+// racingRepository completes the task right after the service reads it, like a
+// concurrent request would
+type racingRepository struct {
+	repository.TaskRepository
+}
+
+func (r racingRepository) GetByID(ctx context.Context, userID uint, id string) (*model.Task, error) {
+	task, err := r.TaskRepository.GetByID(ctx, userID, id)
+	if err != nil || task == nil || task.Status != model.StatusPending {
+		return task, err
+	}
+	_, err = r.TaskRepository.Update(ctx, userID, id, &model.UpdateTask{Status: model.StatusCompleted, Resolution: "won the race"})
+	return task, err
+}
+
+func TestTaskServiceUpdateRace(t *testing.T) {
+	db := memory.NewDB()
+	tasks := memory.NewTaskRepository(db)
+	s := NewTaskService(racingRepository{tasks}, memory.NewWorkflowRepository(db), memory.NewProjectRepository(db), TaskOptions{})
+	id := createInStatus(t, tasks, model.StatusPending)
+
+	// pending -> in_process is allowed, completed -> in_process too but isn't what was checked
+	if _, err := s.Update(memberCtx, id, &model.UpdateTask{Status: model.StatusInProcess}, false); !errors.Is(err, utils.ConflictError) {
+		t.Errorf("Update after a concurrent move = %v, want ConflictError", err)
+	}
+	got, _ := tasks.GetByID(context.Background(), 1, id)
+	if got.Status != model.StatusCompleted {
+		t.Errorf("status = %q, want the concurrent move kept", got.Status)
+	}
+}
+
+func TestTaskServiceWorkflowTransitions(t *testing.T) {
+	review := []model.WorkflowStatus{
+		{Name: "todo", Category: model.CategoryTodo},
+		{Name: "review", Category: model.CategoryDoing},
+		{Name: "qa", Category: model.CategoryDoing},
+		{Name: "shipped", Category: model.CategoryDone},
+	}
+	categories := map[model.TaskStatus]model.StatusCategory{}
+	for _, status := range review {
+		categories[status.Name] = status.Category
+	}
+	onlyStart := []TaskTransition{{From: model.StatusPending, To: model.StatusInProcess}}
+
+	tests := []struct {
+		name       string
+		opts       TaskOptions
+		from       model.TaskStatus
+		to         model.TaskStatus
+		resolution string
+		want       error
+	}{
+		{name: "start", from: "todo", to: "review"},
+		{name: "within a category", from: "review", to: "qa"},
+		{name: "finish", from: "qa", to: "shipped"},
+		{name: "ship without resolution", from: "todo", to: "shipped", want: utils.UnprocessableError},
+		{name: "ship with resolution", from: "todo", to: "shipped", resolution: "trivial"},
+		{name: "reopen", from: "shipped", to: "review"},
+		{name: "reset shipped", from: "shipped", to: "todo", want: utils.ConflictError},
+		{name: "custom table", opts: TaskOptions{Transitions: onlyStart}, from: "review", to: "shipped", want: utils.ConflictError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := memory.NewDB()
+			tasks, workflows := memory.NewTaskRepository(db), memory.NewWorkflowRepository(db)
+			s := NewTaskService(tasks, workflows, memory.NewProjectRepository(db), tt.opts)
+			workflow := &model.Workflow{UserID: 1, Name: "Code review", Statuses: review}
+			if err := workflows.Create(context.Background(), workflow); err != nil {
+				t.Fatalf("Create workflow: %v", err)
+			}
+			task := &model.Task{UserID: 1, Title: "Task", WorkflowID: &workflow.ID, Status: tt.from, Category: categories[tt.from]}
+			if err := tasks.Create(context.Background(), task); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+
+			got, err := s.Update(memberCtx, strconv.FormatUint(uint64(task.ID), 10), &model.UpdateTask{Status: tt.to, Resolution: tt.resolution}, false)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Update(%s -> %s) = %v, want %v", tt.from, tt.to, err, tt.want)
+			}
+			if tt.want == nil && (got.Status != tt.to || got.Category != categories[tt.to]) {
+				t.Errorf("status = %q (%s), want %q", got.Status, got.Category, tt.to)
+			}
+		})
+	}
+
+	// new tasks move from the first todo status too
+	db := memory.NewDB()
+	workflows := memory.NewWorkflowRepository(db)
+	s := NewTaskService(memory.NewTaskRepository(db), workflows, memory.NewProjectRepository(db), TaskOptions{})
+	workflow := &model.Workflow{UserID: 1, Name: "Code review", Statuses: review}
+	if err := workflows.Create(context.Background(), workflow); err != nil {
+		t.Fatalf("Create workflow: %v", err)
+	}
+	if err := s.Create(memberCtx, &model.Task{Title: "Hotfix", WorkflowID: &workflow.ID, Status: "shipped"}); !errors.Is(err, utils.UnprocessableError) {
+		t.Errorf("Create shipped without resolution = %v, want UnprocessableError", err)
+	}
+	if err := s.Create(memberCtx, &model.Task{Title: "Hotfix", WorkflowID: &workflow.ID, Status: "qa"}); err != nil {
+		t.Errorf("Create in qa: %v", err)
+	}
+}
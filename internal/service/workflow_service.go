@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/akhilbidhuri/taskkr/internal/auth"
+	"github.com/akhilbidhuri/taskkr/internal/logger"
+	"github.com/akhilbidhuri/taskkr/internal/model"
+	"github.com/akhilbidhuri/taskkr/internal/repository"
+	"github.com/akhilbidhuri/taskkr/internal/tracing"
+	"github.com/akhilbidhuri/taskkr/internal/utils"
+)
+
+// maxWorkflowName is the size of the workflows.name column
+const maxWorkflowName = 50
+
+// WorkflowService manages the workflows of a user, tasks pick theirs through TaskService
+type WorkflowService struct {
+	repo repository.WorkflowRepository
+}
+
+func NewWorkflowService(repo repository.WorkflowRepository) *WorkflowService {
+	return &WorkflowService{repo: repo}
+}
+
+func (s *WorkflowService) Create(ctx context.Context, workflow *model.Workflow) error {
+	ctx, span := tracing.Start(ctx, "WorkflowService.Create")
+	defer span.End()
+
+	identity, err := authorize(ctx, auth.PermTaskWrite)
+	if err != nil {
+		return err
+	}
+	name, err := workflowName(workflow.Name)
+	if err != nil {
+		return err
+	}
+	if err := validateStatuses(workflow.Statuses); err != nil {
+		return err
+	}
+	workflow.ID = 0
+	workflow.UserID = identity.UserID
+	workflow.Name = name
+	workflow.CreatedAt, workflow.UpdatedAt = time.Time{}, time.Time{}
+	if err := s.repo.Create(ctx, workflow); err != nil {
+		return err
+	}
+	logger.FromContext(ctx).Info("workflow created", "workflow_id", workflow.ID)
+	return nil
+}
+
+func (s *WorkflowService) GetByID(ctx context.Context, id string) (*model.Workflow, error) {
+	ctx, span := tracing.Start(ctx, "WorkflowService.GetByID")
+	defer span.End()
+
+	userID, err := ownerScope(ctx, auth.PermTaskRead, auth.PermTaskReadAll)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, userID, id)
+}
+
+func (s *WorkflowService) List(ctx context.Context) ([]*model.Workflow, error) {
+	ctx, span := tracing.Start(ctx, "WorkflowService.List")
+	defer span.End()
+
+	userID, err := ownerScope(ctx, auth.PermTaskRead, auth.PermTaskReadAll)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.List(ctx, userID)
+}
+
+// Update renames the workflow or replaces its statuses, tasks follow the new categories
+func (s *WorkflowService) Update(ctx context.Context, id string, workflow *model.UpdateWorkflow) (*model.Workflow, error) {
+	ctx, span := tracing.Start(ctx, "WorkflowService.Update")
+	defer span.End()
+
+	userID, err := ownerScope(ctx, auth.PermTaskWrite, auth.PermTaskWriteAll)
+	if err != nil {
+		return nil, err
+	}
+	if workflow.Name != "" {
+		if workflow.Name, err = workflowName(workflow.Name); err != nil {
+			return nil, err
+		}
+	}
+	if workflow.Statuses != nil {
+		if err := validateStatuses(*workflow.Statuses); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.Update(ctx, userID, id, workflow)
+}
+
+// Delete removes a workflow no task uses anymore
+func (s *WorkflowService) Delete(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "WorkflowService.Delete")
+	defer span.End()
+
+	userID, err := ownerScope(ctx, auth.PermTaskWrite, auth.PermTaskWriteAll)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, userID, id); err != nil {
+		return err
+	}
+	logger.FromContext(ctx).Info("workflow deleted", "workflow_id", id)
+	return nil
+}
+
+// workflowName trims the name and checks it fits the column
+func workflowName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		return "", fmt.Errorf("%w: workflow name cannot be empty", utils.InvalidInputError)
+	case utf8.RuneCountInString(name) > maxWorkflowName:
+		return "", fmt.Errorf("%w: workflow name cannot be longer than %d characters", utils.InvalidInputError, maxWorkflowName)
+	}
+	return name, nil
+}
+
+// validateStatuses checks the statuses are unique and well formed, and that
+// tasks have a todo status to start in and a done status to end in
+func validateStatuses(statuses []model.WorkflowStatus) error {
+	seen := map[model.TaskStatus]bool{}
+	categories := map[model.StatusCategory]bool{}
+	for _, status := range statuses {
+		if !model.ValidStatusName(status.Name) {
+			return fmt.Errorf("%w: status %q must be up to 20 lowercase letters, digits or underscores, starting with a letter", utils.InvalidInputError, status.Name)
+		}
+		if !status.Category.IsValid() {
+			return fmt.Errorf("%w: status %q has unknown category %q, it must be todo, doing or done", utils.InvalidInputError, status.Name, status.Category)
+		}
+		if seen[status.Name] {
+			return fmt.Errorf("%w: status %q is listed twice", utils.InvalidInputError, status.Name)
+		}
+		seen[status.Name] = true
+		categories[status.Category] = true
+	}
+	if !categories[model.CategoryTodo] || !categories[model.CategoryDone] {
+		return fmt.Errorf("%w: a workflow needs at least a todo and a done status", utils.InvalidInputError)
+	}
+	return nil
+}
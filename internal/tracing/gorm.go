@@ -0,0 +1,76 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const spanKey = "taskkr:tracing_span"
+
+// db.system.name attributes of the supported databases
+var (
+	DBSystemPostgres = semconv.DBSystemNamePostgreSQL
+	DBSystemSQLite   = semconv.DBSystemNameSQLite
+)
+
+// InstrumentGorm creates a client span for every query run through db.
+// The SQL is recorded as built by GORM, with placeholders instead of the values.
+func InstrumentGorm(db *gorm.DB, system attribute.KeyValue) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("taskkr:tracing_start", startSpan("create", system)),
+		cb.Create().After("gorm:create").Register("taskkr:tracing_end", endSpan),
+		cb.Query().Before("gorm:query").Register("taskkr:tracing_start", startSpan("query", system)),
+		cb.Query().After("gorm:query").Register("taskkr:tracing_end", endSpan),
+		cb.Update().Before("gorm:update").Register("taskkr:tracing_start", startSpan("update", system)),
+		cb.Update().After("gorm:update").Register("taskkr:tracing_end", endSpan),
+		cb.Delete().Before("gorm:delete").Register("taskkr:tracing_start", startSpan("delete", system)),
+		cb.Delete().After("gorm:delete").Register("taskkr:tracing_end", endSpan),
+		cb.Row().Before("gorm:row").Register("taskkr:tracing_start", startSpan("row", system)),
+		cb.Row().After("gorm:row").Register("taskkr:tracing_end", endSpan),
+		cb.Raw().Before("gorm:raw").Register("taskkr:tracing_start", startSpan("raw", system)),
+		cb.Raw().After("gorm:raw").Register("taskkr:tracing_end", endSpan),
+	)
+}
+
+func startSpan(operation string, system attribute.KeyValue) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Statement == nil || db.Statement.Context == nil {
+			return
+		}
+		_, span := Start(db.Statement.Context, "gorm."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(system, semconv.DBOperationName(operation)),
+		)
+		db.InstanceSet(spanKey, span)
+	}
+}
+
+func endSpan(db *gorm.DB) {
+	v, ok := db.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	span, ok := v.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	if db.Statement.Table != "" {
+		span.SetAttributes(semconv.DBCollectionName(db.Statement.Table))
+	}
+	span.SetAttributes(
+		semconv.DBQueryText(db.Statement.SQL.String()),
+		attribute.Int64("db.response.affected_rows", db.Statement.RowsAffected),
+	)
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}
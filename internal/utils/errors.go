@@ -3,5 +3,13 @@ package utils
 import "errors"
 
 var (
-	NoEntryError = errors.New("No entry present")
+	NoEntryError         = errors.New("No entry present")
+	UnauthenticatedError = errors.New("No authenticated user")
+	ForbiddenError       = errors.New("Permission denied")
+	// InvalidInputError is wrapped by the validation errors of the services
+	InvalidInputError = errors.New("Invalid input")
+	// ConflictError is wrapped when a change clashes with the stored data
+	ConflictError = errors.New("Conflict")
+	// UnprocessableError is wrapped when a change is allowed but misses information it requires
+	UnprocessableError = errors.New("Unprocessable")
 )
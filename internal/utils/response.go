@@ -3,6 +3,8 @@ package utils
 import (
 	"encoding/json"
 	"net/http"
+
+	"github.com/akhilbidhuri/taskkr/internal/requestid"
 )
 
 // Response represents the standard structure for API responses
@@ -11,6 +13,8 @@ type Response struct {
 	Message string      `json:"message,omitempty"`
 	Data    interface{} `json:"data,omitempty"`
 	Error   string      `json:"error,omitempty"`
+	// RequestID is set on errors so they can be correlated with the logs
+	RequestID string `json:"request_id,omitempty"`
 }
 
 // JSON sends a JSON response with the given status code
@@ -20,11 +24,13 @@ func JSON(w http.ResponseWriter, statusCode int, resp Response) {
 	json.NewEncoder(w).Encode(resp)
 }
 
-// Error sends an error response with the given message and status code
-func Error(w http.ResponseWriter, statusCode int, message string, err error) {
+// Error sends an error response with the given message and status code, it
+// carries the id the RequestID middleware stored in the request's context
+func Error(w http.ResponseWriter, r *http.Request, statusCode int, message string, err error) {
 	response := Response{
-		Success: false,
-		Message: message,
+		Success:   false,
+		Message:   message,
+		RequestID: requestid.FromContext(r.Context()),
 	}
 	if err != nil {
 		response.Error = err.Error()
@@ -1 +0,0 @@
--- Not used, use for manual migrations
@@ -0,0 +1,18 @@
+// Package migrations embeds the versioned SQL migrations of each supported database.
+// Files are named <version>_<name>.up.sql and <version>_<name>.down.sql and every
+// change is added for all dialects with the same version. Tables created by the
+// former AutoMigrate setup are adopted as is.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed postgres/*.sql sqlite/*.sql
+var files embed.FS
+
+// For returns the migrations of the dialect, postgres or sqlite
+func For(dialect string) (fs.FS, error) {
+	return fs.Sub(files, dialect)
+}
@@ -0,0 +1,5 @@
+ALTER TABLE tasks ADD COLUMN start_at TIMESTAMPTZ;
+ALTER TABLE tasks ADD COLUMN due_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_due_at ON tasks (due_at);
+CREATE INDEX IF NOT EXISTS idx_tasks_user_id_due_at ON tasks (user_id, due_at);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS labels (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    BIGINT      NOT NULL,
+    name       VARCHAR(50) NOT NULL,
+    color      VARCHAR(7),
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_labels_user_id_name ON labels (user_id, name);
+
+CREATE TABLE IF NOT EXISTS task_labels (
+    task_id  BIGINT NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    label_id BIGINT NOT NULL REFERENCES labels (id) ON DELETE CASCADE,
+    PRIMARY KEY (task_id, label_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_labels_label_id ON task_labels (label_id);
@@ -0,0 +1,3 @@
+ALTER TABLE tasks ADD COLUMN parent_id BIGINT REFERENCES tasks (id);
+
+CREATE INDEX IF NOT EXISTS idx_tasks_parent_id ON tasks (parent_id);
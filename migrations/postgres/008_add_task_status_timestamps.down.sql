@@ -0,0 +1,3 @@
+ALTER TABLE tasks DROP COLUMN resolution;
+ALTER TABLE tasks DROP COLUMN completed_at;
+ALTER TABLE tasks DROP COLUMN started_at;
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_tasks_workflow_id;
+
+ALTER TABLE tasks DROP COLUMN category;
+ALTER TABLE tasks DROP COLUMN workflow_id;
+
+DROP TABLE IF EXISTS workflow_statuses;
+DROP TABLE IF EXISTS workflows;
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS task_events;
+DROP TABLE IF EXISTS task_assignees;
+
+DROP INDEX IF EXISTS idx_tasks_created_by;
+
+ALTER TABLE tasks DROP COLUMN created_by;
@@ -0,0 +1,27 @@
+ALTER TABLE tasks ADD COLUMN created_by BIGINT NOT NULL DEFAULT 0;
+
+-- tasks were always created by their owner so far
+UPDATE tasks SET created_by = user_id;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_created_by ON tasks (created_by);
+
+CREATE TABLE IF NOT EXISTS task_assignees (
+    task_id    BIGINT NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    user_id    BIGINT NOT NULL,
+    created_at TIMESTAMPTZ,
+    PRIMARY KEY (task_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_assignees_user_id ON task_assignees (user_id);
+
+-- task_events is the history of a task, for now the changes of its assignees
+CREATE TABLE IF NOT EXISTS task_events (
+    id          BIGSERIAL PRIMARY KEY,
+    task_id     BIGINT      NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    actor_id    BIGINT      NOT NULL,
+    type        VARCHAR(20) NOT NULL,
+    assignee_id BIGINT,
+    created_at  TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_events_task_id ON task_events (task_id);
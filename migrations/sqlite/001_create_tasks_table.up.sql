@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS tasks (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id     INTEGER      NOT NULL,
+    title       VARCHAR(255) NOT NULL,
+    description TEXT,
+    status      VARCHAR(20)  DEFAULT 'pending',
+    created_at  DATETIME,
+    updated_at  DATETIME,
+    deleted_at  DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_tasks_deleted_at ON tasks (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_tasks_user_id ON tasks (user_id);
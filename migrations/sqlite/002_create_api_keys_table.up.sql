@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    name         VARCHAR(255) NOT NULL,
+    prefix       VARCHAR(16)  NOT NULL,
+    key_hash     VARCHAR(64)  NOT NULL,
+    user_id      INTEGER      NOT NULL,
+    role         VARCHAR(20)  NOT NULL,
+    expires_at   DATETIME,
+    revoked_at   DATETIME,
+    last_used_at DATETIME,
+    usage_count  INTEGER      NOT NULL DEFAULT 0,
+    created_at   DATETIME,
+    updated_at   DATETIME
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_key_hash ON api_keys (key_hash);
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys (user_id);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_tasks_user_id_due_at;
+DROP INDEX IF EXISTS idx_tasks_due_at;
+
+ALTER TABLE tasks DROP COLUMN due_at;
+ALTER TABLE tasks DROP COLUMN start_at;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_tasks_user_id_priority;
+
+ALTER TABLE tasks DROP COLUMN priority;
@@ -0,0 +1,3 @@
+ALTER TABLE tasks ADD COLUMN priority VARCHAR(10) NOT NULL DEFAULT 'medium';
+
+CREATE INDEX IF NOT EXISTS idx_tasks_user_id_priority ON tasks (user_id, priority);
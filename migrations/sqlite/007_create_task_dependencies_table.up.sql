@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS task_dependencies (
+    task_id       INTEGER NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    blocked_by_id INTEGER NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    created_at    DATETIME,
+    PRIMARY KEY (task_id, blocked_by_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_dependencies_blocked_by_id ON task_dependencies (blocked_by_id);
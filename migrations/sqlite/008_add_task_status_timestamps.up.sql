@@ -0,0 +1,7 @@
+ALTER TABLE tasks ADD COLUMN started_at DATETIME;
+ALTER TABLE tasks ADD COLUMN completed_at DATETIME;
+ALTER TABLE tasks ADD COLUMN resolution TEXT;
+
+-- the best guess for tasks completed before the timestamps were recorded
+UPDATE tasks SET completed_at = updated_at WHERE status = 'completed';
+UPDATE tasks SET started_at = updated_at WHERE status = 'in_process';
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS workflows (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id    INTEGER     NOT NULL,
+    name       VARCHAR(50) NOT NULL,
+    created_at DATETIME,
+    updated_at DATETIME
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_workflows_user_id_name ON workflows (user_id, name);
+
+CREATE TABLE IF NOT EXISTS workflow_statuses (
+    workflow_id INTEGER     NOT NULL REFERENCES workflows (id) ON DELETE CASCADE,
+    name        VARCHAR(20) NOT NULL,
+    category    VARCHAR(10) NOT NULL,
+    position    INTEGER     NOT NULL,
+    PRIMARY KEY (workflow_id, name)
+);
+
+-- tasks without a workflow use the built-in pending, in_process and completed statuses
+ALTER TABLE tasks ADD COLUMN workflow_id INTEGER REFERENCES workflows (id) ON DELETE SET NULL;
+ALTER TABLE tasks ADD COLUMN category VARCHAR(10) NOT NULL DEFAULT 'todo';
+
+UPDATE tasks SET category = 'doing' WHERE status = 'in_process';
+UPDATE tasks SET category = 'done' WHERE status = 'completed';
+
+CREATE INDEX IF NOT EXISTS idx_tasks_workflow_id ON tasks (workflow_id);
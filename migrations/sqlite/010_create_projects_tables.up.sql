@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS projects (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id     INTEGER      NOT NULL,
+    name        VARCHAR(100) NOT NULL,
+    description TEXT,
+    created_at  DATETIME,
+    updated_at  DATETIME
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_projects_user_id_name ON projects (user_id, name);
+
+-- the owner of a project is stored as one of its members
+CREATE TABLE IF NOT EXISTS project_members (
+    project_id INTEGER     NOT NULL REFERENCES projects (id) ON DELETE CASCADE,
+    user_id    INTEGER     NOT NULL,
+    role       VARCHAR(10) NOT NULL,
+    created_at DATETIME,
+    PRIMARY KEY (project_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_project_members_user_id ON project_members (user_id);
+
+ALTER TABLE tasks ADD COLUMN project_id INTEGER REFERENCES projects (id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_project_id ON tasks (project_id);